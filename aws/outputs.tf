@@ -5,3 +5,20 @@ output "lbs" {
 output "prometheus_target" {
   value = var.expose_prometheus ? module.prometheus[0].prometheus_target : ""
 }
+
+output "validator" {
+  value = {
+    instance    = polkadot_failover.polkadot.validator_instance
+    group       = polkadot_failover.polkadot.validator_group
+    location    = polkadot_failover.polkadot.validator_location
+    detected_at = polkadot_failover.polkadot.validator_detected_at
+  }
+}
+
+output "validator_history" {
+  value = {
+    transitions = data.polkadot_validator_history.polkadot.transitions
+    overlaps    = data.polkadot_validator_history.polkadot.overlaps
+    timeline    = data.polkadot_validator_history.polkadot.timeline
+  }
+}
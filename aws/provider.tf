@@ -27,9 +27,16 @@ provider "aws" {
 }
 
 provider "polkadot" {
-  version    = "~> 0.1"
-  profile    = length(var.aws_profiles) > 0 ? element(var.aws_profiles, 2) : null
-  access_key = length(var.aws_access_keys) > 0 ? element(var.aws_access_keys, 2) : null
-  secret_key = length(var.aws_secret_keys) > 0 ? element(var.aws_secret_keys, 2) : null
-  regions    = var.aws_regions
+  version = "~> 0.1"
+
+  # each region may be deployed into a different account
+  dynamic "region" {
+    for_each = var.aws_regions
+    content {
+      name       = region.value
+      profile    = length(var.aws_profiles) > 0 ? element(var.aws_profiles, region.key) : null
+      access_key = length(var.aws_access_keys) > 0 ? element(var.aws_access_keys, region.key) : null
+      secret_key = length(var.aws_secret_keys) > 0 ? element(var.aws_secret_keys, region.key) : null
+    }
+  }
 }
@@ -162,15 +162,99 @@ variable "admin_email" {
 }
 
 variable "failover_mode" {
-  description = "Failover mode. Either 'single' or 'distributed'"
+  description = "Failover mode. Either 'single', 'standby' or 'distributed'"
   type        = string
   default     = "distributed"
   validation {
-    condition     = var.failover_mode == "single" || var.failover_mode == "distributed"
-    error_message = "The failover_mode must be one of 'single', 'distributed'."
+    condition     = contains(["single", "standby", "distributed"], var.failover_mode)
+    error_message = "The failover_mode must be one of 'single', 'standby', 'distributed'."
   }
 }
 
+variable "standby_count" {
+  description = "Number of warm standby instances kept besides the validator in 'standby' failover mode"
+  type        = number
+  default     = 2
+}
+
+variable "on_validator_unknown" {
+  description = "Action in 'single' and 'standby' failover modes if the validator has not been detected. Either 'abort', 'delete_all' or 'keep_all'"
+  type        = string
+  default     = "abort"
+  validation {
+    condition     = contains(["abort", "delete_all", "keep_all"], var.on_validator_unknown)
+    error_message = "The on_validator_unknown must be one of 'abort', 'delete_all', 'keep_all'."
+  }
+}
+
+variable "validator_detection" {
+  description = "Source the validator is detected with. Either 'metrics' (cloud monitoring metrics), 'rpc' (system_nodeRoles JSON-RPC calls to instances) or 'both' (metrics and JSON-RPC must agree)"
+  type        = string
+  default     = "metrics"
+  validation {
+    condition     = contains(["metrics", "rpc", "both"], var.validator_detection)
+    error_message = "The validator_detection must be one of 'metrics', 'rpc', 'both'."
+  }
+}
+
+variable "rpc_port" {
+  description = "Polkadot JSON-RPC HTTP port of instances used by 'rpc' and 'both' validator detection"
+  type        = number
+  default     = 9933
+}
+
+variable "metric_window" {
+  description = "Validator metric query window in seconds. The latest metric sample in the window is used"
+  type        = number
+  default     = 300
+}
+
+variable "metric_max_age" {
+  description = "Validator metric samples older than this number of seconds are unknown rather than validator. 0 disables the check"
+  type        = number
+  default     = 180
+}
+
+variable "metric_aggregation" {
+  description = "Statistic validator metric samples are aggregated with. One of 'maximum', 'minimum', 'average', 'sum'"
+  type        = string
+  default     = "maximum"
+
+  validation {
+    condition     = contains(["maximum", "minimum", "average", "sum"], var.metric_aggregation)
+    error_message = "The metric_aggregation must be one of 'maximum', 'minimum', 'average', 'sum'."
+  }
+}
+
+variable "validator_metric_value" {
+  description = "Aggregated validator metric value reported by the validator instance. Instances which do not validate report 0"
+  type        = number
+  default     = 1
+
+  validation {
+    condition     = var.validator_metric_value >= 1
+    error_message = "The validator_metric_value must be a positive number."
+  }
+}
+
+variable "validator_history_window" {
+  description = "Window in seconds of the validator history reported by validator_history output"
+  type        = number
+  default     = 3600
+}
+
+variable "consul_address" {
+  description = "Consul HTTP API address, e.g. http://10.0.0.10:8500. If set, the validator is cross-checked against the Consul lock holder and failover does not act when they disagree"
+  type        = string
+  default     = null
+}
+
+variable "failover_dry_run" {
+  description = "Run 'single' and 'standby' mode failover without deleting instances. Calculated instance numbers are kept in dry_run_failover_instances attribute"
+  type        = bool
+  default     = false
+}
+
 variable "validate_metric" {
   description = "Name of telegraf validate metric"
   type        = string
@@ -1,9 +1,31 @@
 resource "polkadot_failover" "polkadot" {
-  provider         = polkadot
-  locations        = var.gcp_regions
-  instances        = var.instance_count
-  prefix           = var.prefix
-  metric_name      = local.validator_metric_name
-  metric_namespace = var.metric_namespace
-  failover_mode    = var.failover_mode
+  provider               = polkadot
+  locations              = var.gcp_regions
+  instances              = var.instance_count
+  prefix                 = var.prefix
+  metric_name            = local.validator_metric_name
+  metric_namespace       = var.metric_namespace
+  failover_mode          = var.failover_mode
+  standby_count          = var.standby_count
+  on_validator_unknown   = var.on_validator_unknown
+  dry_run                = var.failover_dry_run
+  validator_detection    = var.validator_detection
+  rpc_port               = var.rpc_port
+  metric_window          = var.metric_window
+  metric_max_age         = var.metric_max_age
+  metric_aggregation     = var.metric_aggregation
+  validator_metric_value = var.validator_metric_value
+  consul_address         = var.consul_address
+}
+
+data "polkadot_validator_history" "polkadot" {
+  provider               = polkadot
+  prefix                 = var.prefix
+  metric_name            = local.validator_metric_name
+  metric_namespace       = var.metric_namespace
+  window                 = var.validator_history_window
+  metric_aggregation     = var.metric_aggregation
+  validator_metric_value = var.validator_metric_value
+
+  depends_on = [polkadot_failover.polkadot]
 }
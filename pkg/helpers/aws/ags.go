@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"sort"
 	"strings"
 	"time"
 
@@ -136,28 +135,35 @@ func filterASG(groups *AgsGroups, handler func(group *autoscaling.Group) bool) {
 
 func GetRegionASGs(ctx context.Context, client *autoscaling.AutoScaling, prefix string) (AgsGroups, error) {
 
+	groups, err := describeRegionASGs(ctx, client, prefix)
+
+	if err != nil {
+		return nil, err
+	}
+
+	filterRunningInstances(groups)
+
+	return groups, nil
+
+}
+
+// describeRegionASGs returns auto scaling groups with prefix and all their instances
+func describeRegionASGs(ctx context.Context, client *autoscaling.AutoScaling, prefix string) (AgsGroups, error) {
+
 	var groups AgsGroups
 
-	resp, err := client.DescribeAutoScalingGroupsWithContext(ctx, &autoscaling.DescribeAutoScalingGroupsInput{})
+	err := client.DescribeAutoScalingGroupsPagesWithContext(
+		ctx,
+		&autoscaling.DescribeAutoScalingGroupsInput{},
+		func(page *autoscaling.DescribeAutoScalingGroupsOutput, lastPage bool) bool {
+			groups = append(groups, page.AutoScalingGroups...)
+			return true
+		},
+	)
+
 	if err != nil {
 		return nil, processAwsError(err)
 	}
-	if resp.AutoScalingGroups != nil {
-		groups = append(groups, resp.AutoScalingGroups...)
-	}
-	for resp.NextToken != nil && *resp.NextToken != "" {
-		resp, err := client.DescribeAutoScalingGroupsWithContext(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
-			AutoScalingGroupNames: nil,
-			MaxRecords:            nil,
-			NextToken:             resp.NextToken,
-		})
-		if err != nil {
-			return nil, processAwsError(err)
-		}
-		if resp.AutoScalingGroups != nil {
-			groups = append(groups, resp.AutoScalingGroups...)
-		}
-	}
 
 	filterASG(&groups, func(group *autoscaling.Group) bool {
 		return strings.HasPrefix(*group.AutoScalingGroupName, prefix)
@@ -168,55 +174,46 @@ func GetRegionASGs(ctx context.Context, client *autoscaling.AutoScaling, prefix
 }
 
 func GetASGs(ctx context.Context, clients []*autoscaling.AutoScaling, prefix string) (AgsGroupsList, error) {
+	groups, _, err := GetASGsWithStandby(ctx, clients, prefix)
+	return groups, err
+}
 
-	type asgItem struct {
-		groups []*autoscaling.Group
-		index  int
-	}
-
-	var indexes []interface{}
-
-	for index := range clients {
-		indexes = append(indexes, index)
-	}
+// GetASGsWithStandby returns auto scaling groups with prefix and running instances, and IDs of standby instances
+// by region and group name. Both are taken from the same auto scaling groups description
+func GetASGsWithStandby(ctx context.Context, clients []*autoscaling.AutoScaling, prefix string) (AgsGroupsList, AsgToInstancesByRegion, error) {
 
-	out := fanout.ConcurrentResponseItems(ctx, func(ctx context.Context, value interface{}) (interface{}, error) {
-		index := value.(int)
-		client := clients[index]
-		groups, err := GetRegionASGs(
-			ctx,
-			client,
-			prefix,
-		)
+	groups := make([]AgsGroups, len(clients))
+	standby := NewAsgInstancesByRegion(len(clients))
 
+	err := fanout.Run(ctx, len(clients), fanout.Options{Mode: fanout.CancelOnError}, func(ctx context.Context, idx int) error {
+		regionGroups, err := describeRegionASGs(ctx, clients[idx], prefix)
 		if err != nil {
-			return asgItem{}, err
+			return err
 		}
-
-		return asgItem{
-			groups: groups,
-			index:  index,
-		}, nil
-
-	}, indexes...)
-
-	var groups []AgsGroups
-
-	items, err := fanout.ReadItemChannel(out)
+		standby[idx] = standbyInstances(regionGroups)
+		filterRunningInstances(regionGroups)
+		groups[idx] = regionGroups
+		return nil
+	})
 
 	if err != nil {
-		return groups, err
+		return nil, nil, err
 	}
 
-	sort.Slice(items, func(i, j int) bool {
-		return items[i].(asgItem).index < items[j].(asgItem).index
-	})
+	return groups, standby, nil
+}
 
-	for _, item := range items {
-		groups = append(groups, item.(asgItem).groups)
+// standbyInstances returns IDs of standby instances by group name
+func standbyInstances(groups AgsGroups) map[string][]string {
+	instances := map[string][]string{}
+	for _, group := range groups {
+		for _, instance := range group.Instances {
+			if IsStandbyASGInstance(instance) {
+				instances[*group.AutoScalingGroupName] = append(instances[*group.AutoScalingGroupName], *instance.InstanceId)
+			}
+		}
 	}
-
-	return groups, nil
+	return instances
 }
 
 func checkActionActivities(activities []*autoscaling.Activity) ([]string, error) {
@@ -240,7 +237,96 @@ func checkActionActivities(activities []*autoscaling.Activity) ([]string, error)
 	return actionActivities, nil
 }
 
-func DetachASGInstances(ctx context.Context, client *autoscaling.AutoScaling, asgName string, instanceIDs []string) error {
+// maxStandbyInstances is the maximum number of instances of an EnterStandby call
+const maxStandbyInstances = 20
+
+// IsRunningASGInstance returns true if the instance is launching or in service.
+// Instances being terminated, detached or in standby do not run the polkadot service for the auto scaling group
+func IsRunningASGInstance(instance *autoscaling.Instance) bool {
+	if instance.LifecycleState == nil {
+		return true
+	}
+	switch *instance.LifecycleState {
+	case autoscaling.LifecycleStateTerminating,
+		autoscaling.LifecycleStateTerminatingWait,
+		autoscaling.LifecycleStateTerminatingProceed,
+		autoscaling.LifecycleStateTerminated,
+		autoscaling.LifecycleStateDetaching,
+		autoscaling.LifecycleStateDetached,
+		autoscaling.LifecycleStateEnteringStandby,
+		autoscaling.LifecycleStateStandby:
+		return false
+	}
+	return true
+}
+
+// IsStandbyASGInstance returns true if the instance has been moved to standby
+func IsStandbyASGInstance(instance *autoscaling.Instance) bool {
+	return instance.LifecycleState != nil && *instance.LifecycleState == autoscaling.LifecycleStateStandby
+}
+
+// filterRunningInstances removes instances which do not run from auto scaling groups
+func filterRunningInstances(groups AgsGroups) {
+	for _, group := range groups {
+		var instances []*autoscaling.Instance
+		for _, instance := range group.Instances {
+			if IsRunningASGInstance(instance) {
+				instances = append(instances, instance)
+			}
+		}
+		group.Instances = instances
+	}
+}
+
+// waitForActivities waits while auto scaling group activities are finished
+func waitForActivities(ctx context.Context, client *autoscaling.AutoScaling, asgName string, activities []*autoscaling.Activity) error {
+
+	activityIDs, err := checkActionActivities(activities)
+
+	if err != nil {
+		return err
+	}
+
+	for len(activityIDs) > 0 {
+
+		log.Printf("[DEBUG]: failover. Got %d unfinished activities: %s", len(activityIDs), activityIDs)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timeout waiting for activities of the autoscale group %q: %s", asgName, activityIDs)
+		case <-time.After(time.Second):
+		}
+
+		var newActivityIDs []string
+		var activitiesErr error
+
+		err := client.DescribeScalingActivitiesPagesWithContext(ctx, &autoscaling.DescribeScalingActivitiesInput{
+			ActivityIds:          aws.StringSlice(activityIDs),
+			AutoScalingGroupName: aws.String(asgName),
+		}, func(output *autoscaling.DescribeScalingActivitiesOutput, lastPage bool) bool {
+			var ids []string
+			ids, activitiesErr = checkActionActivities(output.Activities)
+			newActivityIDs = append(newActivityIDs, ids...)
+			return activitiesErr == nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if activitiesErr != nil {
+			return activitiesErr
+		}
+
+		activityIDs = newActivityIDs
+
+	}
+
+	return nil
+}
+
+// lowerASGMinSize lowers min_size of the auto scaling group so that desired capacity can be decremented by count
+func lowerASGMinSize(ctx context.Context, client *autoscaling.AutoScaling, asgName string, count int) error {
 
 	asgResp, err := client.DescribeAutoScalingGroupsWithContext(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
 		AutoScalingGroupNames: aws.StringSlice([]string{asgName}),
@@ -258,113 +344,270 @@ func DetachASGInstances(ctx context.Context, client *autoscaling.AutoScaling, as
 
 	group := asgGroups[0]
 
-	newMinSize := *group.MinSize - int64(len(instanceIDs))
-	newDesiredSize := *group.DesiredCapacity - int64(len(instanceIDs))
+	newMinSize := *group.DesiredCapacity - int64(count)
 
 	if newMinSize < 0 {
 		newMinSize = 0
 	}
-	if newDesiredSize < 0 {
-		newDesiredSize = 0
+
+	if newMinSize >= *group.MinSize {
+		return nil
 	}
 
-	if newMinSize != *group.MaxSize || newDesiredSize != *group.DesiredCapacity {
-		log.Printf(
-			"[DEBUG] failover: Changing min_size to %d and desired_capacity to %d for the autoscale group %q",
-			newMinSize,
-			newDesiredSize,
-			asgName,
-		)
-		_, err := client.UpdateAutoScalingGroup(&autoscaling.UpdateAutoScalingGroupInput{
-			AutoScalingGroupName: aws.String(asgName),
-			MinSize:              aws.Int64(newMinSize),
-			DesiredCapacity:      aws.Int64(newDesiredSize),
-		})
-		if err != nil {
-			return err
+	log.Printf("[DEBUG] failover: Changing min_size to %d for the autoscale group %q", newMinSize, asgName)
+
+	_, err = client.UpdateAutoScalingGroupWithContext(ctx, &autoscaling.UpdateAutoScalingGroupInput{
+		AutoScalingGroupName: aws.String(asgName),
+		MinSize:              aws.Int64(newMinSize),
+	})
+
+	return err
+}
+
+// raiseASGMaxSize raises max_size of the auto scaling group so that desired capacity can be incremented by count
+func raiseASGMaxSize(ctx context.Context, client *autoscaling.AutoScaling, asgName string, count int) error {
+
+	asgResp, err := client.DescribeAutoScalingGroupsWithContext(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: aws.StringSlice([]string{asgName}),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	asgGroups := asgResp.AutoScalingGroups
+
+	if len(asgGroups) == 0 {
+		return fmt.Errorf("could not find the autoscale group %q", asgName)
+	}
+
+	group := asgGroups[0]
+
+	newMaxSize := *group.DesiredCapacity + int64(count)
+
+	if newMaxSize <= *group.MaxSize {
+		return nil
+	}
+
+	log.Printf("[DEBUG] failover: Changing max_size to %d for the autoscale group %q", newMaxSize, asgName)
+
+	_, err = client.UpdateAutoScalingGroupWithContext(ctx, &autoscaling.UpdateAutoScalingGroupInput{
+		AutoScalingGroupName: aws.String(asgName),
+		MaxSize:              aws.Int64(newMaxSize),
+	})
+
+	return err
+}
+
+// SelfHealingProcesses are auto scaling group processes launching instances in place of deleted or unhealthy ones
+var SelfHealingProcesses = []string{"Launch", "ReplaceUnhealthy", "AZRebalance"}
+
+// ProcessesToSuspend returns self healing processes of the auto scaling group which are not suspended yet
+func ProcessesToSuspend(group *autoscaling.Group) []string {
+	suspended := map[string]bool{}
+	for _, process := range group.SuspendedProcesses {
+		if process.ProcessName != nil {
+			suspended[*process.ProcessName] = true
+		}
+	}
+	var processes []string
+	for _, process := range SelfHealingProcesses {
+		if !suspended[process] {
+			processes = append(processes, process)
 		}
 	}
+	return processes
+}
 
-	log.Printf(
-		"[DEBUG] failover: Detaching instances %s from the autoscale group %q",
-		instanceIDs,
-		asgName,
-	)
-	resp, err := client.DetachInstancesWithContext(ctx, &autoscaling.DetachInstancesInput{
-		AutoScalingGroupName:           &asgName,
-		InstanceIds:                    aws.StringSlice(instanceIDs),
-		ShouldDecrementDesiredCapacity: aws.Bool(true),
+// SuspendASGProcesses suspends processes of the auto scaling group
+func SuspendASGProcesses(ctx context.Context, client *autoscaling.AutoScaling, asgName string, processes []string) error {
+
+	log.Printf("[DEBUG] failover: Suspending processes %s of the autoscale group %q", processes, asgName)
+
+	_, err := client.SuspendProcessesWithContext(ctx, &autoscaling.ScalingProcessQuery{
+		AutoScalingGroupName: aws.String(asgName),
+		ScalingProcesses:     aws.StringSlice(processes),
 	})
 
-	if err != nil {
+	return processAwsError(err)
+}
+
+// ResumeASGProcesses resumes processes of the auto scaling group
+func ResumeASGProcesses(ctx context.Context, client *autoscaling.AutoScaling, asgName string, processes []string) error {
+
+	log.Printf("[DEBUG] failover: Resuming processes %s of the autoscale group %q", processes, asgName)
+
+	_, err := client.ResumeProcessesWithContext(ctx, &autoscaling.ScalingProcessQuery{
+		AutoScalingGroupName: aws.String(asgName),
+		ScalingProcesses:     aws.StringSlice(processes),
+	})
+
+	return processAwsError(err)
+}
+
+// ProtectASGInstances sets or clears scale in protection of the auto scaling group instances
+func ProtectASGInstances(ctx context.Context, client *autoscaling.AutoScaling, asgName string, instanceIDs []string, protected bool) error {
+
+	log.Printf("[DEBUG] failover: Setting scale in protection %t for instances %s of the autoscale group %q", protected, instanceIDs, asgName)
+
+	_, err := client.SetInstanceProtectionWithContext(ctx, &autoscaling.SetInstanceProtectionInput{
+		AutoScalingGroupName: aws.String(asgName),
+		InstanceIds:          aws.StringSlice(instanceIDs),
+		ProtectedFromScaleIn: aws.Bool(protected),
+	})
+
+	return processAwsError(err)
+}
+
+// TerminateASGInstances terminates instances through the auto scaling group decrementing its desired capacity
+// and waits while the instances are terminated
+func TerminateASGInstances(ctx context.Context, client *autoscaling.AutoScaling, ec2Client *ec2.EC2, asgName string, instanceIDs []string) error {
+
+	if err := lowerASGMinSize(ctx, client, asgName, len(instanceIDs)); err != nil {
 		return err
 	}
 
-	activities, err := checkActionActivities(resp.Activities)
+	for _, instanceID := range instanceIDs {
+		log.Printf("[DEBUG] failover: Terminating instance %q of the autoscale group %q", instanceID, asgName)
+		_, err := client.TerminateInstanceInAutoScalingGroupWithContext(ctx, &autoscaling.TerminateInstanceInAutoScalingGroupInput{
+			InstanceId:                     aws.String(instanceID),
+			ShouldDecrementDesiredCapacity: aws.Bool(true),
+		})
+		if err != nil {
+			return fmt.Errorf("cannot terminate instance %q of the autoscale group %q: %w", instanceID, asgName, processAwsError(err))
+		}
+	}
+
+	return ec2Client.WaitUntilInstanceTerminatedWithContext(ctx, &ec2.DescribeInstancesInput{
+		InstanceIds: aws.StringSlice(instanceIDs),
+	})
+}
 
-	if err != nil {
+// StandbyASGInstances moves instances of the auto scaling group to standby decrementing its desired capacity and stops them.
+// Stopped standby instances keep their volumes
+func StandbyASGInstances(ctx context.Context, client *autoscaling.AutoScaling, ec2Client *ec2.EC2, asgName string, instanceIDs []string) error {
+
+	if err := lowerASGMinSize(ctx, client, asgName, len(instanceIDs)); err != nil {
 		return err
 	}
 
-	for len(activities) > 0 {
+	for start := 0; start < len(instanceIDs); start += maxStandbyInstances {
+		end := start + maxStandbyInstances
+		if end > len(instanceIDs) {
+			end = len(instanceIDs)
+		}
 
-		log.Printf("[DEBUG]: failover. Got %d unfinished activities: %s", len(activities), activities)
+		log.Printf("[DEBUG] failover: Moving instances %s of the autoscale group %q to standby", instanceIDs[start:end], asgName)
 
-		resp, err := client.DescribeScalingActivitiesWithContext(ctx, &autoscaling.DescribeScalingActivitiesInput{
-			ActivityIds:          aws.StringSlice(activities),
-			AutoScalingGroupName: aws.String(asgName),
+		resp, err := client.EnterStandbyWithContext(ctx, &autoscaling.EnterStandbyInput{
+			AutoScalingGroupName:           aws.String(asgName),
+			InstanceIds:                    aws.StringSlice(instanceIDs[start:end]),
+			ShouldDecrementDesiredCapacity: aws.Bool(true),
 		})
 
 		if err != nil {
-			return err
+			return processAwsError(err)
 		}
 
-		newActivities, err := checkActionActivities(resp.Activities)
-
-		if err != nil {
+		if err := waitForActivities(ctx, client, asgName, resp.Activities); err != nil {
 			return err
 		}
+	}
 
-		for resp.NextToken != nil {
-			resp, err := client.DescribeScalingActivitiesWithContext(ctx, &autoscaling.DescribeScalingActivitiesInput{
-				ActivityIds:          aws.StringSlice(activities),
-				AutoScalingGroupName: aws.String(asgName),
-				NextToken:            resp.NextToken,
-			})
+	if _, err := ec2Client.StopInstancesWithContext(ctx, &ec2.StopInstancesInput{
+		InstanceIds: aws.StringSlice(instanceIDs),
+	}); err != nil {
+		return processAwsError(err)
+	}
 
-			if err != nil {
-				return err
-			}
+	return ec2Client.WaitUntilInstanceStoppedWithContext(ctx, &ec2.DescribeInstancesInput{
+		InstanceIds: aws.StringSlice(instanceIDs),
+	})
+}
 
-			tokenActivities, err := checkActionActivities(resp.Activities)
+// ExitStandbyASGInstances starts stopped standby instances of the auto scaling group and returns them to service
+// incrementing its desired capacity. max_size is raised if desired capacity would exceed it
+func ExitStandbyASGInstances(ctx context.Context, client *autoscaling.AutoScaling, ec2Client *ec2.EC2, asgName string, instanceIDs []string) error {
 
-			if err != nil {
-				return err
-			}
+	log.Printf("[DEBUG] failover: Starting standby instances %s of the autoscale group %q", instanceIDs, asgName)
+
+	if _, err := ec2Client.StartInstancesWithContext(ctx, &ec2.StartInstancesInput{
+		InstanceIds: aws.StringSlice(instanceIDs),
+	}); err != nil {
+		return processAwsError(err)
+	}
+
+	if err := ec2Client.WaitUntilInstanceRunningWithContext(ctx, &ec2.DescribeInstancesInput{
+		InstanceIds: aws.StringSlice(instanceIDs),
+	}); err != nil {
+		return err
+	}
+
+	if err := raiseASGMaxSize(ctx, client, asgName, len(instanceIDs)); err != nil {
+		return err
+	}
 
-			newActivities = append(newActivities, tokenActivities...)
+	for start := 0; start < len(instanceIDs); start += maxStandbyInstances {
+		end := start + maxStandbyInstances
+		if end > len(instanceIDs) {
+			end = len(instanceIDs)
 		}
 
-		activities = newActivities
-		time.Sleep(time.Duration(1) * time.Second)
+		log.Printf("[DEBUG] failover: Returning instances %s of the autoscale group %q to service", instanceIDs[start:end], asgName)
+
+		resp, err := client.ExitStandbyWithContext(ctx, &autoscaling.ExitStandbyInput{
+			AutoScalingGroupName: aws.String(asgName),
+			InstanceIds:          aws.StringSlice(instanceIDs[start:end]),
+		})
+
+		if err != nil {
+			return processAwsError(err)
+		}
 
+		if err := waitForActivities(ctx, client, asgName, resp.Activities); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func DeleteInstances(ctx context.Context, client *ec2.EC2, instanceIDs []string) error {
-	_, err := client.TerminateInstancesWithContext(ctx, &ec2.TerminateInstancesInput{
-		DryRun:      aws.Bool(false),
+// TerminateStandbyASGInstances terminates standby instances of the auto scaling group and waits while they are terminated.
+// Standby instances are not counted in desired capacity, so it is not changed
+func TerminateStandbyASGInstances(ctx context.Context, ec2Client *ec2.EC2, asgName string, instanceIDs []string) error {
+
+	log.Printf("[DEBUG] failover: Terminating standby instances %s of the autoscale group %q", instanceIDs, asgName)
+
+	if _, err := ec2Client.TerminateInstancesWithContext(ctx, &ec2.TerminateInstancesInput{
 		InstanceIds: aws.StringSlice(instanceIDs),
-	})
-	if err != nil {
-		return err
+	}); err != nil {
+		return processAwsError(err)
 	}
-	return client.WaitUntilInstanceExistsWithContext(ctx, &ec2.DescribeInstancesInput{
-		DryRun:      aws.Bool(false),
+
+	return ec2Client.WaitUntilInstanceTerminatedWithContext(ctx, &ec2.DescribeInstancesInput{
+		InstanceIds: aws.StringSlice(instanceIDs),
+	})
+}
+
+// GetInstancesPrivateIPs returns private IP addresses of instances
+func GetInstancesPrivateIPs(ctx context.Context, client *ec2.EC2, instanceIDs []string) (map[string]string, error) {
+	addresses := make(map[string]string, len(instanceIDs))
+	err := client.DescribeInstancesPagesWithContext(ctx, &ec2.DescribeInstancesInput{
 		InstanceIds: aws.StringSlice(instanceIDs),
+	}, func(output *ec2.DescribeInstancesOutput, lastPage bool) bool {
+		for _, reservation := range output.Reservations {
+			for _, instance := range reservation.Instances {
+				if instance.InstanceId != nil && instance.PrivateIpAddress != nil {
+					addresses[*instance.InstanceId] = *instance.PrivateIpAddress
+				}
+			}
+		}
+		return true
 	})
+	if err != nil {
+		return nil, processAwsError(err)
+	}
+	return addresses, nil
 }
 
 func newAsgClient(region string) (*autoscaling.AutoScaling, error) {
@@ -0,0 +1,56 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterRunningInstances(t *testing.T) {
+	instance := func(id, state string) *autoscaling.Instance {
+		return &autoscaling.Instance{InstanceId: aws.String(id), LifecycleState: aws.String(state)}
+	}
+
+	groups := AgsGroups{
+		{
+			AutoScalingGroupName: aws.String("asg1"),
+			Instances: []*autoscaling.Instance{
+				instance("i1", autoscaling.LifecycleStateInService),
+				instance("i2", autoscaling.LifecycleStateStandby),
+				instance("i3", autoscaling.LifecycleStateTerminatingWait),
+				instance("i4", autoscaling.LifecycleStatePending),
+			},
+		},
+		{
+			AutoScalingGroupName: aws.String("asg2"),
+			Instances: []*autoscaling.Instance{
+				instance("i5", autoscaling.LifecycleStateEnteringStandby),
+				{InstanceId: aws.String("i6")},
+			},
+		},
+	}
+
+	require.Equal(t, map[string][]string{"asg1": {"i2"}}, standbyInstances(groups))
+
+	filterRunningInstances(groups)
+
+	require.Equal(t, []AsgInstancePair{
+		{InstanceID: "i1", ASGName: "asg1"},
+		{InstanceID: "i4", ASGName: "asg1"},
+		{InstanceID: "i6", ASGName: "asg2"},
+	}, groups.AsgInstancePair(0))
+}
+
+func TestProcessesToSuspend(t *testing.T) {
+	group := &autoscaling.Group{}
+	require.Equal(t, SelfHealingProcesses, ProcessesToSuspend(group))
+
+	// processes suspended by the operator are not resumed by failover
+	group.SuspendedProcesses = []*autoscaling.SuspendedProcess{
+		{ProcessName: aws.String("ReplaceUnhealthy")},
+		{ProcessName: aws.String("ScheduledActions")},
+	}
+	require.Equal(t, []string{"Launch", "AZRebalance"}, ProcessesToSuspend(group))
+}
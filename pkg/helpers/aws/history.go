@@ -0,0 +1,195 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/fanout"
+)
+
+// metricSeries is validator metric series of an auto scaling group instance.
+// Instance is the auto scaling group name for legacy series without instance_id dimension
+type metricSeries struct {
+	group      string
+	instance   string
+	dimensions []*cloudwatch.Dimension
+}
+
+// dimensionValue returns value of dimension with name
+func dimensionValue(dimensions []*cloudwatch.Dimension, name string) string {
+	for _, dimension := range dimensions {
+		if dimension.Name != nil && dimension.Value != nil && *dimension.Name == name {
+			return *dimension.Value
+		}
+	}
+	return ""
+}
+
+// historySeries returns series of auto scaling groups with prefix. Legacy group series are used only for groups without instance series
+func historySeries(metrics []*cloudwatch.Metric, prefix string) []metricSeries {
+
+	var series []metricSeries
+	legacy := map[string]metricSeries{}
+	perInstance := map[string]bool{}
+
+	for _, metric := range metrics {
+		group := dimensionValue(metric.Dimensions, groupNameDimension)
+		if !strings.HasPrefix(group, helpers.GetPrefix(prefix)) {
+			continue
+		}
+		instance := dimensionValue(metric.Dimensions, instanceIDDimension)
+		switch {
+		case instance != "" && len(metric.Dimensions) == 2:
+			series = append(series, metricSeries{group: group, instance: instance, dimensions: metric.Dimensions})
+			perInstance[group] = true
+		case instance == "" && len(metric.Dimensions) == 1:
+			legacy[group] = metricSeries{group: group, instance: group, dimensions: metric.Dimensions}
+		}
+	}
+
+	for group, groupSeries := range legacy {
+		if !perInstance[group] {
+			series = append(series, groupSeries)
+		}
+	}
+
+	return series
+}
+
+// getRegionValidatorHistory returns validator metric samples of auto scaling groups with prefix stored in the client region.
+// Instances publish the metric to all regions, so samples are not labeled with the client region
+func getRegionValidatorHistory(
+	ctx context.Context,
+	client *cloudwatch.CloudWatch,
+	prefix,
+	metricNamespace,
+	metricName string,
+	aggregation helpers.MetricAggregation,
+	window time.Duration,
+) ([]helpers.MetricSample, error) {
+
+	var metrics []*cloudwatch.Metric
+
+	err := client.ListMetricsPagesWithContext(ctx, &cloudwatch.ListMetricsInput{
+		Namespace:  aws.String(metricNamespace),
+		MetricName: aws.String(metricName),
+		Dimensions: []*cloudwatch.DimensionFilter{{Name: aws.String(groupNameDimension)}},
+	}, func(output *cloudwatch.ListMetricsOutput, lastPage bool) bool {
+		metrics = append(metrics, output.Metrics...)
+		return true
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot list metrics %q. Region %q: %w", metricName, client.SigningRegion, err)
+	}
+
+	series := historySeries(metrics, prefix)
+	dimensions := make(map[string][]*cloudwatch.Dimension, len(series))
+
+	for idx, s := range series {
+		dimensions[fmt.Sprintf("series%d", idx)] = s.dimensions
+	}
+
+	endTime := time.Now()
+	values, err := getMetricSeries(ctx, client, metricNamespace, metricName, dimensions, aggregation, endTime.Add(-window), endTime)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []helpers.MetricSample
+
+	for idx, s := range series {
+		for _, value := range values[fmt.Sprintf("series%d", idx)] {
+			samples = append(samples, helpers.MetricSample{
+				Timestamp: value.timestamp,
+				Group:     s.group,
+				Instance:  s.instance,
+				Value:     value.value,
+			})
+		}
+	}
+
+	return samples, nil
+}
+
+// groupRegions returns regions of auto scaling groups by group name. regions are region names of groups list indexes
+func groupRegions(groups AgsGroupsList, regions []string) map[string]string {
+	result := map[string]string{}
+	for regionID, regionGroups := range groups {
+		if regionID >= len(regions) {
+			continue
+		}
+		for _, group := range regionGroups {
+			result[*group.AutoScalingGroupName] = regions[regionID]
+		}
+	}
+	return result
+}
+
+// mergeRegionSamples merges samples read from regions. Series published to several regions are kept once.
+// Samples are labeled with the region of the auto scaling group. Location of removed groups is unknown
+func mergeRegionSamples(results [][]helpers.MetricSample, regions map[string]string) []helpers.MetricSample {
+
+	type sampleKey struct {
+		group     string
+		instance  string
+		timestamp time.Time
+	}
+
+	var samples []helpers.MetricSample
+	seen := map[sampleKey]bool{}
+
+	for _, regionSamples := range results {
+		for _, sample := range regionSamples {
+			key := sampleKey{group: sample.Group, instance: sample.Instance, timestamp: sample.Timestamp.UTC()}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			sample.Location = regions[sample.Group]
+			samples = append(samples, sample)
+		}
+	}
+
+	return samples
+}
+
+// GetValidatorHistory returns validator metric samples of auto scaling groups with prefix for the last window in all regions.
+// Series are found by metric dimensions, so samples of terminated instances are included.
+// groups are auto scaling groups per client region, samples are labeled with regions of their groups
+func GetValidatorHistory(
+	ctx context.Context,
+	clients []*cloudwatch.CloudWatch,
+	groups AgsGroupsList,
+	prefix,
+	metricNamespace,
+	metricName string,
+	aggregation helpers.MetricAggregation,
+	window time.Duration,
+) ([]helpers.MetricSample, error) {
+
+	results := make([][]helpers.MetricSample, len(clients))
+	regions := make([]string, len(clients))
+	for idx, client := range clients {
+		regions[idx] = client.SigningRegion
+	}
+
+	err := fanout.Run(ctx, len(clients), fanout.Options{Mode: fanout.CancelOnError}, func(ctx context.Context, idx int) error {
+		samples, err := getRegionValidatorHistory(ctx, clients[idx], prefix, metricNamespace, metricName, aggregation, window)
+		results[idx] = samples
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeRegionSamples(results, groupRegions(groups, regions)), nil
+}
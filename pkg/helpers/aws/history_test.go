@@ -0,0 +1,66 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
+	"github.com/stretchr/testify/require"
+)
+
+func testMetric(dimensions ...string) *cloudwatch.Metric {
+	metric := &cloudwatch.Metric{}
+	for idx := 0; idx+1 < len(dimensions); idx += 2 {
+		metric.Dimensions = append(metric.Dimensions, &cloudwatch.Dimension{Name: aws.String(dimensions[idx]), Value: aws.String(dimensions[idx+1])})
+	}
+	return metric
+}
+
+func TestHistorySeries(t *testing.T) {
+	metrics := []*cloudwatch.Metric{
+		testMetric(groupNameDimension, "test-asg-1", instanceIDDimension, "i-1"),
+		testMetric(groupNameDimension, "test-asg-1", instanceIDDimension, "i-2"),
+		testMetric(groupNameDimension, "test-asg-1"),
+		testMetric(groupNameDimension, "test-asg-2"),
+		testMetric(groupNameDimension, "other-asg", instanceIDDimension, "i-3"),
+	}
+
+	series := historySeries(metrics, "test")
+
+	instances := map[string]string{}
+	for _, s := range series {
+		instances[s.instance] = s.group
+	}
+
+	// legacy series of test-asg-1 is replaced with instance series
+	require.Equal(t, map[string]string{"i-1": "test-asg-1", "i-2": "test-asg-1", "test-asg-2": "test-asg-2"}, instances)
+}
+
+func TestMergeRegionSamples(t *testing.T) {
+	timestamp := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// every instance publishes the metric to all regions
+	series := []helpers.MetricSample{
+		{Timestamp: timestamp, Group: "test-asg-1", Instance: "i-1", Value: 1},
+		{Timestamp: timestamp, Group: "test-asg-2", Instance: "i-2", Value: 0},
+		{Timestamp: timestamp, Group: "test-removed-asg", Instance: "i-3", Value: 0},
+	}
+
+	groups := AgsGroupsList{
+		{{AutoScalingGroupName: aws.String("test-asg-1")}},
+		{{AutoScalingGroupName: aws.String("test-asg-2")}},
+	}
+
+	samples := mergeRegionSamples(
+		[][]helpers.MetricSample{series, append([]helpers.MetricSample{}, series...)},
+		groupRegions(groups, []string{"us-east-1", "us-east-2"}),
+	)
+
+	require.Equal(t, []helpers.MetricSample{
+		{Timestamp: timestamp, Group: "test-asg-1", Instance: "i-1", Location: "us-east-1", Value: 1},
+		{Timestamp: timestamp, Group: "test-asg-2", Instance: "i-2", Location: "us-east-2", Value: 0},
+		{Timestamp: timestamp, Group: "test-removed-asg", Instance: "i-3", Value: 0},
+	}, samples)
+}
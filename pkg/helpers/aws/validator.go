@@ -9,10 +9,12 @@ import (
 	"log"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
 
 	taws "github.com/gruntwork-io/terratest/modules/aws"
 
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
 	helperErrors "github.com/protofire/polkadot-failover-mechanism/pkg/helpers/errors"
 
 	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/fanout"
@@ -23,160 +25,341 @@ import (
 type Validator struct {
 	Value int
 	AsgInstancePair
+	// Missing is true if there are no metric data points for the auto scaling group
+	Missing bool
+	// RawValue is the last metric value
+	RawValue float64
+	// Timestamp is the time of the last metric value. Stale is true if it is older than metric max age
+	Timestamp time.Time
+	Stale     bool
 }
 
-func getValidatorMetric(ctx context.Context, client *cloudwatch.CloudWatch, asgName, metricNamespace, metricName string) (int, error) {
-	endTime := time.Now()
-	duration, _ := time.ParseDuration("-5m")
-	startTime := endTime.Add(duration)
-	period := int64(300)
-	metricID := "m1"
-	stat := "Maximum"
-	metricDim1Name := "group_name"
-	query := &cloudwatch.MetricDataQuery{
-		Id: &metricID,
-		MetricStat: &cloudwatch.MetricStat{
-			Metric: &cloudwatch.Metric{
-				Namespace:  &metricNamespace,
-				MetricName: &metricName,
-				Dimensions: []*cloudwatch.Dimension{
-					{
-						Name:  &metricDim1Name,
-						Value: &asgName,
-					},
+// MetricValue returns validator metric value seen for the instance
+func (v Validator) MetricValue() helperErrors.MetricValue {
+	return helperErrors.MetricValue{
+		Instance:  fmt.Sprintf("%s/%s", v.ASGName, v.InstanceID),
+		Value:     v.RawValue,
+		Missing:   v.Missing,
+		Stale:     v.Stale,
+		Timestamp: v.Timestamp,
+	}
+}
+
+const (
+	groupNameDimension  = "group_name"
+	instanceIDDimension = "instance_id"
+)
+
+// metricSample is the latest metric value in the freshness window
+type metricSample struct {
+	value     float64
+	timestamp time.Time
+	found     bool
+}
+
+// metricStatistic returns cloud watch statistic of validator metric aggregation
+func metricStatistic(aggregation helpers.MetricAggregation) string {
+	switch aggregation {
+	case helpers.MetricAggregationMinimum:
+		return cloudwatch.StatisticMinimum
+	case helpers.MetricAggregationAverage:
+		return cloudwatch.StatisticAverage
+	case helpers.MetricAggregationSum:
+		return cloudwatch.StatisticSum
+	default:
+		return cloudwatch.StatisticMaximum
+	}
+}
+
+// maxMetricDataQueries is the maximum number of queries of GetMetricData request
+const maxMetricDataQueries = 500
+
+// getMetricSeries returns metric values between startTime and endTime for dimension sets by query ID
+func getMetricSeries(
+	ctx context.Context,
+	client *cloudwatch.CloudWatch,
+	metricNamespace,
+	metricName string,
+	dimensions map[string][]*cloudwatch.Dimension,
+	aggregation helpers.MetricAggregation,
+	startTime,
+	endTime time.Time,
+) (map[string][]metricSample, error) {
+	period := int64(60)
+	stat := metricStatistic(aggregation)
+	scanBy := cloudwatch.ScanByTimestampDescending
+
+	queries := make([]*cloudwatch.MetricDataQuery, 0, len(dimensions))
+
+	for id, dims := range dimensions {
+		queries = append(queries, &cloudwatch.MetricDataQuery{
+			Id: aws.String(id),
+			MetricStat: &cloudwatch.MetricStat{
+				Metric: &cloudwatch.Metric{
+					Namespace:  aws.String(metricNamespace),
+					MetricName: aws.String(metricName),
+					Dimensions: dims,
 				},
+				Period: &period,
+				Stat:   &stat,
 			},
-			Period: &period,
-			Stat:   &stat,
-		},
+		})
 	}
-	resp, err := client.GetMetricDataWithContext(ctx, &cloudwatch.GetMetricDataInput{
-		EndTime:           &endTime,
-		StartTime:         &startTime,
-		MetricDataQueries: []*cloudwatch.MetricDataQuery{query},
-	})
+
+	series := make(map[string][]metricSample, len(dimensions))
+
+	for start := 0; start < len(queries); start += maxMetricDataQueries {
+		end := start + maxMetricDataQueries
+		if end > len(queries) {
+			end = len(queries)
+		}
+		err := client.GetMetricDataPagesWithContext(ctx, &cloudwatch.GetMetricDataInput{
+			EndTime:           &endTime,
+			StartTime:         &startTime,
+			ScanBy:            &scanBy,
+			MetricDataQueries: queries[start:end],
+		}, func(output *cloudwatch.GetMetricDataOutput, lastPage bool) bool {
+			for _, result := range output.MetricDataResults {
+				if result.Id == nil {
+					continue
+				}
+				for idx, v := range result.Values {
+					if v == nil || idx >= len(result.Timestamps) || result.Timestamps[idx] == nil {
+						continue
+					}
+					series[*result.Id] = append(series[*result.Id], metricSample{value: *v, timestamp: *result.Timestamps[idx], found: true})
+				}
+			}
+			return true
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cannot get metrics %q. Region %q: %w", metricName, client.SigningRegion, err)
+		}
+	}
+
+	return series, nil
+}
+
+// getMetricSamples returns the latest metric values in freshness window for dimension sets by query ID
+func getMetricSamples(
+	ctx context.Context,
+	client *cloudwatch.CloudWatch,
+	metricNamespace,
+	metricName string,
+	dimensions map[string][]*cloudwatch.Dimension,
+	aggregation helpers.MetricAggregation,
+	freshness helpers.MetricFreshness,
+) (map[string]metricSample, error) {
+	endTime := time.Now()
+
+	series, err := getMetricSeries(ctx, client, metricNamespace, metricName, dimensions, aggregation, endTime.Add(-freshness.GetWindow()), endTime)
 	if err != nil {
-		return 0, fmt.Errorf("cannot get metrics %q for asg %q. Region %q: %w", metricName, asgName, client.SigningRegion, err)
+		return nil, err
 	}
 
-	if len(resp.MetricDataResults) == 0 {
-		log.Printf(
-			"[DEBUG] failover: Not found metric data messages for ASG %q, metric namespace %q, metric name %q",
-			asgName,
-			metricNamespace,
-			metricName,
-		)
-		return 0, nil
+	samples := make(map[string]metricSample, len(series))
+
+	for id, values := range series {
+		sample := samples[id]
+		for _, value := range values {
+			if !sample.found || value.timestamp.After(sample.timestamp) {
+				sample = value
+			}
+		}
+		samples[id] = sample
+	}
+
+	return samples, nil
+}
+
+// getGroupValidatorMetrics queries validator metrics of the auto scaling group instances with and without instance_id dimension
+func getGroupValidatorMetrics(
+	ctx context.Context,
+	client *cloudwatch.CloudWatch,
+	pairs []AsgInstancePair,
+	metricNamespace,
+	metricName string,
+	aggregation helpers.MetricAggregation,
+	freshness helpers.MetricFreshness,
+) ([]Validator, error) {
+
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+
+	asgName := pairs[0].ASGName
+	groupDimension := &cloudwatch.Dimension{Name: aws.String(groupNameDimension), Value: aws.String(asgName)}
+	groupQueryID := "group"
+
+	dimensions := map[string][]*cloudwatch.Dimension{
+		groupQueryID: {groupDimension},
+	}
+
+	for idx, pair := range pairs {
+		dimensions[fmt.Sprintf("instance%d", idx)] = []*cloudwatch.Dimension{
+			groupDimension,
+			{Name: aws.String(instanceIDDimension), Value: aws.String(pair.InstanceID)},
+		}
+	}
+
+	samples, err := getMetricSamples(ctx, client, metricNamespace, metricName, dimensions, aggregation, freshness)
+	if err != nil {
+		return nil, fmt.Errorf("asg %q: %w", asgName, err)
 	}
 
-	result := resp.MetricDataResults[len(resp.MetricDataResults)-1]
-	values := result.Values
+	return groupValidators(pairs, samples, groupQueryID, metricName, freshness, time.Now()), nil
+}
 
-	var intValues []int
-	for _, value := range values {
-		if value != nil {
-			intValues = append(intValues, int(*value))
+// groupValidators resolves validator metric values of auto scaling group instances from samples of instance%d and group queries.
+// Metrics with instance_id dimension are used if any instance reports them. Otherwise legacy metric with group_name
+// dimension only is used. It is exact for a single instance. Non-zero legacy value of several instances is ambiguous and missing
+func groupValidators(
+	pairs []AsgInstancePair,
+	samples map[string]metricSample,
+	groupQueryID,
+	metricName string,
+	freshness helpers.MetricFreshness,
+	now time.Time,
+) []Validator {
+
+	asgName := pairs[0].ASGName
+	perInstance := false
+	for idx := range pairs {
+		if samples[fmt.Sprintf("instance%d", idx)].found {
+			perInstance = true
 		}
 	}
 
-	if len(intValues) == 0 {
+	groupSample := samples[groupQueryID]
+
+	if !perInstance && groupSample.found && groupSample.value != 0 && len(pairs) > 1 {
 		log.Printf(
-			"[DEBUG] failover: Not found metric data messages for ASG %q, metric namespace %q, metric name %q",
-			asgName,
-			metricNamespace,
+			"[WARNING] failover: Metric %q of ASG %q has no %s dimension. Cannot find which of %d instances validates",
 			metricName,
+			asgName,
+			instanceIDDimension,
+			len(pairs),
 		)
-		return 0, nil
+		groupSample = metricSample{}
 	}
 
-	log.Printf("[DEBUG] failover: Got metric data values: %d", intValues)
+	validators := make([]Validator, 0, len(pairs))
 
-	return intValues[len(intValues)-1], nil
+	for idx, pair := range pairs {
+		sample := groupSample
+		if perInstance {
+			sample = samples[fmt.Sprintf("instance%d", idx)]
+		}
+		if !sample.found {
+			log.Printf(
+				"[DEBUG] failover: Not found metric data messages for ASG %q, instance %q, metric name %q",
+				asgName,
+				pair.InstanceID,
+				metricName,
+			)
+		}
+		validators = append(validators, Validator{
+			AsgInstancePair: pair,
+			Value:           int(sample.value),
+			Missing:         !sample.found,
+			RawValue:        sample.value,
+			Timestamp:       sample.timestamp,
+			Stale:           sample.found && freshness.Stale(sample.timestamp, now),
+		})
+	}
 
+	return validators
 }
 
-func getValidatorMetrics(
+// GetValidatorMetrics returns validator metric values of auto scaling groups instances aggregated with aggregation
+func GetValidatorMetrics(
 	ctx context.Context,
 	clients []*cloudwatch.CloudWatch,
 	asgs AgsGroupsList,
 	metricNamespace,
 	metricName string,
+	aggregation helpers.MetricAggregation,
+	freshness helpers.MetricFreshness,
 ) ([]Validator, error) {
 
-	var pairs []interface{}
+	var groups [][]AsgInstancePair
 
-	for _, pair := range asgs.AsgInstancePairs() {
-		pairs = append(pairs, pair)
+	for regionID, regionGroups := range asgs {
+		for _, group := range regionGroups {
+			groups = append(groups, AgsGroups{group}.AsgInstancePair(regionID))
+		}
 	}
 
-	out := fanout.ConcurrentResponseItems(ctx, func(ctx context.Context, value interface{}) (interface{}, error) {
-		pair := value.(AsgInstancePair)
-		metric, err := getValidatorMetric(
+	validators := make([][]Validator, len(groups))
+
+	err := fanout.Run(ctx, len(groups), fanout.Options{Mode: fanout.CancelOnError}, func(ctx context.Context, idx int) error {
+		pairs := groups[idx]
+		if len(pairs) == 0 {
+			return nil
+		}
+		groupValidators, err := getGroupValidatorMetrics(
 			ctx,
-			clients[pair.RegionID],
-			pair.ASGName,
+			clients[pairs[0].RegionID],
+			pairs,
 			metricNamespace,
 			metricName,
+			aggregation,
+			freshness,
 		)
+		validators[idx] = groupValidators
+		return err
+	})
 
-		if err != nil {
-			return Validator{}, err
-		}
-
-		return Validator{
-			AsgInstancePair: pair,
-			Value:           metric,
-		}, nil
-
-	}, pairs...)
-
-	items, err := fanout.ReadItemChannel(out)
-
-	result := make([]Validator, len(pairs))
+	result := make([]Validator, 0, asgs.InstancesCount())
 
 	if err != nil {
 		return result, err
 	}
 
-	for _, item := range items {
-		result = append(result, item.(Validator))
+	for _, groupValidators := range validators {
+		result = append(result, groupValidators...)
 	}
 
 	return result, nil
 }
 
+// GetValidator returns the only auto scaling group instance which metric value is the check validator value
 func GetValidator(
 	ctx context.Context,
 	clients []*cloudwatch.CloudWatch,
 	asgs AgsGroupsList,
 	metricNamespace,
 	metricName string,
+	check helpers.ValidatorMetricCheck,
+	freshness helpers.MetricFreshness,
 ) (Validator, error) {
 
-	metricItems, err := getValidatorMetrics(ctx, clients, asgs, metricNamespace, metricName)
+	metricItems, err := GetValidatorMetrics(ctx, clients, asgs, metricNamespace, metricName, check.GetAggregation(), freshness)
 
 	if err != nil {
 		return Validator{}, err
 	}
 
 	var validators []Validator
+	values := make([]helperErrors.MetricValue, 0, len(metricItems))
 
 	for _, metric := range metricItems {
-		if metric.Value != 0 {
+		values = append(values, metric.MetricValue())
+		if !metric.Missing && !metric.Stale && check.IsValidator(metric.RawValue) {
 			validators = append(validators, metric)
 		}
 	}
 
 	switch len(validators) {
 	case 0:
-		return Validator{}, helperErrors.NewValidatorError("cannot find validators", helperErrors.ValidatorErrorNotFound)
+		return Validator{}, helperErrors.NewValidatorError("cannot find validators", helperErrors.ValidatorErrorNotFound).WithValues(values)
 	case 1:
 		return validators[0], nil
 	default:
 		return Validator{}, helperErrors.NewValidatorError(
 			fmt.Sprintf("found %d validators: %#v", len(validators), validators),
 			helperErrors.ValidatorErrorMultiple,
-		)
+		).WithValues(values)
 	}
 
 }
@@ -198,7 +381,7 @@ func WaitForValidator(
 		case <-ctx.Done():
 			return Validator{}, fmt.Errorf("timeout waiting for validator")
 		case <-ticker.C:
-			validator, err := GetValidator(ctx, clients, asgs, metricNamespace, metricName)
+			validator, err := GetValidator(ctx, clients, asgs, metricNamespace, metricName, helpers.DefaultValidatorMetricCheck(), helpers.DefaultMetricFreshness())
 			if err != nil {
 				validatorError := &helperErrors.ValidatorError{}
 				if errors.As(err, validatorError) {
@@ -216,6 +399,88 @@ func WaitForValidator(
 
 }
 
+// GetValidatorMetricName returns metric name if cloud watch has the metric for the auto scaling group in any region
+func GetValidatorMetricName(
+	ctx context.Context,
+	clients []*cloudwatch.CloudWatch,
+	asgName,
+	metricNamespace,
+	metricName string,
+) (string, error) {
+
+	dimensionName := groupNameDimension
+
+	for _, client := range clients {
+		resp, err := client.ListMetricsWithContext(ctx, &cloudwatch.ListMetricsInput{
+			Namespace:  &metricNamespace,
+			MetricName: &metricName,
+			Dimensions: []*cloudwatch.DimensionFilter{
+				{
+					Name:  &dimensionName,
+					Value: &asgName,
+				},
+			},
+		})
+		if err != nil {
+			return "", fmt.Errorf("cannot list metrics %q for asg %q. Region %q: %w", metricName, asgName, client.SigningRegion, err)
+		}
+		for _, metric := range resp.Metrics {
+			if metric.MetricName != nil {
+				return *metric.MetricName, nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// WaitValidatorMetricNames waits while metrics of auto scaling groups are found attempts times in a row
+func WaitValidatorMetricNames(
+	ctx context.Context,
+	clients []*cloudwatch.CloudWatch,
+	asgNames []string,
+	metricNamespace,
+	metricName string,
+	period time.Duration,
+	attempts int,
+) (map[string]string, error) {
+
+	metrics := make([]string, len(asgNames))
+
+	err := fanout.Run(ctx, len(asgNames), fanout.Options{Mode: fanout.CancelOnError}, func(ctx context.Context, idx int) error {
+		asgName := asgNames[idx]
+
+		metric, err := helpers.WaitForStableValue(ctx, period, attempts, func(ctx context.Context) (string, error) {
+			return GetValidatorMetricName(ctx, clients, asgName, metricNamespace, metricName)
+		})
+
+		if err != nil {
+			return fmt.Errorf(
+				"error getting metric %q, namespace %q, asg %q: %w",
+				metricName,
+				metricNamespace,
+				asgName,
+				err,
+			)
+		}
+
+		metrics[idx] = metric
+		return nil
+	})
+
+	result := make(map[string]string, len(asgNames))
+
+	if err != nil {
+		return result, err
+	}
+
+	for idx, asgName := range asgNames {
+		result[asgName] = metrics[idx]
+	}
+
+	return result, nil
+}
+
 func newCloudWatchClient(region string) (*cloudwatch.CloudWatch, error) {
 	sess, err := taws.NewAuthenticatedSession(region)
 	if err != nil {
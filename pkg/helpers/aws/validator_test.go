@@ -0,0 +1,70 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
+	"github.com/stretchr/testify/require"
+)
+
+func testPairs(instanceIDs ...string) []AsgInstancePair {
+	pairs := make([]AsgInstancePair, 0, len(instanceIDs))
+	for _, id := range instanceIDs {
+		pairs = append(pairs, AsgInstancePair{ASGName: "asg", InstanceID: id})
+	}
+	return pairs
+}
+
+func validatorValues(validators []Validator) map[string]interface{} {
+	values := make(map[string]interface{}, len(validators))
+	for _, validator := range validators {
+		if validator.Missing || validator.Stale {
+			values[validator.InstanceID] = nil
+		} else {
+			values[validator.InstanceID] = validator.Value
+		}
+	}
+	return values
+}
+
+func TestGroupValidatorsPerInstance(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	freshness := helpers.DefaultMetricFreshness()
+
+	samples := map[string]metricSample{
+		"group":     {value: 1, timestamp: now.Add(-time.Minute), found: true},
+		"instance0": {value: 0, timestamp: now.Add(-time.Minute), found: true},
+		"instance1": {value: 1, timestamp: now.Add(-time.Minute), found: true},
+	}
+
+	validators := groupValidators(testPairs("i-1", "i-2", "i-3"), samples, "group", "validator_value", freshness, now)
+	require.Equal(t, map[string]interface{}{"i-1": 0, "i-2": 1, "i-3": nil}, validatorValues(validators))
+
+	// stale instance metric is unknown
+	samples["instance1"] = metricSample{value: 1, timestamp: now.Add(-4 * time.Minute), found: true}
+	validators = groupValidators(testPairs("i-1", "i-2", "i-3"), samples, "group", "validator_value", freshness, now)
+	require.Equal(t, map[string]interface{}{"i-1": 0, "i-2": nil, "i-3": nil}, validatorValues(validators))
+}
+
+func TestGroupValidatorsLegacyGroupMetric(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	freshness := helpers.DefaultMetricFreshness()
+
+	samples := map[string]metricSample{
+		"group": {value: 1, timestamp: now.Add(-time.Minute), found: true},
+	}
+
+	// single instance is resolved exactly
+	validators := groupValidators(testPairs("i-1"), samples, "group", "validator_value", freshness, now)
+	require.Equal(t, map[string]interface{}{"i-1": 1}, validatorValues(validators))
+
+	// validator among several instances is ambiguous
+	validators = groupValidators(testPairs("i-1", "i-2"), samples, "group", "validator_value", freshness, now)
+	require.Equal(t, map[string]interface{}{"i-1": nil, "i-2": nil}, validatorValues(validators))
+
+	// no instance validates
+	samples["group"] = metricSample{value: 0, timestamp: now.Add(-time.Minute), found: true}
+	validators = groupValidators(testPairs("i-1", "i-2"), samples, "group", "validator_value", freshness, now)
+	require.Equal(t, map[string]interface{}{"i-1": 0, "i-2": 0}, validatorValues(validators))
+}
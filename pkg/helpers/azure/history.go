@@ -0,0 +1,86 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/profiles/2019-03-01/resources/mgmt/insights"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
+)
+
+// historySamples returns all time series values of VM scale sets metrics. Hosts are instances, scale set name is used for series without host
+func historySamples(
+	metrics map[string]insights.Metric,
+	vmScaleSetLocations map[string]string,
+	aggregationType insights.AggregationType,
+) []helpers.MetricSample {
+
+	var samples []helpers.MetricSample
+
+	for vmScaleSetName, metric := range metrics {
+		if metric.Timeseries == nil {
+			continue
+		}
+		for _, series := range *metric.Timeseries {
+			if series.Data == nil {
+				continue
+			}
+			instance := seriesHostname(series)
+			if instance == "" {
+				instance = vmScaleSetName
+			}
+			for _, data := range *series.Data {
+				value, ok := getDataValue(data, aggregationType)
+				if !ok || data.TimeStamp == nil {
+					continue
+				}
+				samples = append(samples, helpers.MetricSample{
+					Timestamp: data.TimeStamp.Time,
+					Group:     vmScaleSetName,
+					Instance:  instance,
+					Location:  vmScaleSetLocations[vmScaleSetName],
+					Value:     value,
+				})
+			}
+		}
+	}
+
+	return samples
+}
+
+// GetValidatorHistory returns validator metric samples of VM scale sets hosts for the last window.
+// vmScaleSetLocations are VM scale sets locations by name
+func GetValidatorHistory(
+	ctx context.Context,
+	client *insights.MetricsClient,
+	vmScaleSetLocations map[string]string,
+	resourceGroup,
+	metricName,
+	metricNameSpace string,
+	aggregation helpers.MetricAggregation,
+	window time.Duration,
+) ([]helpers.MetricSample, error) {
+
+	vmScaleSetNames := make([]string, 0, len(vmScaleSetLocations))
+	for name := range vmScaleSetLocations {
+		vmScaleSetNames = append(vmScaleSetNames, name)
+	}
+
+	metrics, err := GetValidatorMetricsForVMScaleSets(
+		ctx,
+		client,
+		vmScaleSetNames,
+		resourceGroup,
+		metricName,
+		metricNameSpace,
+		aggregationType(aggregation),
+		helpers.MetricFreshness{Window: window},
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("[ERROR]. Cannot get metric %s for namespace %s: %w", metricName, metricNameSpace, err)
+	}
+
+	return historySamples(metrics, vmScaleSetLocations, aggregationType(aggregation)), nil
+}
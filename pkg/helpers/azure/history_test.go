@@ -0,0 +1,26 @@
+package azure
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/preview/monitor/mgmt/2018-03-01/insights"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistorySamples(t *testing.T) {
+	metric, err := marshallMetric(metricResponse)
+	require.NoError(t, err)
+
+	mp := map[string]insights.Metric{"test1": metric, "test2": {}}
+
+	samples := historySamples(mp, map[string]string{"test1": "centralus", "test2": "eastus"}, insights.Maximum)
+	require.Equal(t, []helpers.MetricSample{{
+		Timestamp: time.Date(2020, 10, 21, 23, 42, 0, 0, time.UTC),
+		Group:     "test1",
+		Instance:  "primary000000",
+		Location:  "centralus",
+		Value:     1,
+	}}, samples)
+}
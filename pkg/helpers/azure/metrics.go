@@ -11,6 +11,7 @@ import (
 
 	"github.com/Azure/go-autorest/autorest"
 
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
 	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/fanout"
 
 	"github.com/Azure/azure-sdk-for-go/profiles/2019-03-01/resources/mgmt/insights"
@@ -35,12 +36,13 @@ func GetValidatorMetricsForVMScaleSet(
 	metricsName,
 	metricNameSpace string,
 	aggregationType insights.AggregationType,
+	freshness helpers.MetricFreshness,
 ) (insights.Metric, error) {
 
 	interval := "PT1M"
 	timespan := fmt.Sprintf(
 		"%s/%s",
-		time.Now().UTC().Add(time.Duration(-5)*time.Minute).Format("2006-01-02T15:04:05"),
+		time.Now().UTC().Add(-freshness.GetWindow()).Format("2006-01-02T15:04:05"),
 		time.Now().UTC().Format("2006-01-02T15:04:05"),
 	)
 	resourceURI := getMetricsResourceURL(client.SubscriptionID, resourceGroup, vmScaleSetName)
@@ -99,84 +101,102 @@ func GetValidatorMetricsForVMScaleSets(
 	metricsName,
 	metricNameSpace string,
 	aggregationType insights.AggregationType,
+	freshness helpers.MetricFreshness,
 ) (map[string]insights.Metric, error) {
 
 	result := make(map[string]insights.Metric, len(vmScaleSetNames))
 
-	type metricItem struct {
-		metric         insights.Metric
-		vmScaleSetName string
-	}
-
-	var names []interface{}
+	metrics := make([]insights.Metric, len(vmScaleSetNames))
 
-	for _, name := range vmScaleSetNames {
-		names = append(names, name)
-	}
-
-	out := fanout.ConcurrentResponseItems(ctx, func(ctx context.Context, value interface{}) (interface{}, error) {
-		vmScaleSetName := value.(string)
+	err := fanout.Run(ctx, len(vmScaleSetNames), fanout.Options{Mode: fanout.CancelOnError}, func(ctx context.Context, idx int) error {
 		metric, err := GetValidatorMetricsForVMScaleSet(
 			ctx,
 			client,
 			resourceGroup,
-			vmScaleSetName,
+			vmScaleSetNames[idx],
 			metricsName,
 			metricNameSpace,
 			aggregationType,
+			freshness,
 		)
-
-		if err != nil {
-			return metricItem{}, err
-		}
-
-		return metricItem{
-			metric:         metric,
-			vmScaleSetName: vmScaleSetName,
-		}, nil
-
-	}, names...)
-
-	items, err := fanout.ReadItemChannel(out)
+		metrics[idx] = metric
+		return err
+	})
 
 	if err != nil {
 		return result, err
 	}
 
-	for _, item := range items {
-		mi := item.(metricItem)
-		result[mi.vmScaleSetName] = mi.metric
+	for idx, vmScaleSetName := range vmScaleSetNames {
+		result[vmScaleSetName] = metrics[idx]
 	}
 
 	return result, err
 
 }
 
-func getDataAggregation(data insights.MetricValue, aggregationType insights.AggregationType, checkValue int) int {
+// getDataValue returns metric value for aggregation type. Second result is false if the value is absent
+func getDataValue(data insights.MetricValue, aggregationType insights.AggregationType) (float64, bool) {
+
+	var value *float64
 
 	switch aggregationType {
 	case insights.Maximum:
-		if data.Maximum != nil && int(*data.Maximum) == checkValue {
-			return checkValue
-		}
+		value = data.Maximum
 	case insights.Minimum:
-		if data.Minimum != nil && int(*data.Minimum) == checkValue {
-			return checkValue
-		}
+		value = data.Minimum
 	case insights.Average:
-		if data.Average != nil && int(*data.Average) == checkValue {
-			return checkValue
-		}
+		value = data.Average
 	case insights.Count:
-		if data.Count != nil && int(*data.Count) == checkValue {
-			return checkValue
-		}
+		value = data.Count
 	case insights.Total:
-		if data.Total != nil && int(*data.Total) == checkValue {
-			return checkValue
+		value = data.Total
+	}
+
+	if value == nil {
+		return 0, false
+	}
+
+	return *value, true
+}
+
+// lastDataValue returns the latest time series value for aggregation type and its timestamp. Third result is false if there are no values
+func lastDataValue(series insights.TimeSeriesElement, aggregationType insights.AggregationType) (float64, time.Time, bool) {
+
+	var value float64
+	var timestamp time.Time
+	found := false
+
+	if series.Data == nil {
+		return value, timestamp, found
+	}
+
+	for _, data := range *series.Data {
+		dataValue, ok := getDataValue(data, aggregationType)
+		if !ok || data.TimeStamp == nil {
+			continue
+		}
+		if !found || data.TimeStamp.After(timestamp) {
+			value = dataValue
+			timestamp = data.TimeStamp.Time
+			found = true
+		}
+	}
+
+	return value, timestamp, found
+}
+
+// seriesHostname returns host dimension value of the time series
+func seriesHostname(series insights.TimeSeriesElement) string {
+	if series.Metadatavalues == nil {
+		return ""
+	}
+	for _, meta := range *series.Metadatavalues {
+		if meta.Name != nil && meta.Value != nil && meta.Name.Value != nil && *meta.Name.Value == "host" {
+			return *meta.Value
 		}
 	}
-	return -1
+	return ""
 }
 
 // LogMetrics ...
@@ -265,6 +285,7 @@ func WaitValidatorMetricNameForMetricNamespace(
 
 }
 
+// WaitValidatorMetricNamesForMetricNamespace waits while metric definitions of VM scale sets are found attempts times in a row
 func WaitValidatorMetricNamesForMetricNamespace(
 	ctx context.Context,
 	client *insights.MetricDefinitionsClient,
@@ -272,110 +293,56 @@ func WaitValidatorMetricNamesForMetricNamespace(
 	resourceGroup,
 	metricName,
 	metricNameSpace string,
-	period int,
+	period time.Duration,
 	attempts int,
 ) (map[string]string, error) {
 
-	ticker := time.NewTicker(time.Duration(period) * time.Second)
-	defer ticker.Stop()
-
-	type metricItem struct {
-		metric         string
-		vmScaleSetName string
-	}
+	metrics := make([]string, len(vmScaleSetNames))
 
-	var names []interface{}
+	err := fanout.Run(ctx, len(vmScaleSetNames), fanout.Options{Mode: fanout.CancelOnError}, func(ctx context.Context, idx int) error {
+		vmScaleSetName := vmScaleSetNames[idx]
 
-	for _, name := range vmScaleSetNames {
-		names = append(names, name)
-	}
-
-	out := fanout.ConcurrentResponseItems(ctx, func(ctx context.Context, value interface{}) (interface{}, error) {
-		vmScaleSetName := value.(string)
-
-		successCount := 0
-		errorsCount := 0
-
-		for {
-			select {
-			case <-ctx.Done():
-				return metricItem{}, fmt.Errorf("cancelled waiting for metric of metric namespace %q. Instance scale set: %q", metricNameSpace, vmScaleSetName)
-			case <-ticker.C:
-				metric, err := GetValidatorMetricNameForMetricNamespace(
-					ctx,
-					client,
-					vmScaleSetName,
-					resourceGroup,
-					metricName,
-					metricNameSpace,
-				)
-
-				if err != nil {
-					dErr := &autorest.DetailedError{}
-					if errors.As(err, dErr) && dErr.StatusCode == 404 {
-						successCount = 0
-						continue
-					}
-					errorsCount++
-					log.Printf(
-						"[DEBUG] faiover: Metrics. error getting metric definitions for metric name %q, namespace %q, scale set %q: %v",
-						metricName,
-						metricNameSpace,
-						vmScaleSetName,
-						err,
-					)
-					if errorsCount >= attempts {
-						return metricItem{}, fmt.Errorf(
-							"error getting metric definitions for metric name %q, namespace %q, scale set %q. Errors occurred %d: %w", metricName,
-							metricNameSpace,
-							vmScaleSetName,
-							errorsCount,
-							err,
-						)
-					}
-					continue
-				}
-
-				errorsCount = 0
-
-				if metric == "" {
-					successCount = 0
-					continue
-				}
-
-				successCount++
-
-				if successCount >= attempts {
-					return metricItem{
-						metric:         metric,
-						vmScaleSetName: vmScaleSetName,
-					}, nil
+		metric, err := helpers.WaitForStableValue(ctx, period, attempts, func(ctx context.Context) (string, error) {
+			metric, err := GetValidatorMetricNameForMetricNamespace(
+				ctx,
+				client,
+				vmScaleSetName,
+				resourceGroup,
+				metricName,
+				metricNameSpace,
+			)
+			if err != nil {
+				dErr := &autorest.DetailedError{}
+				if errors.As(err, dErr) && dErr.StatusCode == 404 {
+					return "", nil
 				}
-
-				log.Printf(
-					"[DEBUG] failover: Metrics. successfully get metric definition %q for namespace %q and scale set %q. Retried: %d",
-					metric,
-					metricNameSpace,
-					vmScaleSetName,
-					successCount,
-				)
-
+				return "", err
 			}
+			return metric, nil
+		})
+
+		if err != nil {
+			return fmt.Errorf(
+				"error getting metric definitions for metric name %q, namespace %q, scale set %q: %w",
+				metricName,
+				metricNameSpace,
+				vmScaleSetName,
+				err,
+			)
 		}
 
-	}, names...)
+		metrics[idx] = metric
+		return nil
+	})
 
 	result := make(map[string]string, len(vmScaleSetNames))
 
-	items, err := fanout.ReadItemChannel(out)
-
 	if err != nil {
 		return result, err
 	}
 
-	for _, item := range items {
-		mi := item.(metricItem)
-		result[mi.vmScaleSetName] = mi.metric
+	for idx, vmScaleSetName := range vmScaleSetNames {
+		result[vmScaleSetName] = metrics[idx]
 	}
 
 	return result, nil
@@ -2,9 +2,13 @@ package azure
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/preview/monitor/mgmt/2018-03-01/insights"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
+	helperErrors "github.com/protofire/polkadot-failover-mechanism/pkg/helpers/errors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -140,7 +144,7 @@ func TestMetricsResponse(t *testing.T) {
 	mp[vmSSName1] = (*responseSuccess.Value)[0]
 	mp[vmSSName2] = (*responseBlank.Value)[0]
 
-	validator, err := findValidator(mp, insights.Maximum, 1)
+	validator, err := findValidator(mp, helpers.DefaultValidatorMetricCheck(), helpers.MetricFreshness{}, time.Now())
 	require.NoError(t, err)
 	require.Equal(t, vmSSName1, validator.ScaleSetName)
 	require.Equal(t, "primary000002", validator.Hostname)
@@ -157,10 +161,73 @@ func TestMetrics(t *testing.T) {
 	mp := make(map[string]insights.Metric)
 	mp[vmSSName1] = metric
 
-	validator, err := findValidator(mp, insights.Maximum, 1)
+	validator, err := findValidator(mp, helpers.DefaultValidatorMetricCheck(), helpers.MetricFreshness{}, time.Now())
 	require.NoError(t, err)
 	require.Equal(t, vmSSName1, validator.ScaleSetName)
 	require.Equal(t, "primary000000", validator.Hostname)
 	require.Equal(t, 1, validator.Metric)
 
 }
+
+func TestMetricsBlankResponseValues(t *testing.T) {
+	responseBlank, err := marshallResponse(metricsBlankResponse)
+	require.NoError(t, err)
+
+	mp := make(map[string]insights.Metric)
+	mp["test2"] = (*responseBlank.Value)[0]
+
+	_, err = findValidator(mp, helpers.DefaultValidatorMetricCheck(), helpers.MetricFreshness{}, time.Now())
+	require.Error(t, err)
+
+	validatorError := &helperErrors.ValidatorError{}
+	require.True(t, errors.As(err, validatorError))
+	require.True(t, validatorError.IsNotFound())
+	require.Equal(t, "test2=<no data>", validatorError.MetricValues())
+}
+
+func TestMetricsStaleValues(t *testing.T) {
+	metric, err := marshallMetric(metricResponse)
+	require.NoError(t, err)
+
+	mp := make(map[string]insights.Metric)
+	mp["test1"] = metric
+
+	freshness := helpers.MetricFreshness{Window: 5 * time.Minute, MaxAge: 3 * time.Minute}
+	lastTimestamp := time.Date(2020, 10, 21, 23, 42, 0, 0, time.UTC)
+
+	validator, err := findValidator(mp, helpers.DefaultValidatorMetricCheck(), freshness, lastTimestamp.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, "primary000000", validator.Hostname)
+
+	_, err = findValidator(mp, helpers.DefaultValidatorMetricCheck(), freshness, lastTimestamp.Add(4*time.Minute))
+	require.Error(t, err)
+
+	validatorError := &helperErrors.ValidatorError{}
+	require.True(t, errors.As(err, validatorError))
+	require.True(t, validatorError.IsNotFound())
+	require.Equal(t, "test1/primary000000=1<stale since 2020-10-21T23:42:00Z>", validatorError.MetricValues())
+}
+
+func TestMetricsValidatorMetricCheck(t *testing.T) {
+	metric, err := marshallMetric(metricResponse)
+	require.NoError(t, err)
+
+	mp := make(map[string]insights.Metric)
+	mp["test1"] = metric
+
+	check := helpers.ValidatorMetricCheck{Aggregation: helpers.MetricAggregationMaximum, Value: 2}
+
+	_, err = findValidator(mp, check, helpers.MetricFreshness{}, time.Now())
+	require.Error(t, err)
+
+	validatorError := &helperErrors.ValidatorError{}
+	require.True(t, errors.As(err, validatorError))
+	require.True(t, validatorError.IsNotFound())
+}
+
+func TestAggregationType(t *testing.T) {
+	require.Equal(t, insights.Maximum, aggregationType(""))
+	require.Equal(t, insights.Minimum, aggregationType(helpers.MetricAggregationMinimum))
+	require.Equal(t, insights.Average, aggregationType(helpers.MetricAggregationAverage))
+	require.Equal(t, insights.Total, aggregationType(helpers.MetricAggregationSum))
+}
@@ -0,0 +1,64 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2020-06-30/compute"
+)
+
+// GetAutomaticRepairsPolicy returns automatic repairs policy of the VM scale set. Nil is returned if the policy is not set
+func GetAutomaticRepairsPolicy(
+	ctx context.Context,
+	client *compute.VirtualMachineScaleSetsClient,
+	resourceGroup,
+	vmScaleSetName string,
+) (*compute.AutomaticRepairsPolicy, error) {
+
+	vmss, err := client.Get(ctx, resourceGroup, vmScaleSetName)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot get vm scale set %q: %w", vmScaleSetName, err)
+	}
+
+	if vmss.VirtualMachineScaleSetProperties == nil {
+		return nil, nil
+	}
+
+	return vmss.VirtualMachineScaleSetProperties.AutomaticRepairsPolicy, nil
+}
+
+// SetAutomaticRepairsPolicy updates automatic repairs policy of the VM scale set
+func SetAutomaticRepairsPolicy(
+	ctx context.Context,
+	client *compute.VirtualMachineScaleSetsClient,
+	resourceGroup,
+	vmScaleSetName string,
+	policy compute.AutomaticRepairsPolicy,
+) error {
+
+	log.Printf("[DEBUG] failover: updating vm scale set %q automatic repairs enabled: %t...", vmScaleSetName, policy.Enabled != nil && *policy.Enabled)
+
+	updateFuture, err := client.Update(ctx, resourceGroup, vmScaleSetName, compute.VirtualMachineScaleSetUpdate{
+		VirtualMachineScaleSetUpdateProperties: &compute.VirtualMachineScaleSetUpdateProperties{
+			AutomaticRepairsPolicy: &policy,
+		},
+	})
+
+	if err != nil {
+		return fmt.Errorf("cannot update vm scale set %q: %w", vmScaleSetName, err)
+	}
+
+	if err := waitForFuture(ctx, &updateFuture, client); err != nil {
+		return fmt.Errorf(
+			"error waiting for VM Scale Set %q (Resource Group %q) is being updated. Error type: %T: %w",
+			vmScaleSetName,
+			resourceGroup,
+			err,
+			err,
+		)
+	}
+
+	return nil
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
 	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/errors"
 
 	"github.com/Azure/azure-sdk-for-go/profiles/2019-03-01/resources/mgmt/insights"
@@ -25,7 +27,8 @@ func GetCurrentValidator(
 	resourceGroup,
 	metricName,
 	metricNameSpace string,
-	aggregator insights.AggregationType,
+	check helpers.ValidatorMetricCheck,
+	freshness helpers.MetricFreshness,
 ) (Validator, error) {
 
 	log.Printf("[DEBUG]. Getting metrics for vm scale sets: %s", strings.Join(vmScaleSetNames, ", "))
@@ -37,59 +40,168 @@ func GetCurrentValidator(
 		resourceGroup,
 		metricName,
 		metricNameSpace,
-		aggregator,
+		aggregationType(check.GetAggregation()),
+		freshness,
 	)
 
 	if err != nil {
 		return Validator{}, fmt.Errorf("[ERROR]. Cannot get metric %s for namespace %s: %w", metricName, metricNameSpace, err)
 	}
 
-	return findValidator(metrics, aggregator, 1)
+	return findValidator(metrics, check, freshness, time.Now())
 
 }
 
-func findValidator(metrics map[string]insights.Metric, aggregationType insights.AggregationType, checkValue int) (Validator, error) {
+// aggregationType returns azure monitor aggregation of validator metric aggregation
+func aggregationType(aggregation helpers.MetricAggregation) insights.AggregationType {
+	switch aggregation {
+	case helpers.MetricAggregationMinimum:
+		return insights.Minimum
+	case helpers.MetricAggregationAverage:
+		return insights.Average
+	case helpers.MetricAggregationSum:
+		return insights.Total
+	default:
+		return insights.Maximum
+	}
+}
+
+// findValidator finds the only host which latest metric value is the check validator value. Values older than freshness max age are stale
+func findValidator(
+	metrics map[string]insights.Metric,
+	check helpers.ValidatorMetricCheck,
+	freshness helpers.MetricFreshness,
+	now time.Time,
+) (Validator, error) {
 
 	var validators []Validator
+	aggregation := aggregationType(check.GetAggregation())
+	var values []errors.MetricValue
 
 	for vmScaleSetName, metric := range metrics {
-		if metric.Timeseries != nil && len(*metric.Timeseries) > 0 {
-			series := (*metric.Timeseries)[0]
-			if series.Data != nil && len(*series.Data) > 0 {
-				for _, data := range *series.Data {
-					metadata := series.Metadatavalues
-					hostname := ""
-					if metadata != nil && len(*series.Metadatavalues) > 0 {
-						for _, meta := range *series.Metadatavalues {
-							if meta.Name != nil && meta.Value != nil && meta.Name.Value != nil && *meta.Name.Value == "host" {
-								hostname = *meta.Value
-							}
-						}
-					}
-					if getDataAggregation(data, aggregationType, checkValue) == checkValue {
-						validators = append(validators, Validator{
-							ScaleSetName: vmScaleSetName,
-							Hostname:     hostname,
-							Metric:       checkValue,
-						})
-						break
-					}
+		found := false
+		if metric.Timeseries != nil {
+			for _, series := range *metric.Timeseries {
+				dataValue, timestamp, ok := lastDataValue(series, aggregation)
+				if !ok {
+					continue
+				}
+				found = true
+				hostname := seriesHostname(series)
+				value := errors.MetricValue{
+					Instance:  vmScaleSetName,
+					Value:     dataValue,
+					Timestamp: timestamp,
+					Stale:     freshness.Stale(timestamp, now),
+				}
+				if hostname != "" {
+					value.Instance = fmt.Sprintf("%s/%s", vmScaleSetName, hostname)
+				}
+				values = append(values, value)
+				if !value.Stale && check.IsValidator(dataValue) {
+					validators = append(validators, Validator{
+						ScaleSetName: vmScaleSetName,
+						Hostname:     hostname,
+						Metric:       check.Value,
+					})
 				}
 			}
 		}
+		if !found {
+			values = append(values, errors.MetricValue{Instance: vmScaleSetName, Missing: true})
+		}
 	}
 
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Instance < values[j].Instance
+	})
+
 	switch len(validators) {
 	case 0:
-		return Validator{}, errors.NewValidatorError("cannot find validators", errors.ValidatorErrorNotFound)
+		return Validator{}, errors.NewValidatorError("cannot find validators", errors.ValidatorErrorNotFound).WithValues(values)
 	case 1:
 		return validators[0], nil
 	default:
-		return Validator{}, errors.NewValidatorError(fmt.Sprintf("found %d validators: %#v", len(validators), validators), errors.ValidatorErrorMultiple)
+		return Validator{}, errors.NewValidatorError(fmt.Sprintf("found %d validators: %#v", len(validators), validators), errors.ValidatorErrorMultiple).WithValues(values)
 	}
 
 }
 
+// ValidatorMetric is the latest validator metric value reported by a VM scale set host
+type ValidatorMetric struct {
+	ScaleSetName string
+	Hostname     string
+	Value        float64
+	// Missing is true if there are no metric data points for the VM scale set
+	Missing bool
+	// Timestamp is the time of the latest value. Stale is true if it is older than metric max age
+	Timestamp time.Time
+	Stale     bool
+}
+
+// GetValidatorMetricValues returns the latest validator metric values of VM scale sets hosts aggregated with aggregation
+func GetValidatorMetricValues(
+	ctx context.Context,
+	client *insights.MetricsClient,
+	vmScaleSetNames []string,
+	resourceGroup,
+	metricName,
+	metricNameSpace string,
+	aggregation helpers.MetricAggregation,
+	freshness helpers.MetricFreshness,
+) ([]ValidatorMetric, error) {
+
+	metrics, err := GetValidatorMetricsForVMScaleSets(
+		ctx,
+		client,
+		vmScaleSetNames,
+		resourceGroup,
+		metricName,
+		metricNameSpace,
+		aggregationType(aggregation),
+		freshness,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("[ERROR]. Cannot get metric %s for namespace %s: %w", metricName, metricNameSpace, err)
+	}
+
+	var values []ValidatorMetric
+	now := time.Now()
+
+	for vmScaleSetName, metric := range metrics {
+		found := false
+		if metric.Timeseries != nil {
+			for _, series := range *metric.Timeseries {
+				dataValue, timestamp, ok := lastDataValue(series, aggregationType(aggregation))
+				if !ok {
+					continue
+				}
+				values = append(values, ValidatorMetric{
+					ScaleSetName: vmScaleSetName,
+					Hostname:     seriesHostname(series),
+					Value:        dataValue,
+					Timestamp:    timestamp,
+					Stale:        freshness.Stale(timestamp, now),
+				})
+				found = true
+			}
+		}
+		if !found {
+			values = append(values, ValidatorMetric{ScaleSetName: vmScaleSetName, Missing: true})
+		}
+	}
+
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].ScaleSetName != values[j].ScaleSetName {
+			return values[i].ScaleSetName < values[j].ScaleSetName
+		}
+		return values[i].Hostname < values[j].Hostname
+	})
+
+	return values, nil
+}
+
 // WaitForValidator waits while validator metrics is being appeared
 func WaitForValidator(
 	ctx context.Context,
@@ -121,7 +233,8 @@ func WaitForValidator(
 				resourceGroup,
 				metricName,
 				metricNamespace,
-				insights.Maximum,
+				helpers.DefaultValidatorMetricCheck(),
+				helpers.DefaultMetricFreshness(),
 			)
 			if err == nil && validator.ScaleSetName != "" {
 				return validator, err
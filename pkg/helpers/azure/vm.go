@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"path"
 	"strings"
 	"time"
 
@@ -153,40 +154,22 @@ func getVMInstancesFromVMScaleSets(
 	vmScaleSets []compute.VirtualMachineScaleSet,
 ) (VMSMap, error) {
 
-	type vmsItem struct {
-		vmScaleSetName string
-		vms            []compute.VirtualMachineScaleSetVM
-	}
-
-	var names []interface{}
-
-	for _, vmScaleSet := range vmScaleSets {
-		names = append(names, *vmScaleSet.Name)
-	}
+	vms := make([][]compute.VirtualMachineScaleSetVM, len(vmScaleSets))
 
-	out := fanout.ConcurrentResponseItems(ctx, func(ctx context.Context, value interface{}) (interface{}, error) {
-		name := value.(string)
-		vmScaleSetVMs, err := getVMInstancesFromVMScaleSet(ctx, client, resourceGroup, name)
-		if err != nil {
-			return nil, err
-		}
-		return vmsItem{
-			vmScaleSetName: name,
-			vms:            vmScaleSetVMs,
-		}, nil
-	}, names...)
+	err := fanout.Run(ctx, len(vmScaleSets), fanout.Options{Mode: fanout.CancelOnError}, func(ctx context.Context, idx int) error {
+		vmScaleSetVMs, err := getVMInstancesFromVMScaleSet(ctx, client, resourceGroup, *vmScaleSets[idx].Name)
+		vms[idx] = vmScaleSetVMs
+		return err
+	})
 
 	result := make(VMSMap)
 
-	items, err := fanout.ReadItemChannel(out)
-
 	if err != nil {
 		return result, err
 	}
 
-	for _, item := range items {
-		vmsItem := item.(vmsItem)
-		result[vmsItem.vmScaleSetName] = vmsItem.vms
+	for idx, vmScaleSet := range vmScaleSets {
+		result[*vmScaleSet.Name] = vms[idx]
 	}
 
 	return result, nil
@@ -261,6 +244,44 @@ func getVirtualMachineScaleSetInterfaces(ctx context.Context, client *network.In
 
 }
 
+// GetVirtualMachineScaleSetPrivateIPs returns primary private IP addresses of VM scale set VMs by VM instance IDs
+func GetVirtualMachineScaleSetPrivateIPs(ctx context.Context, client *network.InterfacesClient, resourceGroup string, vmScaleSet string) (map[string]string, error) {
+
+	ifss, err := getVirtualMachineScaleSetInterfaces(ctx, client, resourceGroup, vmScaleSet)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ips := make(map[string]string, len(ifss))
+
+	for _, ifc := range ifss {
+
+		if ifc.InterfacePropertiesFormat == nil || ifc.VirtualMachine == nil || ifc.VirtualMachine.ID == nil {
+			continue
+		}
+
+		ipConfigurations := ifc.InterfacePropertiesFormat.IPConfigurations
+
+		if ipConfigurations == nil {
+			continue
+		}
+
+		for _, conf := range *ipConfigurations {
+			if conf.InterfaceIPConfigurationPropertiesFormat == nil || conf.PrivateIPAddress == nil {
+				continue
+			}
+			if _, ok := ips[path.Base(*ifc.VirtualMachine.ID)]; !ok || (conf.Primary != nil && *conf.Primary) {
+				ips[path.Base(*ifc.VirtualMachine.ID)] = *conf.PrivateIPAddress
+			}
+		}
+
+	}
+
+	return ips, nil
+
+}
+
 func FilterVirtualMachineScaleSets(vms *[]compute.VirtualMachineScaleSet, handler func(vm compute.VirtualMachineScaleSet) bool) {
 
 	start := 0
@@ -474,29 +495,18 @@ func VirtualMachineScaleSetIPAddressIDsByLocation(vmScaleSets []compute.VirtualM
 		return nil, err
 	}
 
-	type ipItem struct {
-		ip       string
-		location string
-	}
-
-	var vmScaleSetInterfaces []interface{}
-
-	for _, vmScaleSet := range vmScaleSets {
-		vmScaleSetInterfaces = append(vmScaleSetInterfaces, vmScaleSet)
-	}
+	vmScaleSetIPs := make([][]string, len(vmScaleSets))
 
-	out := fanout.ConcurrentResponseItems(ctx, func(ctx context.Context, value interface{}) (interface{}, error) {
+	err = fanout.Run(ctx, len(vmScaleSets), fanout.Options{Mode: fanout.CancelOnError}, func(ctx context.Context, idx int) error {
 
-		vm := value.(compute.VirtualMachineScaleSet)
+		vm := vmScaleSets[idx]
 
 		ifss, err := getVirtualMachineScaleSetInterfaces(ctx, &interfaceClient, resourceGroup, *vm.Name)
 
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		var ipItems []ipItem
-
 		for _, ifc := range ifss {
 
 			if ifc.InterfacePropertiesFormat == nil {
@@ -512,30 +522,25 @@ func VirtualMachineScaleSetIPAddressIDsByLocation(vmScaleSets []compute.VirtualM
 			for _, conf := range *ipConfigurations {
 				ipAddress, err := getIPAddressFromID(ctx, &publicAPIClient, *conf.PublicIPAddress.ID)
 				if err != nil {
-					return nil, err
+					return err
 				}
-				ipItems = append(ipItems, ipItem{ip: ipAddress, location: *vm.Location})
+				vmScaleSetIPs[idx] = append(vmScaleSetIPs[idx], ipAddress)
 			}
 
 		}
 
-		return ipItems, nil
+		return nil
 
-	}, vmScaleSetInterfaces...)
+	})
 
 	ips := make(map[string][]string, len(vmScaleSets))
 
-	items, err := fanout.ReadItemChannel(out)
-
 	if err != nil {
 		return ips, nil
 	}
 
-	for _, item := range items {
-		ipItems := item.([]ipItem)
-		for _, ipItem := range ipItems {
-			ips[ipItem.location] = append(ips[ipItem.location], ipItem.ip)
-		}
+	for idx, vmScaleSet := range vmScaleSets {
+		ips[*vmScaleSet.Location] = append(ips[*vmScaleSet.Location], vmScaleSetIPs[idx]...)
 	}
 
 	return ips, nil
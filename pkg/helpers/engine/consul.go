@@ -0,0 +1,144 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type consulKVPair struct {
+	Key     string `json:"Key"`
+	Session string `json:"Session"`
+}
+
+type consulSession struct {
+	ID   string `json:"ID"`
+	Node string `json:"Node"`
+}
+
+type consulNode struct {
+	Node struct {
+		Node    string `json:"Node"`
+		Address string `json:"Address"`
+	} `json:"Node"`
+}
+
+// ConsulLockHolder is Consul node holding the lock
+type ConsulLockHolder struct {
+	Node    string
+	Address string
+}
+
+// consulGet decodes Consul HTTP API response into out. It returns false if the object does not exist
+func consulGet(ctx context.Context, client *http.Client, address, path string, out interface{}) (bool, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(address, "/")+path, nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("cannot call consul %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("cannot call consul %s: status %s", path, resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return false, fmt.Errorf("cannot decode consul %s response: %w", path, err)
+	}
+
+	return true, nil
+}
+
+// GetConsulLockHolder returns Consul node holding the lock key. Empty holder is returned if the lock is not held
+func GetConsulLockHolder(ctx context.Context, client *http.Client, address, key string) (ConsulLockHolder, error) {
+
+	var pairs []consulKVPair
+	found, err := consulGet(ctx, client, address, "/v1/kv/"+strings.TrimPrefix(key, "/"), &pairs)
+	if err != nil || !found || len(pairs) == 0 || pairs[0].Session == "" {
+		return ConsulLockHolder{}, err
+	}
+
+	var sessions []consulSession
+	found, err = consulGet(ctx, client, address, "/v1/session/info/"+url.PathEscape(pairs[0].Session), &sessions)
+	if err != nil || !found || len(sessions) == 0 {
+		return ConsulLockHolder{}, err
+	}
+
+	holder := ConsulLockHolder{Node: sessions[0].Node}
+
+	// node address is the instance private address advertised by consul agent
+	node := consulNode{}
+	found, err = consulGet(ctx, client, address, "/v1/catalog/node/"+url.PathEscape(holder.Node), &node)
+	if err != nil {
+		return ConsulLockHolder{}, err
+	}
+	if found {
+		holder.Address = node.Node.Address
+	}
+
+	return holder, nil
+}
+
+// ConsulSource detects the validator by the holder of Consul lock instances run polkadot validator under
+type ConsulSource struct {
+	// Resolver maps the lock holder node address to instance. Only node names are matched if it is nil
+	Resolver AddressResolver
+	Address  string
+	Key      string
+	Client   *http.Client
+}
+
+// NewConsulSource creates Consul lock validator source
+func NewConsulSource(resolver AddressResolver, address, key string) *ConsulSource {
+	return &ConsulSource{
+		Resolver: resolver,
+		Address:  address,
+		Key:      key,
+		Client:   &http.Client{Timeout: DefaultRPCTimeout},
+	}
+}
+
+// GetValidatorMetrics returns 1 for the instance holding the lock and 0 for other instances.
+// The lock holder node is matched to instance by name or by address
+func (s *ConsulSource) GetValidatorMetrics(ctx context.Context, groups []Group) ([]Metric, error) {
+
+	holder, err := GetConsulLockHolder(ctx, s.Client, s.Address, s.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[DEBUG] failover: Consul lock %q holder node %q with address %q", s.Key, holder.Node, holder.Address)
+
+	addresses := map[string]string{}
+	if s.Resolver != nil && holder.Address != "" {
+		if addresses, err = s.Resolver.InstanceAddresses(ctx, groups); err != nil {
+			return nil, err
+		}
+	}
+
+	var metrics []Metric
+	for _, group := range groups {
+		for _, instance := range group.Instances {
+			metric := Metric{Group: group.Name, Instance: instance}
+			if holder.Node != "" && (instance == holder.Node || (holder.Address != "" && addresses[instance] == holder.Address)) {
+				metric.Value = 1
+			}
+			metrics = append(metrics, metric)
+		}
+	}
+
+	return metrics, nil
+}
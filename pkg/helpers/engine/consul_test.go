@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	helperErrors "github.com/protofire/polkadot-failover-mechanism/pkg/helpers/errors"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/resource"
+	"github.com/stretchr/testify/require"
+)
+
+// newConsulServer starts Consul HTTP API stub with prefix/.lock held by node with address. Empty node means the lock is not held
+func newConsulServer(t *testing.T, node, address string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/kv/prefix/.lock", func(w http.ResponseWriter, r *http.Request) {
+		if node == "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"Key":"prefix/.lock","Session":"session-1","Flags":3304740253564472344,"Value":null}]`))
+	})
+	mux.HandleFunc("/v1/session/info/session-1", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"ID":"session-1","Node":"` + node + `","Behavior":"release"}]`))
+	})
+	mux.HandleFunc("/v1/catalog/node/"+node, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Node":{"Node":"` + node + `","Address":"` + address + `"},"Services":{}}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGetConsulLockHolder(t *testing.T) {
+	server := newConsulServer(t, "ip-10-0-0-3", "10.0.0.3")
+
+	holder, err := GetConsulLockHolder(context.Background(), server.Client(), server.URL, resource.DefaultConsulLockKey)
+	require.NoError(t, err)
+	require.Equal(t, ConsulLockHolder{Node: "ip-10-0-0-3", Address: "10.0.0.3"}, holder)
+
+	server = newConsulServer(t, "", "")
+
+	holder, err = GetConsulLockHolder(context.Background(), server.Client(), server.URL, resource.DefaultConsulLockKey)
+	require.NoError(t, err)
+	require.Equal(t, ConsulLockHolder{}, holder)
+}
+
+func TestConsulSource(t *testing.T) {
+	backend := testBackend()
+	backend.Addresses["i3"] = "10.0.0.3"
+	backend.Addresses["i4"] = "10.0.0.4"
+
+	groups, err := backend.ListGroups(context.Background())
+	require.NoError(t, err)
+
+	// holder is matched by address
+	server := newConsulServer(t, "ip-10-0-0-3", "10.0.0.3")
+	metrics, err := NewConsulSource(backend, server.URL, resource.DefaultConsulLockKey).GetValidatorMetrics(context.Background(), groups)
+	require.NoError(t, err)
+	validator, err := FindValidator(metrics, 1)
+	require.NoError(t, err)
+	require.Equal(t, "i3", validator.Instance)
+
+	// holder is matched by node name
+	server = newConsulServer(t, "i5", "10.0.0.5")
+	metrics, err = NewConsulSource(nil, server.URL, resource.DefaultConsulLockKey).GetValidatorMetrics(context.Background(), groups)
+	require.NoError(t, err)
+	validator, err = FindValidator(metrics, 1)
+	require.NoError(t, err)
+	require.Equal(t, "i5", validator.Instance)
+}
+
+func TestEngineConsulCrossCheck(t *testing.T) {
+	backend := testBackend()
+	backend.Metrics["i3"] = 1
+	backend.Addresses["i3"] = "10.0.0.3"
+	backend.Addresses["i5"] = "10.0.0.5"
+
+	failover := testFailover(resource.FailOverModeSingle)
+	failover.OnValidatorUnknown = resource.ValidatorUnknownDeleteAll
+
+	failover.ConsulAddress = newConsulServer(t, "ip-10-0-0-3", "10.0.0.3").URL
+	result, err := New(backend, failover).Discover(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, Validator{Group: "g2", Instance: "i3", Location: 1}, result.Validator)
+
+	// metrics and the lock holder disagree. Failover refuses to delete instances even with delete_all policy
+	for _, node := range []string{"ip-10-0-0-5", ""} {
+		failover.ConsulAddress = newConsulServer(t, node, "10.0.0.5").URL
+		e := New(backend, failover)
+		e.PollInterval = 0
+
+		result, err = e.Run(context.Background())
+		require.Error(t, err)
+		require.NotNil(t, result.Abort)
+		validatorError := &helperErrors.ValidatorError{}
+		require.True(t, errors.As(result.ValidatorErr, validatorError))
+		require.True(t, validatorError.Conflict())
+		require.Empty(t, backend.Deleted)
+	}
+}
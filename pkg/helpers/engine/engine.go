@@ -0,0 +1,597 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
+	helperErrors "github.com/protofire/polkadot-failover-mechanism/pkg/helpers/errors"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/fanout"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/resource"
+)
+
+const (
+	// DefaultPollInterval is the interval between validator checks after instances have been deleted
+	DefaultPollInterval = 5 * time.Second
+	// DefaultResumeTimeout is the timeout of resuming autoscalers self healing after instances have been deleted
+	DefaultResumeTimeout = 5 * time.Minute
+)
+
+// Group is an instance group with running polkadot instances
+type Group struct {
+	// Name is auto scaling group, instance group manager or scale set name
+	Name string
+	// Location is index of the group region in failover locations. -1 if the region is not in locations
+	Location int
+	// Instances are names of running instances
+	Instances []string
+	// Standby are names of stopped instances kept by the group for reuse. They are not counted as running
+	Standby []string
+}
+
+// Metric is validator metric value reported by an instance
+type Metric struct {
+	Group    string
+	Instance string
+	Value    float64
+	// Missing is true if there are no metric data points for the instance
+	Missing bool
+	// Stale is true if the last data point taken at Timestamp is older than metric_max_age. Stale values are unknown
+	Stale     bool
+	Timestamp time.Time
+}
+
+// Validator is the instance reporting validator metric
+type Validator struct {
+	Group    string
+	Instance string
+	// Location is index of the validator location. -1 if it is not known
+	Location int
+}
+
+// Detected returns the validator computed attributes. Location index is resolved with locations
+func (v Validator) Detected(locations []string) resource.DetectedValidator {
+	detected := resource.DetectedValidator{
+		Instance: v.Instance,
+		Group:    v.Group,
+	}
+	if v.Location >= 0 && v.Location < len(locations) {
+		detected.Location = locations[v.Location]
+	}
+	return detected
+}
+
+// ValidatorSource reports which instances validate
+type ValidatorSource interface {
+	// GetValidatorMetrics returns validator metric values of groups instances. Value 1 means the instance validates
+	// unless the source implements ValidatorValuer
+	GetValidatorMetrics(ctx context.Context, groups []Group) ([]Metric, error)
+}
+
+// ValidatorValuer is implemented by validator sources which validator instance reports value other than 1
+type ValidatorValuer interface {
+	ValidatorValue() int
+}
+
+// metricsSource is cloud backend validator source. The validator reports failover validator_metric_value
+type metricsSource struct {
+	CloudBackend
+	value int
+}
+
+// ValidatorValue returns metric value reported by the validator
+func (s metricsSource) ValidatorValue() int {
+	return s.value
+}
+
+// validatorValue returns metric value the source reports for the validator
+func validatorValue(source ValidatorSource) int {
+	if valuer, ok := source.(ValidatorValuer); ok {
+		return valuer.ValidatorValue()
+	}
+	return 1
+}
+
+// AddressResolver returns instances addresses. It is implemented by backends supporting rpc validator detection
+type AddressResolver interface {
+	// InstanceAddresses returns private addresses of groups instances by instance name
+	InstanceAddresses(ctx context.Context, groups []Group) (map[string]string, error)
+}
+
+// ValidatorProtector is implemented by backends protecting the validator instance from being removed by the cloud provider, e.g. by scale in
+type ValidatorProtector interface {
+	// ProtectValidator protects the validator instance of the group and removes protection of other instances
+	ProtectValidator(ctx context.Context, validator Validator) error
+}
+
+// SelfHealingSuspender is implemented by backends which autoscalers replace deleted or unhealthy instances.
+// Self healing is suspended while failover deletes instances, so that a replacement does not start validating meanwhile
+type SelfHealingSuspender interface {
+	// SuspendSelfHealing suspends autoscaler processes replacing instances of groups
+	SuspendSelfHealing(ctx context.Context, groups []Group) error
+	// ResumeSelfHealing resumes processes suspended by SuspendSelfHealing. It is called even if suspending has failed
+	ResumeSelfHealing(ctx context.Context) error
+}
+
+// CloudBackend lists, checks and deletes polkadot instances of a cloud provider.
+// Cloud monitoring metrics are the backend validator source
+type CloudBackend interface {
+	ValidatorSource
+	// ListGroups returns instance groups with running instances
+	ListGroups(ctx context.Context) ([]Group, error)
+	// DeleteInstances deletes instances of the group
+	DeleteInstances(ctx context.Context, group Group) error
+	// WaitForCount waits while the number of running instances becomes equal to count
+	WaitForCount(ctx context.Context, count int) error
+}
+
+// Result describes instances single and standby mode failover keeps and deletes
+type Result struct {
+	Groups       []Group
+	Validator    Validator
+	ValidatorErr error
+	// Running is number of running instances per location
+	Running []int
+	// Positions is number of instances per location failover keeps
+	Positions []int
+	// Standbys are instances kept in standby mode
+	Standbys []string
+	// MovedValidator is the validator deleted because it runs outside of the pinned location
+	MovedValidator string
+	// Replacement is the instance in the pinned location kept to take over the moved validator
+	Replacement string
+	// Deletions are groups with instances to delete
+	Deletions []Group
+	// KeepAll is on_validator_unknown policy result. Abort is set if on_validator_unknown or on_validator_outside_pin policy aborts failover
+	KeepAll bool
+	Abort   resource.AbortError
+}
+
+// DeletionsCount returns number of instances to delete
+func (r Result) DeletionsCount() int {
+	return instancesCount(r.Deletions)
+}
+
+// Plan converts result to failover plan
+func (r Result) Plan(locations int) resource.FailoverPlan {
+	plan := resource.NewFailoverPlan(locations)
+	plan.Validator = r.Validator.Instance
+	plan.ValidatorError = r.ValidatorErr
+	plan.Standbys = r.Standbys
+	plan.MovedValidator = r.MovedValidator
+	plan.Replacement = r.Replacement
+	plan.Abort = r.Abort
+	for _, group := range r.Deletions {
+		for _, instance := range group.Instances {
+			plan.AddDeletion(group.Location, instance)
+		}
+	}
+	for _, instances := range plan.Deletions {
+		sort.Strings(instances)
+	}
+	return plan
+}
+
+// Engine runs single and standby mode failover with a cloud backend
+type Engine struct {
+	Backend  CloudBackend
+	Failover *resource.Failover
+	// Sources detect the validator. All sources should detect the same validator
+	Sources []ValidatorSource
+	// PollInterval is the interval between validator checks after instances have been deleted
+	PollInterval time.Duration
+	// ResumeTimeout is the timeout of resuming autoscalers self healing. Resuming does not use the failover context that might be cancelled
+	ResumeTimeout time.Duration
+}
+
+// New creates failover engine
+func New(backend CloudBackend, failover *resource.Failover) *Engine {
+	return &Engine{
+		Backend:       backend,
+		Failover:      failover,
+		Sources:       ValidatorSources(backend, failover),
+		PollInterval:  DefaultPollInterval,
+		ResumeTimeout: DefaultResumeTimeout,
+	}
+}
+
+// ValidatorSources returns validator sources chosen by failover validator_detection.
+// Consul lock holder is the last source cross-checking the others if Consul address is set
+func ValidatorSources(backend CloudBackend, failover *resource.Failover) []ValidatorSource {
+	var sources []ValidatorSource
+	resolver, _ := backend.(AddressResolver)
+	if failover.DetectsWithMetrics() {
+		sources = append(sources, metricsSource{CloudBackend: backend, value: failover.GetValidatorMetricCheck().Value})
+	}
+	if failover.DetectsWithRPC() {
+		sources = append(sources, NewRPCSource(resolver, failover.GetRPCPort()))
+	}
+	if failover.ChecksConsulLock() {
+		sources = append(sources, NewConsulSource(resolver, failover.ConsulAddress, failover.GetConsulLockKey()))
+	}
+	return sources
+}
+
+func instancesCount(groups []Group) int {
+	s := 0
+	for _, group := range groups {
+		s += len(group.Instances)
+	}
+	return s
+}
+
+// metricValues returns metric values sorted by instance for validator errors
+func metricValues(metrics []Metric) []helperErrors.MetricValue {
+	values := make([]helperErrors.MetricValue, 0, len(metrics))
+	for _, metric := range metrics {
+		values = append(values, helperErrors.MetricValue{
+			Instance:  fmt.Sprintf("%s/%s", metric.Group, metric.Instance),
+			Value:     metric.Value,
+			Missing:   metric.Missing,
+			Stale:     metric.Stale,
+			Timestamp: metric.Timestamp,
+		})
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Instance < values[j].Instance
+	})
+	return values
+}
+
+// FindValidator returns the only instance with validator metric value
+func FindValidator(metrics []Metric, value int) (Validator, error) {
+
+	var validators []Validator
+	values := metricValues(metrics)
+
+	for _, metric := range metrics {
+		if !metric.Missing && !metric.Stale && int(metric.Value) == value {
+			validators = append(validators, Validator{Group: metric.Group, Instance: metric.Instance, Location: -1})
+		}
+	}
+
+	switch len(validators) {
+	case 0:
+		return Validator{Location: -1}, helperErrors.NewValidatorError("cannot find validators", helperErrors.ValidatorErrorNotFound).WithValues(values)
+	case 1:
+		return validators[0], nil
+	default:
+		return Validator{Location: -1}, helperErrors.NewValidatorError(
+			fmt.Sprintf("found %d validators: %v", len(validators), validators),
+			helperErrors.ValidatorErrorMultiple,
+		).WithValues(values)
+	}
+}
+
+func (e *Engine) getValidator(ctx context.Context, groups []Group) (Validator, error) {
+
+	validator := Validator{Location: -1}
+
+	for idx, source := range e.Sources {
+		metrics, err := source.GetValidatorMetrics(ctx, groups)
+		if err != nil {
+			return Validator{Location: -1}, err
+		}
+
+		sourceValidator, err := FindValidator(metrics, validatorValue(source))
+		if err != nil {
+			validatorError := &helperErrors.ValidatorError{}
+			if idx > 0 && errors.As(err, validatorError) {
+				// previous sources have detected the validator
+				return Validator{Location: -1}, helperErrors.NewValidatorError(
+					fmt.Sprintf("validator source has not confirmed validator %q: %s", validator.Instance, validatorError.Message),
+					helperErrors.ValidatorErrorConflict,
+				).WithValues(metricValues(metrics))
+			}
+			return sourceValidator, err
+		}
+
+		if idx > 0 && sourceValidator.Instance != validator.Instance {
+			return Validator{Location: -1}, helperErrors.NewValidatorError(
+				fmt.Sprintf("validator sources detected different validators %q and %q", validator.Instance, sourceValidator.Instance),
+				helperErrors.ValidatorErrorConflict,
+			).WithValues(metricValues(metrics))
+		}
+
+		validator = sourceValidator
+	}
+
+	// metrics might be labeled with group names different from listed ones, instance names are unique
+	for _, group := range groups {
+		for _, instance := range group.Instances {
+			if instance == validator.Instance {
+				validator.Group = group.Name
+				validator.Location = group.Location
+			}
+		}
+	}
+
+	return validator, nil
+}
+
+// Inspect lists instance groups, counts running instances per location and finds the validator. It neither decides nor changes anything
+func (e *Engine) Inspect(ctx context.Context) (Result, error) {
+
+	result := Result{Validator: Validator{Location: -1}}
+
+	groups, err := e.Backend.ListGroups(ctx)
+	if err != nil {
+		log.Printf("[ERROR] failover: Cannot get instance groups: %s", err)
+		return result, err
+	}
+
+	log.Printf("[DEBUG] failover: Found %d instance groups with %d instances", len(groups), instancesCount(groups))
+
+	result.Groups = groups
+	result.Running = make([]int, len(e.Failover.Locations))
+	for _, group := range groups {
+		if group.Location >= 0 && group.Location < len(result.Running) {
+			result.Running[group.Location] += len(group.Instances)
+		}
+	}
+
+	if instancesCount(groups) == 0 {
+		return result, nil
+	}
+
+	validator, err := e.getValidator(ctx, groups)
+	if err != nil {
+		validatorError := &helperErrors.ValidatorError{}
+		if !errors.As(err, validatorError) {
+			log.Printf("[ERROR] failover: Cannot get validator: %s", err)
+			return result, err
+		}
+		log.Printf("[WARNING] failover: Cannot get validator: %s", validatorError)
+		result.ValidatorErr = err
+		return result, nil
+	}
+
+	log.Printf("[DEBUG] failover: Found validator instance %q in group %q", validator.Instance, validator.Group)
+	result.Validator = validator
+
+	return result, nil
+}
+
+// Discover finds the validator and instances failover keeps and deletes. It does not change instances
+func (e *Engine) Discover(ctx context.Context) (Result, error) {
+
+	f := e.Failover
+
+	result, err := e.Inspect(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	groups, validator := result.Groups, result.Validator
+	detected := validator
+
+	keep := map[string]bool{}
+	positions := make([]int, len(f.Locations))
+
+	if validator.Instance != "" && !f.KeepsValidator(validator.Location) {
+		log.Printf("[WARNING] failover: Validator instance %q runs outside of pinned location %q", validator.Instance, f.PinLocation)
+		result.MovedValidator = validator.Instance
+		validator = Validator{Location: -1}
+		pin := helpers.FindStrIndex(f.PinLocation, f.Locations)
+		if result.Replacement = pinnedInstance(groups, pin); result.Replacement != "" {
+			keep[result.Replacement] = true
+			positions[pin] = 1
+		}
+	}
+
+	result.Validator = validator
+
+	if validator.Instance != "" {
+		keep[validator.Instance] = true
+	}
+
+	if f.IsStandbyMode() {
+		candidates := make([][]string, len(f.Locations))
+		for _, group := range groups {
+			if group.Location < 0 || group.Location >= len(candidates) {
+				continue
+			}
+			for _, instance := range group.Instances {
+				if !keep[instance] {
+					candidates[group.Location] = append(candidates[group.Location], instance)
+				}
+			}
+		}
+		positions, result.Standbys = f.SelectStandbys(candidates, validator.Location)
+		for _, instance := range result.Standbys {
+			keep[instance] = true
+		}
+		log.Printf("[DEBUG] failover: Keeping standby instances: %v", result.Standbys)
+	} else if validator.Location >= 0 && validator.Location < len(positions) {
+		positions[validator.Location] = 1
+	}
+
+	for _, group := range groups {
+		deletion := Group{Name: group.Name, Location: group.Location}
+		for _, instance := range group.Instances {
+			if !keep[instance] {
+				deletion.Instances = append(deletion.Instances, instance)
+			}
+		}
+		if len(deletion.Instances) > 0 {
+			result.Deletions = append(result.Deletions, deletion)
+		}
+	}
+
+	if abort := f.ApplyPinnedValidatorPolicy(detected.Instance, detected.Location, result.Replacement, result.DeletionsCount()); abort != nil {
+		log.Printf("[WARNING] failover: Policy %q keeps validator instance %q outside of pinned location", f.OnValidatorOutsidePin, detected.Instance)
+		result.Validator = detected
+		result.MovedValidator = ""
+		result.Replacement = ""
+		result.Deletions = nil
+		result.Abort = abort
+		positions = result.Running
+	} else if result.MovedValidator != "" {
+		log.Printf("[WARNING] failover: Validator instance %q will be deleted. Instance %q in pinned location takes over", result.MovedValidator, result.Replacement)
+	}
+
+	keepAll, abort := f.ApplyValidatorUnknownPolicy(result.ValidatorErr, result.DeletionsCount())
+
+	if keepAll || abort != nil {
+		log.Printf("[WARNING] failover: Validator has not been detected. Policy %q keeps all instances", f.OnValidatorUnknown)
+		result.KeepAll = keepAll
+		if abort != nil {
+			result.Abort = abort
+		}
+		result.Deletions = nil
+		positions = result.Running
+	}
+
+	result.Positions = positions
+
+	return result, nil
+}
+
+// Run discovers instances, sets failover counts and deletes instances besides the validator and standbys.
+// ValidatorUnknownError or ValidatorOutsidePinError is returned if on_validator_unknown or on_validator_outside_pin policy aborts failover
+func (e *Engine) Run(ctx context.Context) (Result, error) {
+
+	f := e.Failover
+
+	result, err := e.Discover(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	if result.Abort != nil && !f.DryRun {
+		return result, result.Abort
+	}
+
+	f.SetCounts(result.Positions...)
+	f.FillDefaultCountsIfNotSet()
+
+	if f.DryRun {
+		f.ApplyDryRun(result.Running)
+		log.Printf(
+			"[DEBUG] failover: Dry run. Calculated instance numbers per location: %v. Skipping deletion of %d instances",
+			f.DryRunInstances,
+			result.DeletionsCount(),
+		)
+		return result, nil
+	}
+
+	if protector, ok := e.Backend.(ValidatorProtector); ok && result.Validator.Instance != "" {
+		log.Printf("[DEBUG] failover: Protecting validator instance %q", result.Validator.Instance)
+		if err := protector.ProtectValidator(ctx, result.Validator); err != nil {
+			return result, fmt.Errorf("failover: cannot protect validator instance %q: %w", result.Validator.Instance, err)
+		}
+	}
+
+	if result.DeletionsCount() == 0 {
+		return result, nil
+	}
+
+	return result, e.removeInstances(ctx, result)
+}
+
+// removeInstances deletes instances and waits for the remaining instances count and the validator.
+// Autoscalers self healing is suspended meanwhile and resumed even if removal fails or ctx is cancelled
+func (e *Engine) removeInstances(ctx context.Context, result Result) (err error) {
+
+	if suspender, ok := e.Backend.(SelfHealingSuspender); ok {
+		defer func() {
+			resumeCtx, cancel := context.WithTimeout(context.Background(), e.ResumeTimeout)
+			defer cancel()
+			log.Printf("[DEBUG] failover: Resuming self healing...")
+			if resumeErr := suspender.ResumeSelfHealing(resumeCtx); resumeErr != nil {
+				log.Printf("[ERROR] failover: Cannot resume self healing: %s", resumeErr)
+				if err == nil {
+					err = fmt.Errorf("failover: cannot resume self healing: %w", resumeErr)
+				}
+			}
+		}()
+		log.Printf("[DEBUG] failover: Suspending self healing of %d instance groups", len(result.Groups))
+		if err := suspender.SuspendSelfHealing(ctx, result.Groups); err != nil {
+			return fmt.Errorf("failover: cannot suspend self healing: %w", err)
+		}
+	}
+
+	if err := e.deleteInstances(ctx, result.Deletions); err != nil {
+		return err
+	}
+
+	expected := instancesCount(result.Groups) - result.DeletionsCount()
+
+	log.Printf("[DEBUG] failover: Waiting for instances count: %d", expected)
+
+	if err := e.Backend.WaitForCount(ctx, expected); err != nil {
+		return err
+	}
+
+	// the replacement of the moved validator starts validating once the moved validator has been deleted
+	if result.Validator.Instance != "" || result.Replacement != "" {
+		log.Printf("[DEBUG] failover: Waiting for validator...")
+		if err := e.waitForValidator(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pinnedInstance returns the first running instance in location with index pin. Empty string is returned if there are no instances
+func pinnedInstance(groups []Group, pin int) string {
+	var instances []string
+	for _, group := range groups {
+		if group.Location == pin {
+			instances = append(instances, group.Instances...)
+		}
+	}
+	if len(instances) == 0 {
+		return ""
+	}
+	sort.Strings(instances)
+	return instances[0]
+}
+
+func (e *Engine) deleteInstances(ctx context.Context, deletions []Group) error {
+
+	log.Printf("[DEBUG] failover: Deleting %d instances", instancesCount(deletions))
+
+	err := fanout.Run(ctx, len(deletions), fanout.Options{}, func(ctx context.Context, idx int) error {
+		group := deletions[idx]
+		log.Printf("[DEBUG] failover: Deleting instances of group %q: %v", group.Name, group.Instances)
+		if err := e.Backend.DeleteInstances(ctx, group); err != nil {
+			return fmt.Errorf("cannot delete instances %v of group %q: %w", group.Instances, group.Name, err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failover: %w", err)
+	}
+
+	return nil
+}
+
+func (e *Engine) waitForValidator(ctx context.Context) error {
+
+	var err error
+
+	for {
+		groups, listErr := e.Backend.ListGroups(ctx)
+		if listErr == nil {
+			_, err = e.getValidator(ctx, groups)
+			if err == nil {
+				return nil
+			}
+		} else {
+			err = listErr
+		}
+		log.Printf("[DEBUG] failover: Validator is not ready: %s", err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timeout waiting for validator. last error: %w", err)
+		case <-time.After(e.PollInterval):
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	helperErrors "github.com/protofire/polkadot-failover-mechanism/pkg/helpers/errors"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/resource"
+	"github.com/stretchr/testify/require"
+)
+
+func testFailover(mode resource.FailOverMode) *resource.Failover {
+	return &resource.Failover{
+		Prefix:             "test",
+		FailoverMode:       mode,
+		Instances:          []int{2, 2, 2},
+		Locations:          []string{"l1", "l2", "l3"},
+		StandbyCount:       1,
+		OnValidatorUnknown: resource.ValidatorUnknownAbort,
+	}
+}
+
+func testBackend() *FakeBackend {
+	return NewFakeBackend(
+		Group{Name: "g1", Location: 0, Instances: []string{"i1", "i2"}},
+		Group{Name: "g2", Location: 1, Instances: []string{"i3", "i4"}},
+		Group{Name: "g3", Location: 2, Instances: []string{"i5", "i6"}},
+	)
+}
+
+// takeoverBackend starts validating on the replacement instance once the validator has been deleted
+type takeoverBackend struct {
+	*FakeBackend
+	validator   string
+	replacement string
+}
+
+func (b takeoverBackend) DeleteInstances(ctx context.Context, group Group) error {
+	if err := b.FakeBackend.DeleteInstances(ctx, group); err != nil {
+		return err
+	}
+	for _, instance := range group.Instances {
+		if instance == b.validator {
+			b.mu.Lock()
+			b.Metrics[b.replacement] = 1
+			b.mu.Unlock()
+		}
+	}
+	return nil
+}
+
+func TestEngineSingleMode(t *testing.T) {
+	backend := testBackend()
+	backend.Metrics["i3"] = 1
+	backend.Metrics["i4"] = 0
+
+	failover := testFailover(resource.FailOverModeSingle)
+	e := New(backend, failover)
+	e.PollInterval = 0
+
+	result, err := e.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, Validator{Group: "g2", Instance: "i3", Location: 1}, result.Validator)
+	require.Equal(t, []int{0, 1, 0}, failover.FailoverInstances)
+	require.Equal(t, []string{"i1", "i2", "i4", "i5", "i6"}, backend.Deleted)
+	require.Equal(t, "i3", backend.Protected)
+	require.True(t, backend.Resumed)
+	require.Empty(t, backend.Suspended)
+	require.Equal(t, []int{1}, backend.WaitedCounts)
+	require.Equal(t, [][]string{{"i1", "i2"}, {"i4"}, {"i5", "i6"}}, result.Plan(3).Deletions)
+}
+
+func TestEngineValidatorMetricValue(t *testing.T) {
+	backend := testBackend()
+	backend.Metrics["i1"] = 1
+	backend.Metrics["i3"] = 2
+
+	failover := testFailover(resource.FailOverModeSingle)
+	failover.ValidatorMetricValue = 2
+
+	result, err := New(backend, failover).Discover(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, Validator{Group: "g2", Instance: "i3", Location: 1}, result.Validator)
+}
+
+func TestEngineMultipleValidators(t *testing.T) {
+	backend := testBackend()
+	backend.Metrics["i1"] = 1
+	backend.Metrics["i5"] = 1
+
+	failover := testFailover(resource.FailOverModeSingle)
+	e := New(backend, failover)
+
+	result, err := e.Run(context.Background())
+	require.Error(t, err)
+	var abort *resource.ValidatorUnknownError
+	require.True(t, errors.As(err, &abort))
+	require.Equal(t, 6, abort.Deletions)
+	validatorError := &helperErrors.ValidatorError{}
+	require.True(t, errors.As(result.ValidatorErr, validatorError))
+	require.True(t, validatorError.MultipleValidators())
+	require.Empty(t, backend.Deleted)
+
+	failover.OnValidatorUnknown = resource.ValidatorUnknownDeleteAll
+	_, err = e.Run(context.Background())
+	require.NoError(t, err)
+	require.Len(t, backend.Deleted, 6)
+	require.Equal(t, []int{0}, backend.WaitedCounts)
+}
+
+func TestEngineNoValidator(t *testing.T) {
+	backend := testBackend()
+
+	failover := testFailover(resource.FailOverModeSingle)
+	failover.OnValidatorUnknown = resource.ValidatorUnknownKeepAll
+	e := New(backend, failover)
+
+	result, err := e.Run(context.Background())
+	require.NoError(t, err)
+	require.True(t, result.KeepAll)
+	require.Empty(t, backend.Deleted)
+	require.Equal(t, []int{2, 2, 2}, failover.FailoverInstances)
+}
+
+func TestEngineStandbyMode(t *testing.T) {
+	backend := testBackend()
+	backend.Metrics["i1"] = 1
+
+	failover := testFailover(resource.FailOverModeStandby)
+	e := New(backend, failover)
+	e.PollInterval = 0
+
+	result, err := e.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"i3"}, result.Standbys)
+	require.Equal(t, []int{1, 1, 0}, failover.FailoverInstances)
+	require.Equal(t, []string{"i2", "i4", "i5", "i6"}, backend.Deleted)
+}
+
+func TestEnginePinnedLocation(t *testing.T) {
+	backend := testBackend()
+	backend.Metrics["i1"] = 1
+
+	failover := testFailover(resource.FailOverModeSingle)
+	failover.PinLocation = "l3"
+	e := New(backend, failover)
+
+	result, err := e.Run(context.Background())
+	require.Error(t, err)
+	var abort *resource.ValidatorOutsidePinError
+	require.True(t, errors.As(err, &abort))
+	require.Equal(t, 5, abort.Deletions)
+	require.Equal(t, Validator{Group: "g1", Instance: "i1", Location: 0}, result.Validator)
+	require.Empty(t, result.MovedValidator)
+	require.Empty(t, backend.Deleted)
+	require.Empty(t, backend.Suspended)
+	require.Equal(t, []int{2, 2, 2}, result.Positions)
+}
+
+func TestEnginePinnedLocationMove(t *testing.T) {
+	backend := testBackend()
+	backend.Metrics["i1"] = 1
+
+	failover := testFailover(resource.FailOverModeSingle)
+	failover.PinLocation = "l3"
+	failover.OnValidatorOutsidePin = resource.PinnedValidatorMove
+	e := New(backend, failover)
+	e.PollInterval = 0
+
+	result, err := e.Discover(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "i1", result.MovedValidator)
+	require.Equal(t, "i5", result.Replacement)
+	require.Empty(t, result.Validator.Instance)
+	require.Equal(t, 5, result.DeletionsCount())
+	require.Equal(t, []int{0, 0, 1}, result.Positions)
+
+	// the replacement takes over once the moved validator has been deleted
+	e.Backend = takeoverBackend{FakeBackend: backend, validator: "i1", replacement: "i5"}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, err = e.Run(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"i1", "i2", "i3", "i4", "i6"}, backend.Deleted)
+	require.Equal(t, []int{0, 0, 1}, failover.FailoverInstances)
+	require.Equal(t, []int{1}, backend.WaitedCounts)
+}
+
+func TestEnginePinnedLocationMoveWithoutReplacement(t *testing.T) {
+	backend := NewFakeBackend(
+		Group{Name: "g1", Location: 0, Instances: []string{"i1", "i2"}},
+		Group{Name: "g2", Location: 1, Instances: []string{"i3", "i4"}},
+		Group{Name: "g3", Location: 2},
+	)
+	backend.Metrics["i1"] = 1
+
+	failover := testFailover(resource.FailOverModeSingle)
+	failover.PinLocation = "l3"
+	failover.OnValidatorOutsidePin = resource.PinnedValidatorMove
+	e := New(backend, failover)
+
+	result, err := e.Run(context.Background())
+	require.Error(t, err)
+	var abort *resource.ValidatorOutsidePinError
+	require.True(t, errors.As(err, &abort))
+	require.True(t, abort.NoReplacement)
+	require.Equal(t, 4, abort.Deletions)
+	require.Equal(t, Validator{Group: "g1", Instance: "i1", Location: 0}, result.Validator)
+	require.Empty(t, result.MovedValidator)
+	require.Empty(t, result.Replacement)
+	require.Empty(t, backend.Deleted)
+	require.Equal(t, []int{2, 2, 0}, result.Positions)
+}
+
+func TestEnginePartialDeletionFailure(t *testing.T) {
+	backend := testBackend()
+	backend.Metrics["i1"] = 1
+	backend.DeleteErrors["g2"] = errors.New("quota exceeded")
+
+	failover := testFailover(resource.FailOverModeSingle)
+	e := New(backend, failover)
+
+	_, err := e.Run(context.Background())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "g2")
+	require.Contains(t, err.Error(), "quota exceeded")
+	require.Equal(t, []string{"i2", "i5", "i6"}, backend.Deleted)
+	require.Empty(t, backend.WaitedCounts)
+	require.True(t, backend.Resumed)
+}
+
+func TestEngineResumesSelfHealingOnCancel(t *testing.T) {
+	backend := testBackend()
+	backend.Metrics["i1"] = 1
+	backend.DeleteErrors["g2"] = context.Canceled
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := New(backend, testFailover(resource.FailOverModeSingle)).Run(ctx)
+	require.True(t, errors.Is(err, context.Canceled))
+	require.True(t, backend.Resumed)
+	require.Empty(t, backend.Suspended)
+}
+
+func TestEngineDryRun(t *testing.T) {
+	backend := testBackend()
+	backend.Metrics["i1"] = 1
+
+	failover := testFailover(resource.FailOverModeSingle)
+	failover.DryRun = true
+	e := New(backend, failover)
+
+	result, err := e.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 5, result.DeletionsCount())
+	require.Empty(t, backend.Deleted)
+	require.Empty(t, backend.Protected)
+	require.Equal(t, []int{1, 0, 0}, failover.DryRunInstances)
+	require.Equal(t, []int{2, 2, 2}, failover.FailoverInstances)
+
+	// aborted failover is reported by dry run
+	delete(backend.Metrics, "i1")
+	result, err = e.Run(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, result.Abort)
+	require.Empty(t, backend.Deleted)
+}
+
+func TestFindValidatorStaleMetric(t *testing.T) {
+	timestamp := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	metrics := []Metric{
+		{Group: "g1", Instance: "i1", Value: 1, Stale: true, Timestamp: timestamp},
+		{Group: "g2", Instance: "i3", Value: 0, Timestamp: timestamp},
+	}
+
+	_, err := FindValidator(metrics, 1)
+	validatorError := &helperErrors.ValidatorError{}
+	require.True(t, errors.As(err, validatorError))
+	require.True(t, validatorError.IsNotFound())
+	require.Equal(t, "g1/i1=1<stale since 2021-03-01T12:00:00Z>, g2/i3=0", validatorError.MetricValues())
+
+	metrics[1].Value = 1
+
+	validator, err := FindValidator(metrics, 1)
+	require.NoError(t, err)
+	require.Equal(t, "i3", validator.Instance)
+}
@@ -0,0 +1,163 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// FakeBackend is in-memory cloud backend. It allows testing failover decisions without cloud API
+type FakeBackend struct {
+	mu     sync.Mutex
+	groups []Group
+	// Metrics are validator metric values by instance name. Instances without values do not report metric
+	Metrics map[string]float64
+	// Addresses are instance addresses by instance name
+	Addresses map[string]string
+	// DeleteErrors are errors returned while deleting instances by group name
+	DeleteErrors map[string]error
+	// Deleted are names of deleted instances
+	Deleted []string
+	// WaitedCounts are instance counts failover waited for
+	WaitedCounts []int
+	// Protected is the protected validator instance
+	Protected string
+	// Suspended are names of groups which self healing is suspended
+	Suspended []string
+	// Resumed is true if self healing has been resumed with a live context
+	Resumed bool
+}
+
+// NewFakeBackend creates fake backend with groups
+func NewFakeBackend(groups ...Group) *FakeBackend {
+	return &FakeBackend{
+		groups:       copyGroups(groups),
+		Metrics:      map[string]float64{},
+		Addresses:    map[string]string{},
+		DeleteErrors: map[string]error{},
+	}
+}
+
+func copyGroups(groups []Group) []Group {
+	result := make([]Group, 0, len(groups))
+	for _, group := range groups {
+		group.Instances = append([]string{}, group.Instances...)
+		group.Standby = append([]string{}, group.Standby...)
+		result = append(result, group)
+	}
+	return result
+}
+
+// ListGroups returns copy of groups
+func (b *FakeBackend) ListGroups(_ context.Context) ([]Group, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return copyGroups(b.groups), nil
+}
+
+// GetValidatorMetrics returns metric values of groups instances
+func (b *FakeBackend) GetValidatorMetrics(_ context.Context, groups []Group) ([]Metric, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var metrics []Metric
+	for _, group := range groups {
+		for _, instance := range group.Instances {
+			value, ok := b.Metrics[instance]
+			metrics = append(metrics, Metric{Group: group.Name, Instance: instance, Value: value, Missing: !ok})
+		}
+	}
+	return metrics, nil
+}
+
+// InstanceAddresses returns addresses of groups instances
+func (b *FakeBackend) InstanceAddresses(_ context.Context, groups []Group) (map[string]string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	addresses := map[string]string{}
+	for _, group := range groups {
+		for _, instance := range group.Instances {
+			if address, ok := b.Addresses[instance]; ok {
+				addresses[instance] = address
+			}
+		}
+	}
+	return addresses, nil
+}
+
+// DeleteInstances removes group instances
+func (b *FakeBackend) DeleteInstances(_ context.Context, group Group) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.DeleteErrors[group.Name]; err != nil {
+		return err
+	}
+
+	remove := map[string]bool{}
+	for _, instance := range group.Instances {
+		remove[instance] = true
+	}
+
+	for i := range b.groups {
+		if b.groups[i].Name != group.Name {
+			continue
+		}
+		var instances []string
+		for _, instance := range b.groups[i].Instances {
+			if remove[instance] {
+				b.Deleted = append(b.Deleted, instance)
+				delete(b.Metrics, instance)
+				continue
+			}
+			instances = append(instances, instance)
+		}
+		b.groups[i].Instances = instances
+	}
+
+	sort.Strings(b.Deleted)
+
+	return nil
+}
+
+// ProtectValidator stores the protected validator instance
+func (b *FakeBackend) ProtectValidator(_ context.Context, validator Validator) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.Protected = validator.Instance
+	return nil
+}
+
+// SuspendSelfHealing stores names of groups
+func (b *FakeBackend) SuspendSelfHealing(_ context.Context, groups []Group) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.Resumed = false
+	for _, group := range groups {
+		b.Suspended = append(b.Suspended, group.Name)
+	}
+	return nil
+}
+
+// ResumeSelfHealing clears suspended groups
+func (b *FakeBackend) ResumeSelfHealing(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.Suspended = nil
+	b.Resumed = true
+	return nil
+}
+
+// WaitForCount checks that the number of running instances equals count
+func (b *FakeBackend) WaitForCount(_ context.Context, count int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.WaitedCounts = append(b.WaitedCounts, count)
+	if running := instancesCount(b.groups); running != count {
+		return fmt.Errorf("expected %d running instances, got %d", count, running)
+	}
+	return nil
+}
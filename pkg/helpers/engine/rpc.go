@@ -0,0 +1,149 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/fanout"
+)
+
+const (
+	// NodeRoleAuthority is system_nodeRoles role of the validator
+	NodeRoleAuthority = "Authority"
+	// NodeRoleFull is system_nodeRoles role of a full node
+	NodeRoleFull = "Full"
+
+	// DefaultRPCTimeout is JSON-RPC request timeout
+	DefaultRPCTimeout = 10 * time.Second
+)
+
+type rpcRequest struct {
+	ID      int           `json:"id"`
+	JSONRPC string        `json:"jsonrpc"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type nodeRolesResponse struct {
+	Result []string  `json:"result"`
+	Error  *rpcError `json:"error"`
+}
+
+// GetNodeRoles calls system_nodeRoles JSON-RPC method of polkadot node
+func GetNodeRoles(ctx context.Context, client *http.Client, url string) ([]string, error) {
+
+	body, err := json.Marshal(rpcRequest{ID: 1, JSONRPC: "2.0", Method: "system_nodeRoles", Params: []interface{}{}})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot call system_nodeRoles %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cannot call system_nodeRoles %s: status %s", url, resp.Status)
+	}
+
+	response := nodeRolesResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("cannot decode system_nodeRoles response %s: %w", url, err)
+	}
+
+	if response.Error != nil {
+		return nil, fmt.Errorf("system_nodeRoles %s error %d: %s", url, response.Error.Code, response.Error.Message)
+	}
+
+	return response.Result, nil
+}
+
+// RPCSource detects the validator by system_nodeRoles JSON-RPC calls to instances. Instances reporting Authority role validate
+type RPCSource struct {
+	Resolver AddressResolver
+	Port     int
+	Client   *http.Client
+}
+
+// NewRPCSource creates JSON-RPC validator source
+func NewRPCSource(resolver AddressResolver, port int) *RPCSource {
+	return &RPCSource{
+		Resolver: resolver,
+		Port:     port,
+		Client:   &http.Client{Timeout: DefaultRPCTimeout},
+	}
+}
+
+// GetValidatorMetrics returns 1 for instances with Authority role and 0 for other instances.
+// Instances without address or failed calls have missing values. Addresses without port use Port
+func (s *RPCSource) GetValidatorMetrics(ctx context.Context, groups []Group) ([]Metric, error) {
+
+	if s.Resolver == nil {
+		return nil, errors.New("rpc validator detection is not supported by the cloud backend")
+	}
+
+	addresses, err := s.Resolver.InstanceAddresses(ctx, groups)
+	if err != nil {
+		return nil, err
+	}
+
+	var metrics []Metric
+	for _, group := range groups {
+		for _, instance := range group.Instances {
+			metrics = append(metrics, Metric{Group: group.Name, Instance: instance})
+		}
+	}
+
+	err = fanout.Run(ctx, len(metrics), fanout.Options{}, func(ctx context.Context, idx int) error {
+		metric := &metrics[idx]
+		address, ok := addresses[metric.Instance]
+		if !ok || address == "" {
+			log.Printf("[WARNING] failover: Cannot find address of instance %q", metric.Instance)
+			metric.Missing = true
+			return nil
+		}
+		// addresses might contain a port, e.g. load balancer listeners
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			address = net.JoinHostPort(address, strconv.Itoa(s.Port))
+		}
+		url := fmt.Sprintf("http://%s", address)
+		roles, err := GetNodeRoles(ctx, s.Client, url)
+		if err != nil {
+			log.Printf("[WARNING] failover: Cannot get node roles of instance %q: %v", metric.Instance, err)
+			metric.Missing = true
+			return nil
+		}
+		for _, role := range roles {
+			if role == NodeRoleAuthority {
+				metric.Value = 1
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return metrics, nil
+}
@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	helperErrors "github.com/protofire/polkadot-failover-mechanism/pkg/helpers/errors"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/resource"
+	"github.com/stretchr/testify/require"
+)
+
+// newRPCServer starts mock polkadot JSON-RPC server reporting node role
+func newRPCServer(t *testing.T, role string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := rpcRequest{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		if request.Method != "system_nodeRoles" {
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":1}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","result":["` + role + `"],"id":1}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func serverAddress(server *httptest.Server) string {
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+func TestGetNodeRoles(t *testing.T) {
+	server := newRPCServer(t, NodeRoleAuthority)
+
+	roles, err := GetNodeRoles(context.Background(), server.Client(), server.URL)
+	require.NoError(t, err)
+	require.Equal(t, []string{NodeRoleAuthority}, roles)
+}
+
+func TestRPCSource(t *testing.T) {
+	backend := testBackend()
+	backend.Addresses["i1"] = serverAddress(newRPCServer(t, NodeRoleFull))
+	backend.Addresses["i3"] = serverAddress(newRPCServer(t, NodeRoleAuthority))
+	// i2 does not answer
+	backend.Addresses["i2"] = "127.0.0.1:1"
+
+	groups, err := backend.ListGroups(context.Background())
+	require.NoError(t, err)
+
+	metrics, err := NewRPCSource(backend, resource.DefaultRPCPort).GetValidatorMetrics(context.Background(), groups)
+	require.NoError(t, err)
+	require.Len(t, metrics, 6)
+
+	validator, err := FindValidator(metrics, 1)
+	require.NoError(t, err)
+	require.Equal(t, "i3", validator.Instance)
+
+	for _, metric := range metrics {
+		switch metric.Instance {
+		case "i1":
+			require.False(t, metric.Missing)
+			require.Equal(t, float64(0), metric.Value)
+		case "i3":
+			require.Equal(t, float64(1), metric.Value)
+		default:
+			require.True(t, metric.Missing)
+		}
+	}
+}
+
+func TestEngineRPCDetection(t *testing.T) {
+	backend := testBackend()
+	backend.Addresses["i3"] = serverAddress(newRPCServer(t, NodeRoleAuthority))
+
+	failover := testFailover(resource.FailOverModeSingle)
+	failover.ValidatorDetection = resource.ValidatorDetectionRPC
+
+	result, err := New(backend, failover).Discover(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, Validator{Group: "g2", Instance: "i3", Location: 1}, result.Validator)
+
+	// metrics and rpc should detect the same validator
+	failover.ValidatorDetection = resource.ValidatorDetectionBoth
+	backend.Metrics["i3"] = 1
+
+	result, err = New(backend, failover).Discover(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "i3", result.Validator.Instance)
+
+	delete(backend.Metrics, "i3")
+	backend.Metrics["i1"] = 1
+
+	result, err = New(backend, failover).Discover(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, result.Validator.Instance)
+	require.NotNil(t, result.Abort)
+	validatorError := &helperErrors.ValidatorError{}
+	require.True(t, errors.As(result.ValidatorErr, validatorError))
+	require.True(t, validatorError.Conflict())
+}
@@ -1,6 +1,11 @@
 package errors
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type ValidatorErrorType int
 
@@ -12,6 +17,8 @@ func (vt ValidatorErrorType) String() string {
 		return "ValidatorErrorNotFound"
 	case ValidatorErrorMultiple:
 		return "ValidatorErrorMultiple"
+	case ValidatorErrorConflict:
+		return "ValidatorErrorConflict"
 	}
 	return ""
 }
@@ -20,11 +27,36 @@ const (
 	ValidatorErrorUnknown ValidatorErrorType = iota
 	ValidatorErrorNotFound
 	ValidatorErrorMultiple
+	// ValidatorErrorConflict means validator sources detected different validators
+	ValidatorErrorConflict
 )
 
 type ValidatorError struct {
 	Message string
 	Kind    ValidatorErrorType
+	// Values are validator metric values seen while searching for the validator
+	Values []MetricValue
+}
+
+// MetricValue is the last validator metric value seen for an instance
+type MetricValue struct {
+	Instance string
+	Value    float64
+	// Missing is true if there are no metric data points for the instance
+	Missing bool
+	// Stale is true if the last data point taken at Timestamp is too old to be trusted
+	Stale     bool
+	Timestamp time.Time
+}
+
+func (m MetricValue) String() string {
+	if m.Missing {
+		return fmt.Sprintf("%s=<no data>", m.Instance)
+	}
+	if m.Stale {
+		return fmt.Sprintf("%s=%s<stale since %s>", m.Instance, strconv.FormatFloat(m.Value, 'f', -1, 64), m.Timestamp.UTC().Format(time.RFC3339))
+	}
+	return fmt.Sprintf("%s=%s", m.Instance, strconv.FormatFloat(m.Value, 'f', -1, 64))
 }
 
 func NewValidatorError(message string, kind ValidatorErrorType) ValidatorError {
@@ -38,6 +70,30 @@ func (v ValidatorError) Error() string {
 	return fmt.Sprintf("%s: Error type: %s", v.Message, v.Kind)
 }
 
+// Is compares validator errors by message and kind. Metric values make ValidatorError not comparable
+func (v ValidatorError) Is(target error) bool {
+	t, ok := target.(ValidatorError)
+	return ok && t.Message == v.Message && t.Kind == v.Kind
+}
+
+// WithValues returns validator error with metric values
+func (v ValidatorError) WithValues(values []MetricValue) ValidatorError {
+	v.Values = values
+	return v
+}
+
+// MetricValues formats metric values as <instance>=<value> pairs
+func (v ValidatorError) MetricValues() string {
+	if len(v.Values) == 0 {
+		return "<no instances>"
+	}
+	pairs := make([]string, 0, len(v.Values))
+	for _, value := range v.Values {
+		pairs = append(pairs, value.String())
+	}
+	return strings.Join(pairs, ", ")
+}
+
 func (v ValidatorError) IsNotFound() bool {
 	return v.Kind == ValidatorErrorNotFound
 }
@@ -45,3 +101,7 @@ func (v ValidatorError) IsNotFound() bool {
 func (v ValidatorError) MultipleValidators() bool {
 	return v.Kind == ValidatorErrorMultiple
 }
+
+func (v ValidatorError) Conflict() bool {
+	return v.Kind == ValidatorErrorConflict
+}
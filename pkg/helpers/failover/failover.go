@@ -1,21 +1,47 @@
 package failover
 
+import "sort"
+
 // CalculateInstancesForSingleFailOverMode calculates per environment count
 // in case failover mode is single and there are no any instances deployed yet
 func CalculateInstancesForSingleFailOverMode(counts []int) []int {
 
+	result := make([]int, len(counts))
+
+	if len(counts) == 0 {
+		return result
+	}
+
+	result[CalculateValidatorLocation(counts, nil, -1)] = 1
+
+	return result
+
+}
+
+// CalculateValidatorLocation returns location index for the validator in case it is not running.
+// Pinned location wins, then the first preferred location with configured instances,
+// then the location with maximum number of configured instances. Ties are broken by locations order.
+// preferred are location indexes in preference order, pin is the pinned location index or -1
+func CalculateValidatorLocation(counts []int, preferred []int, pin int) int {
+
+	if pin >= 0 && pin < len(counts) {
+		return pin
+	}
+
+	for _, idx := range preferred {
+		if idx >= 0 && idx < len(counts) && counts[idx] > 0 {
+			return idx
+		}
+	}
+
 	maxIdx := 0
-	maxCount := counts[0]
-	for idx, i := range counts {
-		if i > maxCount {
+	for idx, count := range counts {
+		if count > counts[maxIdx] {
 			maxIdx = idx
 		}
 	}
 
-	result := make([]int, len(counts))
-	result[maxIdx] = 1
-
-	return result
+	return maxIdx
 
 }
 
@@ -25,7 +51,10 @@ func CalculateInstanceCountPerRegion(instanceLocations []string, location string
 	counts := make([]int, len(instanceLocations))
 
 	if location == "" {
-		return []int{1, 0, 0}
+		if len(counts) > 0 {
+			counts[0] = 1
+		}
+		return counts
 	}
 
 	for i, loc := range instanceLocations {
@@ -37,3 +66,74 @@ func CalculateInstanceCountPerRegion(instanceLocations []string, location string
 	}
 	return counts
 }
+
+// CalculateInstancesForStandbyFailOverMode calculates per location count in case failover mode is standby.
+// The validator location gets one instance. Standbys are placed one by one into other locations
+// while these locations have configured instances. validatorIdx is -1 if the validator is not known
+func CalculateInstancesForStandbyFailOverMode(counts []int, validatorIdx int, standbys int) []int {
+
+	result := make([]int, len(counts))
+
+	if len(counts) == 0 {
+		return result
+	}
+
+	if validatorIdx < 0 || validatorIdx >= len(counts) {
+		validatorIdx = CalculateValidatorLocation(counts, nil, -1)
+	}
+
+	result[validatorIdx] = 1
+
+	for placed := 0; placed < standbys; {
+		progress := false
+		for idx := range counts {
+			if placed == standbys {
+				break
+			}
+			if idx == validatorIdx || result[idx] >= counts[idx] {
+				continue
+			}
+			result[idx]++
+			placed++
+			progress = true
+		}
+		if !progress {
+			break
+		}
+	}
+
+	return result
+
+}
+
+// SelectStandbyInstances splits instances per location into instances kept as standbys and instances to delete.
+// counts are instances per location including the validator, validatorIdx is the validator location or -1.
+// Instances are sorted, so the same standbys are kept on repeated runs. The validator must not be in instances
+func SelectStandbyInstances(instances [][]string, counts []int, validatorIdx int) ([][]string, [][]string) {
+
+	keep := make([][]string, len(instances))
+	remove := make([][]string, len(instances))
+
+	for idx, names := range instances {
+		sorted := append([]string{}, names...)
+		sort.Strings(sorted)
+		standbys := 0
+		if idx < len(counts) {
+			standbys = counts[idx]
+		}
+		if idx == validatorIdx {
+			standbys--
+		}
+		if standbys < 0 {
+			standbys = 0
+		}
+		if standbys > len(sorted) {
+			standbys = len(sorted)
+		}
+		keep[idx] = sorted[:standbys]
+		remove[idx] = sorted[standbys:]
+	}
+
+	return keep, remove
+
+}
@@ -37,4 +37,57 @@ func TestCalculateInstancesForSingleFailOverMode(t *testing.T) {
 	require.Equal(t, []int{1, 0, 0}, counts)
 	counts = CalculateInstancesForSingleFailOverMode([]int{10, 10, 11})
 	require.Equal(t, []int{0, 0, 1}, counts)
+	counts = CalculateInstancesForSingleFailOverMode([]int{1, 3, 2})
+	require.Equal(t, []int{0, 1, 0}, counts)
+}
+
+func TestCalculateInstanceCountPerRegionFiveLocations(t *testing.T) {
+	locations := []string{"centralus", "eastus", "westus", "northeurope", "westeurope"}
+	counts := CalculateInstanceCountPerRegion(locations, "northeurope", strings.ToLower)
+	require.Equal(t, []int{0, 0, 0, 1, 0}, counts)
+	counts = CalculateInstanceCountPerRegion(locations, "", strings.ToLower)
+	require.Equal(t, []int{1, 0, 0, 0, 0}, counts)
+}
+
+func TestCalculateInstancesForStandbyFailOverMode(t *testing.T) {
+	counts := CalculateInstancesForStandbyFailOverMode([]int{3, 3, 3}, 1, 2)
+	require.Equal(t, []int{1, 1, 1}, counts)
+	counts = CalculateInstancesForStandbyFailOverMode([]int{3, 3, 3}, 0, 3)
+	require.Equal(t, []int{1, 2, 1}, counts)
+	counts = CalculateInstancesForStandbyFailOverMode([]int{3, 3, 3}, -1, 2)
+	require.Equal(t, []int{1, 1, 1}, counts)
+	counts = CalculateInstancesForStandbyFailOverMode([]int{1, 0, 1}, 2, 4)
+	require.Equal(t, []int{1, 0, 1}, counts)
+	counts = CalculateInstancesForStandbyFailOverMode([]int{1, 1, 1, 1, 1}, 3, 0)
+	require.Equal(t, []int{0, 0, 0, 1, 0}, counts)
+}
+
+func TestSelectStandbyInstances(t *testing.T) {
+	keep, remove := SelectStandbyInstances(
+		[][]string{{"a-2", "a-1"}, {"b-3", "b-1", "b-2"}, {"c-1"}},
+		[]int{2, 1, 1},
+		0,
+	)
+	require.Equal(t, [][]string{{"a-1"}, {"b-1"}, {"c-1"}}, keep)
+	require.Equal(t, [][]string{{"a-2"}, {"b-2", "b-3"}, {}}, remove)
+
+	keep, remove = SelectStandbyInstances([][]string{{"a-1"}, nil, {"c-1"}}, []int{1, 1, 0}, -1)
+	require.Equal(t, [][]string{{"a-1"}, {}, {}}, keep)
+	require.Equal(t, [][]string{{}, {}, {"c-1"}}, remove)
+}
+
+func TestCalculateValidatorLocation(t *testing.T) {
+	// ties are broken by locations order
+	require.Equal(t, 0, CalculateValidatorLocation([]int{2, 2, 2}, nil, -1))
+	require.Equal(t, 1, CalculateValidatorLocation([]int{1, 3, 3}, nil, -1))
+	require.Equal(t, 2, CalculateValidatorLocation([]int{3, 1, 4}, nil, -1))
+	require.Equal(t, 0, CalculateValidatorLocation([]int{0, 0, 0}, nil, -1))
+	// the first preferred location with configured instances wins
+	require.Equal(t, 2, CalculateValidatorLocation([]int{3, 3, 1}, []int{2, 1}, -1))
+	require.Equal(t, 1, CalculateValidatorLocation([]int{3, 3, 0}, []int{2, 1}, -1))
+	// preferred locations without instances fall back to maximum
+	require.Equal(t, 0, CalculateValidatorLocation([]int{3, 1, 0}, []int{2}, -1))
+	// pinned location wins
+	require.Equal(t, 1, CalculateValidatorLocation([]int{3, 0, 3}, []int{2}, 1))
+	require.Equal(t, 0, CalculateValidatorLocation([]int{3, 0, 3}, nil, 5))
 }
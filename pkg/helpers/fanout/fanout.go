@@ -2,105 +2,166 @@ package fanout
 
 import (
 	"context"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 )
 
-// Item is fan out general item
-type Item struct {
-	result interface{}
-	err    error
-}
-
-// ConcurrentResponseItems process with items channel
-func ConcurrentResponseItems(ctx context.Context, worker func(
-	ctx context.Context,
-	value interface{},
-) (interface{}, error), parameters ...interface{}) chan Item {
+// DefaultLimit is the maximum number of concurrent workers if Options.Limit is not set
+const DefaultLimit = 16
 
-	var wg sync.WaitGroup
+// Mode chooses how worker errors are handled
+type Mode int
 
-	out := make(chan Item, len(parameters))
-
-	for _, param := range parameters {
-		wg.Add(1)
+const (
+	// CollectAll runs all items and returns errors of all failed items
+	CollectAll Mode = iota
+	// CancelOnError cancels context of running items and skips pending items after the first error
+	CancelOnError
+)
 
-		go func(param interface{}) {
-			defer wg.Done()
-			result, err := worker(ctx, param)
-			out <- Item{
-				result: result,
-				err:    err,
-			}
-		}(param)
+// Options configure fan out
+type Options struct {
+	// Limit is the maximum number of concurrent workers. DefaultLimit is used if it is not positive
+	Limit int
+	Mode  Mode
+	// Retries is number of repeated worker calls after an error. Backoff is the delay before the first retry, it doubles after each retry
+	Retries int
+	Backoff time.Duration
+	// Retryable returns true if the error should be retried. All errors are retried if it is nil
+	Retryable func(err error) bool
+}
 
+func (o Options) limit(n int) int {
+	limit := o.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
 	}
+	if limit > n {
+		limit = n
+	}
+	return limit
+}
 
-	go func() {
-		defer close(out)
-		wg.Wait()
-	}()
+func (o Options) retryable(err error) bool {
+	return o.Retryable == nil || o.Retryable(err)
+}
 
-	return out
+// ItemError is worker error of the item with Index
+type ItemError struct {
+	Index int
+	Err   error
+}
 
+func (e ItemError) Error() string {
+	return e.Err.Error()
 }
 
-// ConcurrentResponseErrors process with errors channel
-func ConcurrentResponseErrors(ctx context.Context, worker func(ctx context.Context, value interface{}) error, parameters ...interface{}) chan error {
+func (e ItemError) Unwrap() error {
+	return e.Err
+}
 
-	var wg sync.WaitGroup
+// call calls worker for the item retrying errors with backoff
+func call(ctx context.Context, idx int, options Options, worker func(ctx context.Context, idx int) error) error {
 
-	out := make(chan error, len(parameters))
+	backoff := options.Backoff
+	err := worker(ctx, idx)
 
-	for _, param := range parameters {
-		wg.Add(1)
+	for attempt := 0; attempt < options.Retries && err != nil && options.retryable(err); attempt++ {
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		err = worker(ctx, idx)
+	}
 
-		go func(param interface{}, wg *sync.WaitGroup, out chan error) {
-			defer wg.Done()
-			err := worker(ctx, param)
-			if err != nil {
-				out <- err
-			}
-		}(param, &wg, out)
+	return err
+}
 
+// Run calls worker for item indexes from 0 to n-1 with at most Options.Limit concurrent calls.
+// Workers store results by index, so results keep input order. Errors are ItemError sorted by index.
+// In CancelOnError mode items skipped because ctx is done make Run fail with ctx error of the first skipped item
+func Run(ctx context.Context, n int, options Options, worker func(ctx context.Context, idx int) error) error {
+
+	if n <= 0 {
+		return nil
 	}
 
-	go func() {
-		defer close(out)
-		wg.Wait()
-	}()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-	return out
+	var mu sync.Mutex
+	var errs []ItemError
+	// skipped is the first item skipped in CancelOnError mode
+	skipped := -1
 
-}
+	skip := func(idx int) {
+		mu.Lock()
+		if skipped < 0 || idx < skipped {
+			skipped = idx
+		}
+		mu.Unlock()
+	}
 
-// ReadItemChannel reads items channel and constructs error
-func ReadItemChannel(ch chan Item) ([]interface{}, error) {
-	multiErr := &multierror.Error{}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
 
-	var results []interface{}
+	for w := 0; w < options.limit(n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range indexes {
+				if options.Mode == CancelOnError && ctx.Err() != nil {
+					skip(idx)
+					continue
+				}
+				if err := call(ctx, idx, options, worker); err != nil {
+					mu.Lock()
+					errs = append(errs, ItemError{Index: idx, Err: err})
+					mu.Unlock()
+					if options.Mode == CancelOnError {
+						cancel()
+					}
+				}
+			}
+		}()
+	}
 
-	for result := range ch {
-		if result.err != nil {
-			multiErr = multierror.Append(multiErr, result.err)
-		} else {
-			results = append(results, result.result)
+	for idx := 0; idx < n; idx++ {
+		if options.Mode == CancelOnError && ctx.Err() != nil {
+			skip(idx)
+			break
 		}
+		indexes <- idx
+	}
+	close(indexes)
+	wg.Wait()
 
+	if len(errs) == 0 {
+		if skipped >= 0 {
+			// ctx is cancelled only if parent ctx is done, since no item failed
+			return ItemError{Index: skipped, Err: ctx.Err()}
+		}
+		return nil
 	}
 
-	return results, multiErr.ErrorOrNil()
+	if options.Mode == CancelOnError {
+		// errors of items cancelled after the first error are not interesting
+		return errs[0]
+	}
 
-}
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].Index < errs[j].Index
+	})
 
-// ReadErrorsChannel reads error's channel and constructs error
-func ReadErrorsChannel(ch chan error) error {
 	multiErr := &multierror.Error{}
-
-	for err := range ch {
+	for _, err := range errs {
 		multiErr = multierror.Append(multiErr, err)
 	}
-	return multiErr.ErrorOrNil()
 
+	return multiErr
 }
@@ -0,0 +1,153 @@
+package fanout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunOrderedResults(t *testing.T) {
+	results := make([]int, 10)
+
+	err := Run(context.Background(), len(results), Options{Limit: 3}, func(ctx context.Context, idx int) error {
+		// later items complete first
+		time.Sleep(time.Duration(len(results)-idx) * time.Millisecond)
+		results[idx] = idx * idx
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 1, 4, 9, 16, 25, 36, 49, 64, 81}, results)
+}
+
+func TestRunLimit(t *testing.T) {
+	var running, maxRunning int32
+
+	err := Run(context.Background(), 20, Options{Limit: 4}, func(ctx context.Context, idx int) error {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			previous := atomic.LoadInt32(&maxRunning)
+			if current <= previous || atomic.CompareAndSwapInt32(&maxRunning, previous, current) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.LessOrEqual(t, maxRunning, int32(4))
+}
+
+func TestRunCollectAll(t *testing.T) {
+	var calls int32
+
+	err := Run(context.Background(), 5, Options{}, func(ctx context.Context, idx int) error {
+		atomic.AddInt32(&calls, 1)
+		if idx%2 == 1 {
+			return fmt.Errorf("error %d", idx)
+		}
+		return nil
+	})
+
+	require.Error(t, err)
+	require.Equal(t, int32(5), calls)
+
+	multiErr := &multierror.Error{}
+	require.True(t, errors.As(err, &multiErr))
+	require.Len(t, multiErr.Errors, 2)
+
+	var indexes []int
+	for _, itemErr := range multiErr.Errors {
+		indexes = append(indexes, itemErr.(ItemError).Index)
+	}
+	require.Equal(t, []int{1, 3}, indexes)
+}
+
+func TestRunCancelOnError(t *testing.T) {
+	var calls int32
+	failure := errors.New("failure")
+
+	err := Run(context.Background(), 100, Options{Limit: 1, Mode: CancelOnError}, func(ctx context.Context, idx int) error {
+		atomic.AddInt32(&calls, 1)
+		if idx == 2 {
+			return failure
+		}
+		return nil
+	})
+
+	require.True(t, errors.Is(err, failure))
+	itemErr := ItemError{}
+	require.True(t, errors.As(err, &itemErr))
+	require.Equal(t, 2, itemErr.Index)
+	// pending items are skipped
+	require.Less(t, atomic.LoadInt32(&calls), int32(100))
+}
+
+func TestRunCancelOnErrorCancelledContext(t *testing.T) {
+	var calls int32
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := Run(ctx, 10, Options{Limit: 2, Mode: CancelOnError}, func(ctx context.Context, idx int) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	})
+
+	require.True(t, errors.Is(err, context.Canceled))
+	itemErr := ItemError{}
+	require.True(t, errors.As(err, &itemErr))
+	require.Equal(t, 0, itemErr.Index)
+	require.Equal(t, int32(0), atomic.LoadInt32(&calls))
+}
+
+func TestRunCancelOnErrorContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// in flight items succeed although parent context is cancelled meanwhile
+	err := Run(ctx, 10, Options{Limit: 1, Mode: CancelOnError}, func(_ context.Context, idx int) error {
+		if idx == 3 {
+			cancel()
+		}
+		return nil
+	})
+
+	require.True(t, errors.Is(err, context.Canceled))
+	itemErr := ItemError{}
+	require.True(t, errors.As(err, &itemErr))
+	require.Equal(t, 4, itemErr.Index)
+}
+
+func TestRunRetry(t *testing.T) {
+	attempts := make([]int32, 3)
+	temporary := errors.New("temporary")
+
+	err := Run(context.Background(), len(attempts), Options{Retries: 2, Backoff: time.Millisecond}, func(ctx context.Context, idx int) error {
+		if atomic.AddInt32(&attempts[idx], 1) <= int32(idx) {
+			return temporary
+		}
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, []int32{1, 2, 3}, attempts)
+
+	// non retryable errors are returned at once
+	attempts = make([]int32, 1)
+	err = Run(context.Background(), 1, Options{Retries: 2, Retryable: func(err error) bool { return false }}, func(ctx context.Context, idx int) error {
+		atomic.AddInt32(&attempts[idx], 1)
+		return temporary
+	})
+
+	require.True(t, errors.Is(err, temporary))
+	require.Equal(t, []int32{1}, attempts)
+}
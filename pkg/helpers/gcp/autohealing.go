@@ -0,0 +1,46 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"google.golang.org/api/compute/v1"
+)
+
+// SetAutoHealingPolicies replaces auto healing policies of the regional instance group manager. Empty policies disable auto healing
+func SetAutoHealingPolicies(
+	ctx context.Context,
+	client *compute.Service,
+	project,
+	region,
+	name string,
+	policies []*compute.InstanceGroupManagerAutoHealingPolicy,
+) error {
+
+	if policies == nil {
+		policies = []*compute.InstanceGroupManagerAutoHealingPolicy{}
+	}
+
+	log.Printf("[DEBUG] failover: Setting %d auto healing policies of instance group manager %q", len(policies), name)
+
+	op, err := client.RegionInstanceGroupManagers.Patch(project, region, name, &compute.InstanceGroupManager{
+		AutoHealingPolicies: policies,
+		// empty policies are omitted otherwise
+		ForceSendFields: []string{"AutoHealingPolicies"},
+	}).Context(ctx).Do()
+
+	if err := processOpResult(op, err); err != nil {
+		return err
+	}
+
+	if op == nil {
+		return nil
+	}
+
+	if err := waitForOperation(ctx, op, prepareRegionGetOp(ctx, client, project, region)); err != nil {
+		return fmt.Errorf("auto healing policies of instance group manager %q have not been updated in time: %w", name, err)
+	}
+
+	return nil
+}
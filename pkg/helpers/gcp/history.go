@@ -0,0 +1,57 @@
+package gcp
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	monitoring "cloud.google.com/go/monitoring/apiv3/v2"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
+)
+
+// zoneRegion returns region of zone, e.g. us-east1 of us-east1-b
+func zoneRegion(zone string) string {
+	if idx := strings.LastIndex(zone, "-"); idx > 0 {
+		return zone[:idx]
+	}
+	return zone
+}
+
+// GetValidatorHistory returns validator metric samples of instances with prefix for the last window.
+// Instances are found by time series, so samples of deleted instances are included
+func GetValidatorHistory(
+	ctx context.Context,
+	client *monitoring.MetricClient,
+	project,
+	prefix,
+	metricNamespace,
+	metricName string,
+	aggregation helpers.MetricAggregation,
+	window time.Duration,
+) ([]helpers.MetricSample, error) {
+
+	points, err := listMetrics(ctx, client, ProjectScope(project), prefix, resourceType, metricNamespace, metricName, window, 60, aggregation)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []helpers.MetricSample
+
+	for instance, instancePoints := range points {
+		for _, point := range instancePoints {
+			if point.GetInterval().GetEndTime() == nil {
+				continue
+			}
+			samples = append(samples, helpers.MetricSample{
+				Timestamp: point.GetInterval().GetEndTime().AsTime(),
+				Group:     instance.groupName,
+				Instance:  instance.instanceID,
+				Location:  zoneRegion(instance.zone),
+				Value:     point.Value.GetDoubleValue(),
+			})
+		}
+	}
+
+	return samples, nil
+}
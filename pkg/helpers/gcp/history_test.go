@@ -0,0 +1,13 @@
+package gcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestZoneRegion(t *testing.T) {
+	require.Equal(t, "us-east1", zoneRegion("us-east1-b"))
+	require.Equal(t, "europe-west3", zoneRegion("europe-west3-a"))
+	require.Equal(t, "", zoneRegion(""))
+}
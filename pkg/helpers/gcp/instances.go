@@ -44,10 +44,25 @@ func (l InstanceGroupManagerList) InstanceNames() []string {
 	return result
 }
 
+// InstanceRegion returns region of the instance group containing instance with the name
+func (l InstanceGroupManagerList) InstanceRegion(name string) string {
+	for _, group := range l {
+		for _, instance := range group.Instances {
+			if helpers.LastPartOnSplit(instance.Instance, "/") == name {
+				return group.Region
+			}
+		}
+	}
+	return ""
+}
+
 type InstanceGroupManager struct {
 	Name      string
+	Project   string
 	Region    string
 	Instances []*compute.ManagedInstance
+	// AutoHealingPolicies are auto healing policies of the instance group manager
+	AutoHealingPolicies []*compute.InstanceGroupManagerAutoHealingPolicy
 }
 
 func (g InstanceGroupManager) InstanceNames() []string {
@@ -100,17 +115,12 @@ func processOpResult(op *compute.Operation, err error) error {
 	return nil
 }
 
-func DeleteManagementInstances(ctx context.Context, client *compute.Service, project string, groups InstanceGroupManagerList) error {
+// DeleteManagementInstances deletes instances of instance group managers in their projects
+func DeleteManagementInstances(ctx context.Context, client *compute.Service, groups InstanceGroupManagerList) error {
 
-	var values []interface{}
+	return fanout.Run(ctx, len(groups), fanout.Options{}, func(ctx context.Context, idx int) error {
 
-	for _, group := range groups {
-		values = append(values, group)
-	}
-
-	out := fanout.ConcurrentResponseErrors(ctx, func(ctx context.Context, value interface{}) error {
-
-		group := value.(InstanceGroupManager)
+		group := groups[idx]
 
 		if len(group.Instances) == 0 {
 			return nil
@@ -122,7 +132,7 @@ func DeleteManagementInstances(ctx context.Context, client *compute.Service, pro
 			instanceIDs = append(instanceIDs, instance.Instance)
 		}
 		op, err := client.RegionInstanceGroupManagers.DeleteInstances(
-			project,
+			group.Project,
 			group.Region,
 			group.Name,
 			&compute.RegionInstanceGroupManagersDeleteInstancesRequest{
@@ -137,7 +147,7 @@ func DeleteManagementInstances(ctx context.Context, client *compute.Service, pro
 			return err
 		}
 		log.Printf("[DEBUG] failover: Waiting while instances are being deleted: %s", strings.Join(instanceIDs, ", "))
-		if err := waitForOperation(ctx, op, prepareRegionGetOp(ctx, client, project, group.Region)); err != nil {
+		if err := waitForOperation(ctx, op, prepareRegionGetOp(ctx, client, group.Project, group.Region)); err != nil {
 			return fmt.Errorf(
 				"delete operations for instances %s of managent group %q has not finished in time: %w",
 				strings.Join(instanceIDs, ", "),
@@ -147,18 +157,66 @@ func DeleteManagementInstances(ctx context.Context, client *compute.Service, pro
 		}
 		log.Printf("[DEBUG] failover: Instances have been deleted: %s", strings.Join(instanceIDs, ", "))
 		return nil
-	}, values...)
+	})
 
-	return fanout.ReadErrorsChannel(out)
+}
 
+// instanceZone returns zone of the managed instance URL .../projects/<project>/zones/<zone>/instances/<name>
+func instanceZone(instance string) string {
+	parts := strings.Split(instance, "/")
+	for idx, part := range parts {
+		if part == "zones" && idx+1 < len(parts) {
+			return parts[idx+1]
+		}
+	}
+	return ""
 }
 
+// GetInstancesAddresses returns internal IP addresses of the instance groups instances by instance names
+func GetInstancesAddresses(ctx context.Context, client *compute.Service, groups InstanceGroupManagerList) (map[string]string, error) {
+
+	var urls, projects []string
+
+	for _, group := range groups {
+		for _, instance := range group.Instances {
+			urls = append(urls, instance.Instance)
+			projects = append(projects, group.Project)
+		}
+	}
+
+	results := make([]string, len(urls))
+
+	err := fanout.Run(ctx, len(urls), fanout.Options{Mode: fanout.CancelOnError}, func(ctx context.Context, idx int) error {
+		name := helpers.LastPartOnSplit(urls[idx], "/")
+		instance, err := client.Instances.Get(projects[idx], instanceZone(urls[idx]), name).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("cannot get instance %q: %w", name, err)
+		}
+		if len(instance.NetworkInterfaces) > 0 {
+			results[idx] = instance.NetworkInterfaces[0].NetworkIP
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := make(map[string]string, len(urls))
+	for idx, url := range urls {
+		addresses[helpers.LastPartOnSplit(url, "/")] = results[idx]
+	}
+
+	return addresses, nil
+}
+
+// WaitForInstancesCount waits while instance group managers of projects regions have expected number of instances
 func WaitForInstancesCount(
 	ctx context.Context,
 	client *compute.Service,
-	project, prefix string,
+	prefix string,
 	expectedNumber int,
-	regions ...string,
+	projects ...ProjectRegions,
 ) error {
 
 	ticker := time.NewTicker(5 * time.Second)
@@ -175,12 +233,11 @@ func WaitForInstancesCount(
 		case <-ticker.C:
 			log.Printf("[DEBUG] failover: Getting management group instances")
 
-			instanceGroups, err := GetInstanceGroupManagersForRegions(
+			instanceGroups, err := GetInstanceGroupManagersForProjects(
 				ctx,
 				client,
-				project,
 				prefix,
-				regions...,
+				projects...,
 			)
 
 			if err != nil {
@@ -271,9 +328,11 @@ func getManagementInstancesFromGroups(ctx context.Context, client *compute.Servi
 			return nil, fmt.Errorf("cannot get managed instances for instance group manager %q: %w", igm.Name, err)
 		}
 		groupManagers = append(groupManagers, InstanceGroupManager{
-			Name:      igm.Name,
-			Region:    region,
-			Instances: resp.ManagedInstances,
+			Name:                igm.Name,
+			Project:             project,
+			Region:              region,
+			Instances:           resp.ManagedInstances,
+			AutoHealingPolicies: igm.AutoHealingPolicies,
 		})
 	}
 
@@ -305,6 +364,38 @@ func GetInstanceGroupManagersForRegions(ctx context.Context, client *compute.Ser
 	return getManagementInstancesFromGroups(ctx, client, project, instanceGroupManagers...)
 }
 
+// ProjectRegions are regions of the project
+type ProjectRegions struct {
+	Project string
+	Regions []string
+}
+
+// GetInstanceGroupManagersForProjects returns instance group managers of projects regions in projects order
+func GetInstanceGroupManagersForProjects(ctx context.Context, client *compute.Service, prefix string, projects ...ProjectRegions) (InstanceGroupManagerList, error) {
+
+	results := make([]InstanceGroupManagerList, len(projects))
+
+	err := fanout.Run(ctx, len(projects), fanout.Options{Mode: fanout.CancelOnError}, func(ctx context.Context, idx int) error {
+		groups, err := GetInstanceGroupManagersForRegions(ctx, client, projects[idx].Project, prefix, projects[idx].Regions...)
+		if err != nil {
+			return fmt.Errorf("project %q: %w", projects[idx].Project, err)
+		}
+		results[idx] = groups
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var groups InstanceGroupManagerList
+	for _, result := range results {
+		groups = append(groups, result...)
+	}
+
+	return groups, nil
+}
+
 func GetInstanceGroupManagersForRegionsInnerClient(project, prefix string, regions ...string) (InstanceGroupManagerList, error) {
 
 	client, err := getComputeClient()
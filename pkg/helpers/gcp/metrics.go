@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
 
@@ -20,9 +22,30 @@ import (
 	monitoring "cloud.google.com/go/monitoring/apiv3/v2"
 )
 
+// MetricsScope is a Cloud Monitoring scoping project and projects which metrics are read through its metrics scope
+type MetricsScope struct {
+	Project string
+	// Projects are monitored projects of the scoping project. Only scoping project metrics are read if it is empty
+	Projects []string
+}
+
+// ProjectScope returns metrics scope of the single project
+func ProjectScope(project string) MetricsScope {
+	return MetricsScope{Project: project}
+}
+
+// MonitoredProjects returns projects which metrics are read
+func (s MetricsScope) MonitoredProjects() []string {
+	if len(s.Projects) == 0 {
+		return []string{s.Project}
+	}
+	return s.Projects
+}
+
 type instance struct {
 	instanceID string
 	groupName  string
+	zone       string
 }
 
 type InstanceMetricPoints map[instance][]*monitoringpb.Point
@@ -37,7 +60,7 @@ func getMetricsClient() (*monitoring.MetricClient, error) {
 	return client, nil
 }
 
-func prepareFilter(prefix, project, resourceType, metricsNamespace, metricName string, instanceNames ...string) string {
+func prepareFilter(prefix string, projects []string, resourceType, metricsNamespace, metricName string, instanceNames ...string) string {
 
 	var instanceFilters []string
 
@@ -47,10 +70,16 @@ func prepareFilter(prefix, project, resourceType, metricsNamespace, metricName s
 
 	instancesFilter := strings.Join(instanceFilters, " OR ")
 
+	projectFilters := make([]string, 0, len(projects))
+
+	for _, project := range projects {
+		projectFilters = append(projectFilters, fmt.Sprintf(`resource.label.project_id = "%s"`, project))
+	}
+
 	mainFilter := fmt.Sprintf(
-		`resource.type = "%s" AND resource.label.project_id = "%s" AND metric.type = "custom.googleapis.com/%s/%s" AND metric.label.prefix = "%s"`,
+		`resource.type = "%s" AND (%s) AND metric.type = "custom.googleapis.com/%s/%s" AND metric.label.prefix = "%s"`,
 		resourceType,
-		project,
+		strings.Join(projectFilters, " OR "),
 		metricsNamespace,
 		metricName,
 		prefix,
@@ -63,22 +92,40 @@ func prepareFilter(prefix, project, resourceType, metricsNamespace, metricName s
 
 }
 
+// seriesAggregation returns aligner and per instance reducer of validator metric aggregation.
+// Maximum aggregation keeps the REDUCE_SUM reducer used before aggregation became configurable
+func seriesAggregation(aggregation helpers.MetricAggregation) (monitoringpb.Aggregation_Aligner, monitoringpb.Aggregation_Reducer) {
+	switch aggregation {
+	case helpers.MetricAggregationMinimum:
+		return monitoringpb.Aggregation_ALIGN_MIN, monitoringpb.Aggregation_REDUCE_MIN
+	case helpers.MetricAggregationAverage:
+		return monitoringpb.Aggregation_ALIGN_MEAN, monitoringpb.Aggregation_REDUCE_MEAN
+	case helpers.MetricAggregationSum:
+		return monitoringpb.Aggregation_ALIGN_SUM, monitoringpb.Aggregation_REDUCE_SUM
+	default:
+		return monitoringpb.Aggregation_ALIGN_MAX, monitoringpb.Aggregation_REDUCE_SUM
+	}
+}
+
 func listMetrics(
 	ctx context.Context,
 	client *monitoring.MetricClient,
-	project,
+	scope MetricsScope,
 	prefix,
 	resourceType,
 	metricsNamespace,
 	metricName string,
-	interval int,
+	window time.Duration,
 	alignmentPeriod int,
+	metricAggregation helpers.MetricAggregation,
 	instanceNames ...string,
 ) (InstanceMetricPoints, error) {
 
+	projects := scope.MonitoredProjects()
+
 	filter := prepareFilter(
 		prefix,
-		project,
+		projects,
 		resourceType,
 		metricsNamespace,
 		metricName,
@@ -87,7 +134,7 @@ func listMetrics(
 
 	log.Printf("[DEBUG]. Filtering time series with filter: %s", filter)
 
-	startTime := time.Now().UTC().Add(time.Minute * -time.Duration(interval))
+	startTime := time.Now().UTC().Add(-window)
 	endTime := time.Now().UTC()
 
 	timeInterval := &monitoringpb.TimeInterval{
@@ -99,15 +146,17 @@ func listMetrics(
 		},
 	}
 
+	aligner, reducer := seriesAggregation(metricAggregation)
+
 	aggregation := &monitoringpb.Aggregation{
 		AlignmentPeriod:    &durationpb.Duration{Seconds: int64(alignmentPeriod)},
-		PerSeriesAligner:   monitoringpb.Aggregation_ALIGN_MAX,
-		CrossSeriesReducer: monitoringpb.Aggregation_REDUCE_SUM,
-		GroupByFields:      []string{"resource.label.instance_id"},
+		PerSeriesAligner:   aligner,
+		CrossSeriesReducer: reducer,
+		GroupByFields:      []string{"resource.label.instance_id", "resource.label.zone"},
 	}
 
 	req := monitoringpb.ListTimeSeriesRequest{
-		Name:        "projects/" + project,
+		Name:        "projects/" + scope.Project,
 		Filter:      filter,
 		Interval:    timeInterval,
 		Aggregation: aggregation,
@@ -131,12 +180,12 @@ func listMetrics(
 		instanceID := resource.Labels["instance_id"]
 		projectID := resource.Labels["project_id"]
 
-		if projectID != project || !strings.HasPrefix(instanceID, helpers.GetPrefix(prefix)) {
+		if helpers.FindStrIndex(projectID, projects) == -1 || !strings.HasPrefix(instanceID, helpers.GetPrefix(prefix)) {
 			continue
 		}
 		metric := timeSeries.Metric
 		groupName := metric.Labels["group_name"]
-		results[instance{instanceID: instanceID, groupName: groupName}] = timeSeries.Points
+		results[instance{instanceID: instanceID, groupName: groupName, zone: resource.Labels["zone"]}] = timeSeries.Points
 
 	}
 
@@ -147,22 +196,65 @@ func listMetrics(
 func GetValidatorMetrics(
 	ctx context.Context,
 	client *monitoring.MetricClient,
-	project,
+	scope MetricsScope,
 	prefix,
 	metricNamespace,
 	metricName string,
+	aggregation helpers.MetricAggregation,
+	freshness helpers.MetricFreshness,
 	instanceNames ...string,
 ) (InstanceMetricPoints, error) {
 	return listMetrics(
 		ctx,
 		client,
-		project,
+		scope,
 		prefix,
 		resourceType,
 		metricNamespace,
 		metricName,
-		5,
+		freshness.GetWindow(),
 		60,
+		aggregation,
 		instanceNames...,
 	)
 }
+
+// GetValidatorMetricDescriptor returns custom metric type if cloud monitoring has the metric descriptor. Empty string is returned if it is not found
+func GetValidatorMetricDescriptor(
+	ctx context.Context,
+	client *monitoring.MetricClient,
+	project,
+	metricsNamespace,
+	metricName string,
+) (string, error) {
+
+	metricType := fmt.Sprintf("custom.googleapis.com/%s/%s", metricsNamespace, metricName)
+
+	descriptor, err := client.GetMetricDescriptor(ctx, &monitoringpb.GetMetricDescriptorRequest{
+		Name: fmt.Sprintf("projects/%s/metricDescriptors/%s", project, metricType),
+	})
+
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return "", nil
+		}
+		return "", fmt.Errorf("cannot get metric descriptor %q. Project %q: %w", metricType, project, err)
+	}
+
+	return descriptor.GetType(), nil
+}
+
+// WaitValidatorMetricDescriptor waits while the custom metric descriptor is found attempts times in a row
+func WaitValidatorMetricDescriptor(
+	ctx context.Context,
+	client *monitoring.MetricClient,
+	project,
+	metricsNamespace,
+	metricName string,
+	period time.Duration,
+	attempts int,
+) (string, error) {
+	return helpers.WaitForStableValue(ctx, period, attempts, func(ctx context.Context) (string, error) {
+		return GetValidatorMetricDescriptor(ctx, client, project, metricsNamespace, metricName)
+	})
+}
@@ -0,0 +1,33 @@
+package gcp
+
+import (
+	"testing"
+
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
+	"github.com/stretchr/testify/require"
+	monitoringpb "google.golang.org/genproto/googleapis/monitoring/v3"
+)
+
+func TestPrepareFilter(t *testing.T) {
+	require.Equal(
+		t,
+		`resource.type = "gce_instance" AND (resource.label.project_id = "a" OR resource.label.project_id = "b") AND metric.type = "custom.googleapis.com/polkadot/validator/value" AND metric.label.prefix = "test" AND (resource.label.instance_id = "x")`,
+		prepareFilter("test", []string{"a", "b"}, resourceType, metricNamespace, metricName, "x"),
+	)
+}
+
+func TestMetricsScopeMonitoredProjects(t *testing.T) {
+	require.Equal(t, []string{"scope"}, ProjectScope("scope").MonitoredProjects())
+	require.Equal(t, []string{"a", "b"}, MetricsScope{Project: "scope", Projects: []string{"a", "b"}}.MonitoredProjects())
+}
+
+func TestSeriesAggregation(t *testing.T) {
+	// default maximum aggregation sums series of an instance as before aggregation became configurable
+	aligner, reducer := seriesAggregation(helpers.DefaultValidatorMetricCheck().GetAggregation())
+	require.Equal(t, monitoringpb.Aggregation_ALIGN_MAX, aligner)
+	require.Equal(t, monitoringpb.Aggregation_REDUCE_SUM, reducer)
+
+	aligner, reducer = seriesAggregation(helpers.MetricAggregationMinimum)
+	require.Equal(t, monitoringpb.Aggregation_ALIGN_MIN, aligner)
+	require.Equal(t, monitoringpb.Aggregation_REDUCE_MIN, reducer)
+}
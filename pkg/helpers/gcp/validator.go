@@ -3,9 +3,12 @@ package gcp
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
 	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/errors"
+	monitoringpb "google.golang.org/genproto/googleapis/monitoring/v3"
 
 	monitoring "cloud.google.com/go/monitoring/apiv3/v2"
 )
@@ -22,55 +25,126 @@ type Validator struct {
 	Metric       int
 }
 
-func GetValidatorWithClient(
+// ValidatorMetric is the last validator metric value of an instance
+type ValidatorMetric struct {
+	GroupName    string
+	InstanceName string
+	Value        float64
+	// Missing is true if there are no metric points for the instance
+	Missing bool
+	// Timestamp is the end time of the last point. Stale is true if it is older than metric max age
+	Timestamp time.Time
+	Stale     bool
+}
+
+// MetricValue returns validator metric value seen for the instance
+func (m ValidatorMetric) MetricValue() errors.MetricValue {
+	return errors.MetricValue{Instance: m.InstanceName, Value: m.Value, Missing: m.Missing, Stale: m.Stale, Timestamp: m.Timestamp}
+}
+
+// lastPoint returns the point with the latest end time
+func lastPoint(points []*monitoringpb.Point) *monitoringpb.Point {
+	var last *monitoringpb.Point
+	for _, point := range points {
+		if point.GetInterval().GetEndTime() == nil {
+			continue
+		}
+		if last == nil || point.GetInterval().GetEndTime().AsTime().After(last.GetInterval().GetEndTime().AsTime()) {
+			last = point
+		}
+	}
+	return last
+}
+
+// GetValidatorMetricValues returns the last validator metric values of instances sorted by instance name
+func GetValidatorMetricValues(
 	ctx context.Context,
 	client *monitoring.MetricClient,
-	project,
+	scope MetricsScope,
 	prefix,
 	metricNamespace,
 	metricName string,
-	checkValue int,
+	aggregation helpers.MetricAggregation,
+	freshness helpers.MetricFreshness,
 	instanceNames ...string,
-) (Validator, error) {
-	points, err := GetValidatorMetrics(ctx, client, project, prefix, metricNamespace, metricName, instanceNames...)
+) ([]ValidatorMetric, error) {
+	points, err := GetValidatorMetrics(ctx, client, scope, prefix, metricNamespace, metricName, aggregation, freshness, instanceNames...)
 
 	if err != nil {
-		return Validator{}, err
+		return nil, err
 	}
 
-	var validators []Validator
+	metrics := make([]ValidatorMetric, 0, len(points))
+	now := time.Now()
 
 	for instance, points := range points {
-		if len(points) == 0 {
-			continue
+		metric := ValidatorMetric{GroupName: instance.groupName, InstanceName: instance.instanceID, Missing: true}
+		if point := lastPoint(points); point != nil {
+			metric.Value = point.Value.GetDoubleValue()
+			metric.Timestamp = point.GetInterval().GetEndTime().AsTime()
+			metric.Stale = freshness.Stale(metric.Timestamp, now)
+			metric.Missing = false
 		}
+		metrics = append(metrics, metric)
+	}
 
-		lastPoint := points[0]
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].InstanceName < metrics[j].InstanceName
+	})
 
-		value := int(lastPoint.Value.GetDoubleValue())
+	return metrics, nil
+}
+
+// GetValidatorWithClient returns the only instance which metric value is the check validator value
+func GetValidatorWithClient(
+	ctx context.Context,
+	client *monitoring.MetricClient,
+	scope MetricsScope,
+	prefix,
+	metricNamespace,
+	metricName string,
+	check helpers.ValidatorMetricCheck,
+	freshness helpers.MetricFreshness,
+	instanceNames ...string,
+) (Validator, error) {
+	metrics, err := GetValidatorMetricValues(ctx, client, scope, prefix, metricNamespace, metricName, check.GetAggregation(), freshness, instanceNames...)
 
-		if value == checkValue {
+	if err != nil {
+		return Validator{}, err
+	}
+
+	var validators []Validator
+	values := make([]errors.MetricValue, 0, len(metrics))
+
+	for _, metric := range metrics {
+		values = append(values, metric.MetricValue())
+		if !metric.Missing && !metric.Stale && check.IsValidator(metric.Value) {
 			validators = append(validators, Validator{
-				GroupName:    instance.groupName,
-				InstanceName: instance.instanceID,
-				Metric:       checkValue,
+				GroupName:    metric.GroupName,
+				InstanceName: metric.InstanceName,
+				Metric:       check.Value,
 			})
-			continue
 		}
-
 	}
 
 	switch len(validators) {
 	case 0:
-		return Validator{}, errors.NewValidatorError("cannot find validators", errors.ValidatorErrorNotFound)
+		return Validator{}, errors.NewValidatorError("cannot find validators", errors.ValidatorErrorNotFound).WithValues(values)
 	case 1:
 		return validators[0], nil
 	default:
-		return Validator{}, errors.NewValidatorError(fmt.Sprintf("found %d validators: %#v", len(validators), validators), errors.ValidatorErrorMultiple)
+		return Validator{}, errors.NewValidatorError(fmt.Sprintf("found %d validators: %#v", len(validators), validators), errors.ValidatorErrorMultiple).WithValues(values)
 	}
 
 }
 
+// waitCheck returns default validator metric check with checkValue
+func waitCheck(checkValue int) helpers.ValidatorMetricCheck {
+	check := helpers.DefaultValidatorMetricCheck()
+	check.Value = checkValue
+	return check
+}
+
 // WaitForValidator waits while validator metrics is being appeared
 func WaitForValidator(
 	project,
@@ -98,7 +172,7 @@ func WaitForValidator(
 		case <-timerChan:
 			return Validator{}, fmt.Errorf("timeout waiting for validator")
 		default:
-			validator, err := GetValidatorWithClient(ctx, client, project, prefix, metricNamespace, metricName, checkValue, instanceNames...)
+			validator, err := GetValidatorWithClient(ctx, client, ProjectScope(project), prefix, metricNamespace, metricName, waitCheck(checkValue), helpers.DefaultMetricFreshness(), instanceNames...)
 			if err == nil && validator.InstanceName != "" {
 				return validator, nil
 			}
@@ -128,7 +202,7 @@ func WaitForValidatorWithClient(
 		case <-timerChan:
 			return Validator{}, fmt.Errorf("timeout waiting for validator")
 		default:
-			validator, err := GetValidatorWithClient(ctx, client, project, prefix, metricNamespace, metricName, checkValue, instanceNames...)
+			validator, err := GetValidatorWithClient(ctx, client, ProjectScope(project), prefix, metricNamespace, metricName, waitCheck(checkValue), helpers.DefaultMetricFreshness(), instanceNames...)
 			if err == nil && validator.InstanceName != "" {
 				return validator, nil
 			}
@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	helperErrors "github.com/protofire/polkadot-failover-mechanism/pkg/helpers/errors"
+	monitoringpb "google.golang.org/genproto/googleapis/monitoring/v3"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/stretchr/testify/require"
 )
@@ -33,3 +35,18 @@ func TestValidatorErrorCheck(t *testing.T) {
 	testErr := helperErrors.NewValidatorError("cannot find validators", helperErrors.ValidatorErrorNotFound)
 	require.True(t, errors.As(testErr, &helperErrors.ValidatorError{}))
 }
+
+func TestLastPoint(t *testing.T) {
+	point := func(seconds int64, value float64) *monitoringpb.Point {
+		return &monitoringpb.Point{
+			Interval: &monitoringpb.TimeInterval{EndTime: &timestamppb.Timestamp{Seconds: seconds}},
+			Value:    &monitoringpb.TypedValue{Value: &monitoringpb.TypedValue_DoubleValue{DoubleValue: value}},
+		}
+	}
+
+	require.Nil(t, lastPoint(nil))
+
+	last := lastPoint([]*monitoringpb.Point{point(60, 0), point(180, 1), point(120, 0)})
+	require.Equal(t, int64(180), last.GetInterval().GetEndTime().GetSeconds())
+	require.Equal(t, float64(1), last.GetValue().GetDoubleValue())
+}
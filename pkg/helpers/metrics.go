@@ -0,0 +1,93 @@
+package helpers
+
+import (
+	"time"
+)
+
+const (
+	// DefaultMetricWindow is the time range validator metric samples are queried for
+	DefaultMetricWindow = 5 * time.Minute
+	// DefaultMetricMaxAge is the age validator metric samples become stale after. Telegraf pushes metrics every minute
+	DefaultMetricMaxAge = 3 * time.Minute
+)
+
+// MetricFreshness limits validator metric samples. The latest sample in Window is used, samples older than MaxAge are stale
+type MetricFreshness struct {
+	Window time.Duration
+	// MaxAge is not checked if it is 0
+	MaxAge time.Duration
+}
+
+// DefaultMetricFreshness returns freshness limits used if they are not configured
+func DefaultMetricFreshness() MetricFreshness {
+	return MetricFreshness{Window: DefaultMetricWindow, MaxAge: DefaultMetricMaxAge}
+}
+
+// GetWindow returns Window or DefaultMetricWindow if it is not set
+func (f MetricFreshness) GetWindow() time.Duration {
+	if f.Window <= 0 {
+		return DefaultMetricWindow
+	}
+	return f.Window
+}
+
+// Stale returns true if the sample taken at timestamp is older than MaxAge at now
+func (f MetricFreshness) Stale(timestamp, now time.Time) bool {
+	return f.MaxAge > 0 && now.Sub(timestamp) > f.MaxAge
+}
+
+// MetricAggregation is the statistic validator metric samples are aggregated with over a query period
+type MetricAggregation string
+
+const (
+	MetricAggregationMaximum MetricAggregation = "maximum"
+	MetricAggregationMinimum MetricAggregation = "minimum"
+	MetricAggregationAverage MetricAggregation = "average"
+	MetricAggregationSum     MetricAggregation = "sum"
+
+	// DefaultValidatorMetricValue is the metric value reported by the validator instance
+	DefaultValidatorMetricValue = 1
+)
+
+// MetricAggregations returns supported validator metric aggregations
+func MetricAggregations() []string {
+	return []string{
+		string(MetricAggregationMaximum),
+		string(MetricAggregationMinimum),
+		string(MetricAggregationAverage),
+		string(MetricAggregationSum),
+	}
+}
+
+// ValidatorMetricCheck describes how validator metric samples are aggregated and which aggregated value the validator reports
+type ValidatorMetricCheck struct {
+	Aggregation MetricAggregation
+	Value       int
+}
+
+// DefaultValidatorMetricCheck returns the check used if it is not configured. Telegraf reports 1 by the validator
+func DefaultValidatorMetricCheck() ValidatorMetricCheck {
+	return ValidatorMetricCheck{Aggregation: MetricAggregationMaximum, Value: DefaultValidatorMetricValue}
+}
+
+// GetAggregation returns Aggregation or maximum if it is not set
+func (c ValidatorMetricCheck) GetAggregation() MetricAggregation {
+	if c.Aggregation == "" {
+		return MetricAggregationMaximum
+	}
+	return c.Aggregation
+}
+
+// IsValidator returns true if the aggregated metric value is the validator value
+func (c ValidatorMetricCheck) IsValidator(value float64) bool {
+	return int(value) == c.Value
+}
+
+// MetricSample is validator metric value reported by an instance at Timestamp. Location is the instance region
+type MetricSample struct {
+	Timestamp time.Time
+	Group     string
+	Instance  string
+	Location  string
+	Value     float64
+}
@@ -0,0 +1,35 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricFreshness(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	freshness := DefaultMetricFreshness()
+	require.Equal(t, DefaultMetricWindow, freshness.GetWindow())
+	require.False(t, freshness.Stale(now.Add(-time.Minute), now))
+	require.False(t, freshness.Stale(now.Add(-DefaultMetricMaxAge), now))
+	require.True(t, freshness.Stale(now.Add(-4*time.Minute), now))
+
+	// zero max age does not check samples age
+	freshness = MetricFreshness{}
+	require.Equal(t, DefaultMetricWindow, freshness.GetWindow())
+	require.False(t, freshness.Stale(now.Add(-time.Hour), now))
+}
+
+func TestValidatorMetricCheck(t *testing.T) {
+	check := DefaultValidatorMetricCheck()
+	require.True(t, check.IsValidator(1))
+	require.False(t, check.IsValidator(0))
+	require.False(t, check.IsValidator(2))
+
+	check = ValidatorMetricCheck{Value: 2}
+	require.Equal(t, MetricAggregationMaximum, check.GetAggregation())
+	require.True(t, check.IsValidator(2))
+	require.False(t, check.IsValidator(1))
+}
@@ -0,0 +1,106 @@
+package resource
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
+)
+
+// ValidatorDetection enumerates sources the validator is detected with
+type ValidatorDetection string
+
+const (
+	// ValidatorDetectionMetrics detects the validator with cloud monitoring metrics
+	ValidatorDetectionMetrics ValidatorDetection = "metrics"
+	// ValidatorDetectionRPC detects the validator with system_nodeRoles JSON-RPC calls to instances
+	ValidatorDetectionRPC ValidatorDetection = "rpc"
+	// ValidatorDetectionBoth requires metrics and JSON-RPC to detect the same validator
+	ValidatorDetectionBoth ValidatorDetection = "both"
+
+	// DefaultRPCPort is polkadot JSON-RPC HTTP port
+	DefaultRPCPort = 9933
+
+	// DefaultConsulLockKey is the key of `consul lock prefix` run by instances
+	DefaultConsulLockKey = "prefix/.lock"
+
+	ValidatorDetectionFieldName   = "validator_detection"
+	RPCPortFieldName              = "rpc_port"
+	ConsulAddressFieldName        = "consul_address"
+	ConsulLockKeyFieldName        = "consul_lock_key"
+	MetricWindowFieldName         = "metric_window"
+	MetricMaxAgeFieldName         = "metric_max_age"
+	MetricAggregationFieldName    = "metric_aggregation"
+	ValidatorMetricValueFieldName = "validator_metric_value"
+)
+
+// DetectsWithMetrics returns true if the validator is detected with cloud monitoring metrics
+func (f Failover) DetectsWithMetrics() bool {
+	return f.ValidatorDetection != ValidatorDetectionRPC
+}
+
+// DetectsWithRPC returns true if the validator is detected with JSON-RPC calls to instances
+func (f Failover) DetectsWithRPC() bool {
+	return f.ValidatorDetection == ValidatorDetectionRPC || f.ValidatorDetection == ValidatorDetectionBoth
+}
+
+// GetRPCPort returns JSON-RPC port of instances
+func (f Failover) GetRPCPort() int {
+	if f.RPCPort <= 0 {
+		return DefaultRPCPort
+	}
+	return f.RPCPort
+}
+
+// ChecksConsulLock returns true if the validator is cross-checked against the Consul lock holder
+func (f Failover) ChecksConsulLock() bool {
+	return f.ConsulAddress != ""
+}
+
+// GetConsulLockKey returns Consul KV key of the validator lock
+func (f Failover) GetConsulLockKey() string {
+	if f.ConsulLockKey == "" {
+		return DefaultConsulLockKey
+	}
+	return f.ConsulLockKey
+}
+
+// DetectsWithMetricsOnly returns true if cloud monitoring metrics are the only validator source
+func (f Failover) DetectsWithMetricsOnly() bool {
+	return !f.DetectsWithRPC() && !f.ChecksConsulLock()
+}
+
+// GetMetricFreshness returns validator metric query window and samples max age. Defaults are used if the window is not set
+func (f Failover) GetMetricFreshness() helpers.MetricFreshness {
+	if f.MetricWindow <= 0 {
+		return helpers.DefaultMetricFreshness()
+	}
+	return helpers.MetricFreshness{
+		Window: time.Duration(f.MetricWindow) * time.Second,
+		MaxAge: time.Duration(f.MetricMaxAge) * time.Second,
+	}
+}
+
+// GetValidatorMetricCheck returns validator metric aggregation and value. Telegraf defaults are used if they are not set
+func (f Failover) GetValidatorMetricCheck() helpers.ValidatorMetricCheck {
+	check := helpers.DefaultValidatorMetricCheck()
+	if f.MetricAggregation != "" {
+		check.Aggregation = helpers.MetricAggregation(f.MetricAggregation)
+	}
+	if f.ValidatorMetricValue != 0 {
+		check.Value = f.ValidatorMetricValue
+	}
+	return check
+}
+
+// ValidateValidatorMetric checks validator metric aggregation and value. Instances which do not validate report 0,
+// so 0 cannot be the validator value
+func ValidateValidatorMetric(aggregation string, value int) error {
+	if helpers.FindStrIndex(aggregation, helpers.MetricAggregations()) == -1 {
+		return fmt.Errorf("%q should be one of %v, got %q", MetricAggregationFieldName, helpers.MetricAggregations(), aggregation)
+	}
+	if value <= 0 {
+		return fmt.Errorf("%q should be positive, got %d. Instances which do not validate report 0", ValidatorMetricValueFieldName, value)
+	}
+	return nil
+}
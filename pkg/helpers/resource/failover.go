@@ -1,6 +1,9 @@
 package resource
 
 import (
+	"fmt"
+
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
 	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/failover"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
@@ -20,18 +23,28 @@ const (
 	FailOverModeDistributed FailOverMode = "distributed"
 	// FailOverModeSingle ...
 	FailOverModeSingle FailOverMode = "single"
+	// FailOverModeStandby keeps the validator and standby_count warm standbys in other locations
+	FailOverModeStandby FailOverMode = "standby"
+
+	// DefaultStandbyCount is number of warm standbys in standby mode if standby_count is not set
+	DefaultStandbyCount = 2
 
-	TagsFieldName              = "tags"
-	InstancesFieldName         = "instances"
-	LocationsFieldName         = "locations"
-	PrimaryCountFieldName      = "primary_count"
-	SecondaryCountFieldName    = "secondary_count"
-	TertiaryCountFieldName     = "tertiary_count"
-	FailoverInstancesFieldName = "failover_instances"
-	FailoverModeFieldName      = "failover_mode"
-	PrefixFieldName            = "prefix"
-	MetricNameFieldName        = "metric_name"
-	MetricNamespaceFieldName   = "metric_namespace"
+	TagsFieldName               = "tags"
+	InstancesFieldName          = "instances"
+	LocationsFieldName          = "locations"
+	PrimaryCountFieldName       = "primary_count"
+	SecondaryCountFieldName     = "secondary_count"
+	TertiaryCountFieldName      = "tertiary_count"
+	FailoverInstancesFieldName  = "failover_instances"
+	FailoverModeFieldName       = "failover_mode"
+	PrefixFieldName             = "prefix"
+	MetricNameFieldName         = "metric_name"
+	MetricNamespaceFieldName    = "metric_namespace"
+	StandbyCountFieldName       = "standby_count"
+	PreferredLocationsFieldName = "preferred_locations"
+	PinLocationFieldName        = "pin_location"
+	DryRunFieldName             = "dry_run"
+	DryRunInstancesFieldName    = "dry_run_failover_instances"
 )
 
 type Failover struct {
@@ -41,30 +54,47 @@ type Failover struct {
 	MetricNameSpace   string
 	Instances         []int
 	Locations         []string
-	PrimaryCount      int
-	SecondaryCount    int
-	TertiaryCount     int
 	FailoverInstances []int
-	Source            FailoverSource
-}
-
-func (f *Failover) SetPrimaryCount(n int) {
-	f.PrimaryCount = n
-	f.FailoverInstances[0] = n
+	StandbyCount      int
+	// PreferredLocations and PinLocation place the validator in single mode
+	PreferredLocations []string
+	PinLocation        string
+	OnValidatorUnknown ValidatorUnknownPolicy
+	// OnValidatorOutsidePin decides whether single mode failover moves the validator running outside of PinLocation
+	OnValidatorOutsidePin PinnedValidatorPolicy
+	// DryRun failover does not delete instances. Calculated counts are kept in DryRunInstances
+	DryRun          bool
+	DryRunInstances []int
+	// ValidatorDetection chooses validator sources. RPCPort is instances JSON-RPC port
+	ValidatorDetection ValidatorDetection
+	RPCPort            int
+	// ConsulAddress is Consul HTTP API address. The validator is cross-checked against ConsulLockKey holder if it is set
+	ConsulAddress string
+	ConsulLockKey string
+	// MetricWindow and MetricMaxAge are validator metric query window and samples max age in seconds
+	MetricWindow int
+	MetricMaxAge int
+	// MetricAggregation and ValidatorMetricValue tell how metric samples are aggregated and which value the validator reports
+	MetricAggregation    string
+	ValidatorMetricValue int
+	Source               FailoverSource
 }
 
-func (f *Failover) SetSecondaryCount(n int) {
-	f.SecondaryCount = n
-	f.FailoverInstances[1] = n
-}
-
-func (f *Failover) SetTertiaryCount(n int) {
-	f.TertiaryCount = n
-	f.FailoverInstances[2] = n
+// Count returns instances count for location with index idx
+func (f Failover) Count(idx int) int {
+	if idx < 0 || idx >= len(f.FailoverInstances) {
+		return 0
+	}
+	return f.FailoverInstances[idx]
 }
 
 func (f Failover) IsNotSet() bool {
-	return f.PrimaryCount == 0 && f.SecondaryCount == 0 && f.TertiaryCount == 0
+	for _, count := range f.FailoverInstances {
+		if count != 0 {
+			return false
+		}
+	}
+	return true
 }
 
 func (f Failover) Initialized() bool {
@@ -74,9 +104,13 @@ func (f Failover) Initialized() bool {
 func (f *Failover) FillDefaultCountsIfNotSet() {
 	if f.IsNotSet() {
 		if f.IsSingleMode() {
-			// get first location for validator
-			counts := failover.CalculateInstancesForSingleFailOverMode(f.Instances)
+			counts := make([]int, len(f.Instances))
+			if len(counts) > 0 {
+				counts[f.ValidatorLocation(-1)] = 1
+			}
 			f.SetCounts(counts...)
+		} else if f.IsStandbyMode() {
+			f.SetCounts(f.StandbyCounts(-1)...)
 		} else {
 			f.SetCounts(f.Instances...)
 		}
@@ -91,48 +125,117 @@ func (f Failover) IsDistributedMode() bool {
 	return f.FailoverMode == FailOverModeDistributed
 }
 
+func (f Failover) IsStandbyMode() bool {
+	return f.FailoverMode == FailOverModeStandby
+}
+
+// StandbyCounts returns per location counts for standby mode. validatorLocation is index of the validator location or -1
+func (f Failover) StandbyCounts(validatorLocation int) []int {
+	if validatorLocation < 0 && len(f.Instances) > 0 {
+		validatorLocation = f.ValidatorLocation(-1)
+	}
+	return failover.CalculateInstancesForStandbyFailOverMode(f.Instances, validatorLocation, f.StandbyCount)
+}
+
+// ValidatorLocation returns location index of the validator. detected is index of the live validator location or -1.
+// The live validator stays in its location unless single mode pins another location
+func (f Failover) ValidatorLocation(detected int) int {
+	if detected >= 0 && f.KeepsValidator(detected) {
+		return detected
+	}
+	var preferred []int
+	for _, location := range f.PreferredLocations {
+		if idx := helpers.FindStrIndex(location, f.Locations); idx != -1 {
+			preferred = append(preferred, idx)
+		}
+	}
+	pin := -1
+	if f.PinLocation != "" {
+		pin = helpers.FindStrIndex(f.PinLocation, f.Locations)
+	}
+	return failover.CalculateValidatorLocation(f.Instances, preferred, pin)
+}
+
+// KeepsValidator checks whether the live validator in location with index detected stays in its location.
+// Single mode failover moves the validator running outside of the pinned location if on_validator_outside_pin allows it
+func (f Failover) KeepsValidator(detected int) bool {
+	if !f.IsSingleMode() || f.PinLocation == "" || detected < 0 {
+		return true
+	}
+	return helpers.FindStrIndex(f.PinLocation, f.Locations) == detected
+}
+
+// SelectStandbys returns per location counts for standby mode and instances kept as warm standbys.
+// instances are running instances per location without the validator
+func (f Failover) SelectStandbys(instances [][]string, validatorLocation int) ([]int, []string) {
+	counts := f.StandbyCounts(validatorLocation)
+	keep, _ := failover.SelectStandbyInstances(instances, counts, validatorLocation)
+	var standbys []string
+	for _, names := range keep {
+		standbys = append(standbys, names...)
+	}
+	return counts, standbys
+}
+
+// SetCounts sets per location instances count. Locations without passed value get count from Instances
 func (f *Failover) SetCounts(values ...int) {
-	switch len(values) {
-	case 0:
-		f.PrimaryCount = f.Instances[0]
-		f.SecondaryCount = f.Instances[1]
-		f.TertiaryCount = f.Instances[2]
-	case 1:
-		f.PrimaryCount = values[0]
-		f.SecondaryCount = f.Instances[1]
-		f.TertiaryCount = f.Instances[2]
-	case 2:
-		f.PrimaryCount = values[0]
-		f.SecondaryCount = values[1]
-		f.TertiaryCount = f.Instances[2]
-	default:
-		f.PrimaryCount = values[0]
-		f.SecondaryCount = values[1]
-		f.TertiaryCount = values[2]
-	}
-	f.FailoverInstances = []int{f.PrimaryCount, f.SecondaryCount, f.TertiaryCount}
+	size := len(f.Instances)
+	if len(values) > size {
+		size = len(values)
+	}
+	counts := make([]int, size)
+	copy(counts, f.Instances)
+	copy(counts, values)
+	f.FailoverInstances = counts
 }
 
 func (f Failover) InstancesCount() int {
-	return f.PrimaryCount + f.SecondaryCount + f.TertiaryCount
+	s := 0
+	for _, count := range f.FailoverInstances {
+		s += count
+	}
+	return s
 }
 
 func (f Failover) SetSchemaValues(d *schema.ResourceData) error {
-	if err := d.Set(PrimaryCountFieldName, f.PrimaryCount); err != nil {
+	// primary, secondary and tertiary counts are kept for configurations written for three locations
+	if err := d.Set(PrimaryCountFieldName, f.Count(0)); err != nil {
 		return err
 	}
-	if err := d.Set(SecondaryCountFieldName, f.SecondaryCount); err != nil {
+	if err := d.Set(SecondaryCountFieldName, f.Count(1)); err != nil {
 		return err
 	}
-	if err := d.Set(TertiaryCountFieldName, f.TertiaryCount); err != nil {
+	if err := d.Set(TertiaryCountFieldName, f.Count(2)); err != nil {
 		return err
 	}
 	if err := d.Set(FailoverInstancesFieldName, f.FailoverInstances); err != nil {
 		return err
 	}
+	// dry run counts left from previous runs are cleared when dry run is disabled
+	if f.DryRun || len(f.DryRunInstances) > 0 {
+		dryRunInstances := []int{}
+		if f.DryRun {
+			dryRunInstances = f.DryRunInstances
+		}
+		if err := d.Set(DryRunInstancesFieldName, dryRunInstances); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// ApplyDryRun keeps calculated counts in DryRunInstances and sets counts to running instances,
+// so that instance groups are not scaled. Calculated counts are used if nothing is running since there is nothing to delete
+func (f *Failover) ApplyDryRun(running []int) {
+	f.DryRunInstances = f.FailoverInstances
+	for _, count := range running {
+		if count > 0 {
+			f.SetCounts(running...)
+			return
+		}
+	}
+}
+
 func (f Failover) SetSchemaValuesDiag(d *schema.ResourceData) diag.Diagnostics {
 	if err := f.SetSchemaValues(d); err != nil {
 		return diag.FromErr(err)
@@ -140,7 +243,54 @@ func (f Failover) SetSchemaValuesDiag(d *schema.ResourceData) diag.Diagnostics {
 	return nil
 }
 
+// ID returns versioned resource ID. Failover configuration is kept in resource attributes
+func (f Failover) ID() (string, error) {
+	if f.Prefix == "" || f.FailoverMode == "" {
+		return "", fmt.Errorf("cannot build resource ID: prefix %q and failover mode %q should not be empty", f.Prefix, f.FailoverMode)
+	}
+	return FailoverID{Prefix: f.Prefix, Mode: f.FailoverMode}.String(), nil
+}
+
+// FromIDOrSchema reads failover from schema and checks it against the resource ID
+func (f *Failover) FromIDOrSchema(d *schema.ResourceData) error {
+	if err := f.FromSchema(d); err != nil {
+		return err
+	}
+
+	id := d.Id()
+	if id == "" {
+		return nil
+	}
+
+	failoverID, err := ParseID(id)
+	if err != nil {
+		return err
+	}
+
+	if f.Prefix == "" {
+		f.Prefix = failoverID.Prefix
+	}
+	if f.FailoverMode == "" {
+		f.FailoverMode = failoverID.Mode
+	}
+
+	if f.Prefix != failoverID.Prefix || f.FailoverMode != failoverID.Mode {
+		return fmt.Errorf("resource ID %q does not match prefix %q and failover mode %q", id, f.Prefix, f.FailoverMode)
+	}
+
+	f.Source = FailoverSourceID
+	return nil
+}
+
+type getter interface {
+	Get(key string) interface{}
+}
+
 func (f *Failover) FromSchema(d *schema.ResourceData) error {
+	return f.fromGetter(d)
+}
+
+func (f *Failover) fromGetter(d getter) error {
 
 	f.FailoverMode = FailOverMode(d.Get(FailoverModeFieldName).(string))
 
@@ -154,12 +304,104 @@ func (f *Failover) FromSchema(d *schema.ResourceData) error {
 	f.MetricName = d.Get(MetricNameFieldName).(string)
 	f.MetricNameSpace = d.Get(MetricNamespaceFieldName).(string)
 
-	f.PrimaryCount = d.Get(PrimaryCountFieldName).(int)
-	f.SecondaryCount = d.Get(SecondaryCountFieldName).(int)
-	f.TertiaryCount = d.Get(TertiaryCountFieldName).(int)
+	if standbyCount, ok := d.Get(StandbyCountFieldName).(int); ok {
+		f.StandbyCount = standbyCount
+	}
+
+	if preferredLocationsRaw, ok := d.Get(PreferredLocationsFieldName).([]interface{}); ok {
+		f.PreferredLocations = ExpandString(preferredLocationsRaw)
+	}
+
+	if pinLocation, ok := d.Get(PinLocationFieldName).(string); ok {
+		f.PinLocation = pinLocation
+	}
+
+	if policy, ok := d.Get(OnValidatorUnknownFieldName).(string); ok {
+		f.OnValidatorUnknown = ValidatorUnknownPolicy(policy)
+	}
+
+	if policy, ok := d.Get(OnValidatorOutsidePinFieldName).(string); ok {
+		f.OnValidatorOutsidePin = PinnedValidatorPolicy(policy)
+	}
+
+	if dryRun, ok := d.Get(DryRunFieldName).(bool); ok {
+		f.DryRun = dryRun
+	}
+
+	if dryRunInstancesRaw, ok := d.Get(DryRunInstancesFieldName).([]interface{}); ok {
+		f.DryRunInstances = ExpandInt(dryRunInstancesRaw)
+	}
+
+	if detection, ok := d.Get(ValidatorDetectionFieldName).(string); ok {
+		f.ValidatorDetection = ValidatorDetection(detection)
+	}
+
+	if rpcPort, ok := d.Get(RPCPortFieldName).(int); ok {
+		f.RPCPort = rpcPort
+	}
+
+	if consulAddress, ok := d.Get(ConsulAddressFieldName).(string); ok {
+		f.ConsulAddress = consulAddress
+	}
+
+	if consulLockKey, ok := d.Get(ConsulLockKeyFieldName).(string); ok {
+		f.ConsulLockKey = consulLockKey
+	}
+
+	if metricWindow, ok := d.Get(MetricWindowFieldName).(int); ok {
+		f.MetricWindow = metricWindow
+	}
+
+	if metricMaxAge, ok := d.Get(MetricMaxAgeFieldName).(int); ok {
+		f.MetricMaxAge = metricMaxAge
+	}
+
+	if metricAggregation, ok := d.Get(MetricAggregationFieldName).(string); ok {
+		f.MetricAggregation = metricAggregation
+	}
+
+	if validatorMetricValue, ok := d.Get(ValidatorMetricValueFieldName).(int); ok {
+		f.ValidatorMetricValue = validatorMetricValue
+	}
+
+	failoverInstancesRaw := d.Get(FailoverInstancesFieldName).([]interface{})
+	f.FailoverInstances = ExpandInt(failoverInstancesRaw)
+
+	if len(f.FailoverInstances) == 0 {
+		f.FailoverInstances = legacyCounts(d)
+	}
 
 	f.Source = FailoverSourceSchema
 
 	return nil
 
 }
+
+// RestoreLegacyCounts fills counts list from packed ID created before counts were stored as a list
+func (f *Failover) RestoreLegacyCounts(id string) error {
+	if len(f.FailoverInstances) != 0 {
+		return nil
+	}
+	counts, err := BsonUnPackLegacyCounts(id)
+	if err != nil {
+		return err
+	}
+	f.FailoverInstances = counts
+	return nil
+}
+
+// legacyCounts reads counts from primary, secondary and tertiary count fields
+// for states without failover instances list
+func legacyCounts(d getter) []int {
+	counts := []int{
+		d.Get(PrimaryCountFieldName).(int),
+		d.Get(SecondaryCountFieldName).(int),
+		d.Get(TertiaryCountFieldName).(int),
+	}
+	for _, count := range counts {
+		if count != 0 {
+			return counts
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package resource
+
+import (
+	"testing"
+	"time"
+
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailoverValidatorLocation(t *testing.T) {
+	f := Failover{
+		FailoverMode: FailOverModeSingle,
+		Instances:    []int{1, 3, 3},
+		Locations:    []string{"1", "2", "3"},
+	}
+
+	// the first location with maximum instances
+	require.Equal(t, 1, f.ValidatorLocation(-1))
+	// live validator stays in its location
+	require.Equal(t, 0, f.ValidatorLocation(0))
+
+	f.PreferredLocations = []string{"4", "3"}
+	require.Equal(t, 2, f.ValidatorLocation(-1))
+	// preferences do not move live validator
+	require.Equal(t, 0, f.ValidatorLocation(0))
+	require.True(t, f.KeepsValidator(0))
+
+	f.PinLocation = "1"
+	require.Equal(t, 0, f.ValidatorLocation(-1))
+	require.Equal(t, 0, f.ValidatorLocation(0))
+	// pinned location moves live validator in single mode
+	require.False(t, f.KeepsValidator(2))
+	require.Equal(t, 0, f.ValidatorLocation(2))
+	// validator with unknown location is kept
+	require.True(t, f.KeepsValidator(-1))
+
+	f.FailoverMode = FailOverModeStandby
+	require.True(t, f.KeepsValidator(2))
+	require.Equal(t, 2, f.ValidatorLocation(2))
+}
+
+func TestFailoverFillDefaultCountsWithPlacement(t *testing.T) {
+	f := Failover{
+		FailoverMode:       FailOverModeSingle,
+		Instances:          []int{1, 1, 1},
+		Locations:          []string{"1", "2", "3"},
+		PreferredLocations: []string{"2"},
+	}
+	f.FillDefaultCountsIfNotSet()
+	require.Equal(t, []int{0, 1, 0}, f.FailoverInstances)
+
+	f.FailoverInstances = nil
+	f.PinLocation = "3"
+	f.FillDefaultCountsIfNotSet()
+	require.Equal(t, []int{0, 0, 1}, f.FailoverInstances)
+
+	f.FailoverInstances = nil
+	f.FailoverMode = FailOverModeStandby
+	f.StandbyCount = 1
+	f.FillDefaultCountsIfNotSet()
+	require.Equal(t, []int{1, 0, 1}, f.FailoverInstances)
+}
+
+func TestFailoverApplyDryRun(t *testing.T) {
+	f := Failover{
+		FailoverMode: FailOverModeSingle,
+		Instances:    []int{1, 1, 1},
+		Locations:    []string{"1", "2", "3"},
+	}
+	f.SetCounts(0, 1, 0)
+	f.ApplyDryRun([]int{1, 1, 1})
+	require.Equal(t, []int{0, 1, 0}, f.DryRunInstances)
+	require.Equal(t, []int{1, 1, 1}, f.FailoverInstances)
+
+	// nothing is running, calculated counts are kept
+	f.SetCounts(0, 1, 0)
+	f.ApplyDryRun([]int{0, 0, 0})
+	require.Equal(t, []int{0, 1, 0}, f.DryRunInstances)
+	require.Equal(t, []int{0, 1, 0}, f.FailoverInstances)
+}
+
+func TestFailoverGetMetricFreshness(t *testing.T) {
+	require.Equal(t, helpers.DefaultMetricFreshness(), Failover{}.GetMetricFreshness())
+
+	f := Failover{MetricWindow: 600, MetricMaxAge: 0}
+	require.Equal(t, helpers.MetricFreshness{Window: 10 * time.Minute}, f.GetMetricFreshness())
+
+	f.MetricMaxAge = 120
+	require.Equal(t, helpers.MetricFreshness{Window: 10 * time.Minute, MaxAge: 2 * time.Minute}, f.GetMetricFreshness())
+}
+
+func TestFailoverGetValidatorMetricCheck(t *testing.T) {
+	require.Equal(t, helpers.DefaultValidatorMetricCheck(), Failover{}.GetValidatorMetricCheck())
+
+	f := Failover{MetricAggregation: "minimum", ValidatorMetricValue: 2}
+	require.Equal(t, helpers.ValidatorMetricCheck{Aggregation: helpers.MetricAggregationMinimum, Value: 2}, f.GetValidatorMetricCheck())
+}
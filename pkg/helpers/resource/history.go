@@ -0,0 +1,242 @@
+package resource
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/validate"
+)
+
+const (
+	HistoryWindowFieldName = "window"
+	TimelineFieldName      = "timeline"
+	TransitionsFieldName   = "transitions"
+	OverlapsFieldName      = "overlaps"
+	TimestampFieldName     = "timestamp"
+	InstanceFieldName      = "instance"
+
+	// DefaultHistoryWindow is the validator history window in seconds
+	DefaultHistoryWindow = 3600
+	// historyResolution is validator metric samples period. Samples within the period are reported at the same time
+	historyResolution = time.Minute
+)
+
+// ValidatorHistorySource is the validator metric polkadot_validator_history data source reads
+type ValidatorHistorySource struct {
+	Prefix               string
+	MetricName           string
+	MetricNameSpace      string
+	Window               int
+	MetricAggregation    string
+	ValidatorMetricValue int
+}
+
+// FromSchema reads data source arguments
+func (s *ValidatorHistorySource) FromSchema(d *schema.ResourceData) {
+	s.Prefix = d.Get(PrefixFieldName).(string)
+	s.MetricName = d.Get(MetricNameFieldName).(string)
+	s.MetricNameSpace = d.Get(MetricNamespaceFieldName).(string)
+	s.Window = d.Get(HistoryWindowFieldName).(int)
+	s.MetricAggregation = d.Get(MetricAggregationFieldName).(string)
+	s.ValidatorMetricValue = d.Get(ValidatorMetricValueFieldName).(int)
+}
+
+// ID returns data source ID
+func (s ValidatorHistorySource) ID(parts ...string) string {
+	return JoinID(append(parts, s.Prefix, s.MetricNameSpace, s.MetricName, strconv.Itoa(s.Window))...)
+}
+
+// GetWindow returns history window. Default window is used if it is not set
+func (s ValidatorHistorySource) GetWindow() time.Duration {
+	if s.Window <= 0 {
+		return DefaultHistoryWindow * time.Second
+	}
+	return time.Duration(s.Window) * time.Second
+}
+
+// GetValidatorMetricCheck returns validator metric aggregation and value
+func (s ValidatorHistorySource) GetValidatorMetricCheck() helpers.ValidatorMetricCheck {
+	return Failover{MetricAggregation: s.MetricAggregation, ValidatorMetricValue: s.ValidatorMetricValue}.GetValidatorMetricCheck()
+}
+
+// ValidatorHistoryEntry is an instance reporting validator metric value at Timestamp
+type ValidatorHistoryEntry struct {
+	Timestamp time.Time
+	Instance  string
+	Location  string
+}
+
+// ValidatorHistory is the validator timeline ordered by time and instance
+type ValidatorHistory struct {
+	Timeline []ValidatorHistoryEntry
+	// Transitions is how many times the validator has changed. Periods without validator are not transitions
+	Transitions int
+	// Overlaps is number of periods with more than one validator. Overlapping validators risk double signing
+	Overlaps int
+}
+
+// NewValidatorHistory builds the validator timeline from metric samples of all instances.
+// Samples are bucketed by minute, instances which value is the check validator value validate in the bucket
+func NewValidatorHistory(samples []helpers.MetricSample, check helpers.ValidatorMetricCheck) ValidatorHistory {
+
+	buckets := map[time.Time]map[string]ValidatorHistoryEntry{}
+
+	for _, sample := range samples {
+		if !check.IsValidator(sample.Value) {
+			continue
+		}
+		timestamp := sample.Timestamp.UTC().Truncate(historyResolution)
+		if buckets[timestamp] == nil {
+			buckets[timestamp] = map[string]ValidatorHistoryEntry{}
+		}
+		buckets[timestamp][sample.Instance] = ValidatorHistoryEntry{Timestamp: timestamp, Instance: sample.Instance, Location: sample.Location}
+	}
+
+	timestamps := make([]time.Time, 0, len(buckets))
+	for timestamp := range buckets {
+		timestamps = append(timestamps, timestamp)
+	}
+	sort.Slice(timestamps, func(i, j int) bool {
+		return timestamps[i].Before(timestamps[j])
+	})
+
+	history := ValidatorHistory{}
+	previous := ""
+
+	for _, timestamp := range timestamps {
+		entries := make([]ValidatorHistoryEntry, 0, len(buckets[timestamp]))
+		instances := make([]string, 0, len(buckets[timestamp]))
+		for instance, entry := range buckets[timestamp] {
+			entries = append(entries, entry)
+			instances = append(instances, instance)
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Instance < entries[j].Instance
+		})
+		sort.Strings(instances)
+
+		if len(entries) > 1 {
+			history.Overlaps++
+		}
+
+		validators := strings.Join(instances, ",")
+		if previous != "" && validators != previous {
+			history.Transitions++
+		}
+		previous = validators
+
+		history.Timeline = append(history.Timeline, entries...)
+	}
+
+	return history
+}
+
+// SetSchemaValuesDiag stores the timeline and counters in computed attributes
+func (h ValidatorHistory) SetSchemaValuesDiag(d *schema.ResourceData) diag.Diagnostics {
+	timeline := make([]interface{}, 0, len(h.Timeline))
+	for _, entry := range h.Timeline {
+		timeline = append(timeline, map[string]interface{}{
+			TimestampFieldName: entry.Timestamp.Format(time.RFC3339),
+			InstanceFieldName:  entry.Instance,
+			LocationFieldName:  entry.Location,
+		})
+	}
+	if err := d.Set(TimelineFieldName, timeline); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set(TransitionsFieldName, h.Transitions); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set(OverlapsFieldName, h.Overlaps); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// GetValidatorHistorySchema returns polkadot_validator_history data source attributes shared by providers
+func GetValidatorHistorySchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+
+		PrefixFieldName: {
+			Type:             schema.TypeString,
+			Required:         true,
+			ValidateDiagFunc: validate.DiagFunc(validate.Prefix),
+		},
+
+		MetricNameFieldName: {
+			Type:             schema.TypeString,
+			Required:         true,
+			ValidateDiagFunc: validate.DiagFunc(validation.StringIsNotEmpty),
+		},
+
+		MetricNamespaceFieldName: {
+			Type:             schema.TypeString,
+			Required:         true,
+			ValidateDiagFunc: validate.DiagFunc(validation.StringIsNotEmpty),
+		},
+
+		HistoryWindowFieldName: {
+			Type:             schema.TypeInt,
+			Description:      "Validator history window in seconds",
+			Optional:         true,
+			Default:          DefaultHistoryWindow,
+			ValidateDiagFunc: validate.DiagFunc(validation.IntAtLeast(300)),
+		},
+
+		MetricAggregationFieldName: {
+			Type:             schema.TypeString,
+			Description:      "Statistic validator metric samples are aggregated with. One of maximum, minimum, average or sum",
+			Optional:         true,
+			Default:          string(helpers.MetricAggregationMaximum),
+			ValidateDiagFunc: validate.DiagFunc(validation.StringInSlice(helpers.MetricAggregations(), false)),
+		},
+
+		ValidatorMetricValueFieldName: {
+			Type:             schema.TypeInt,
+			Description:      "Aggregated validator metric value reported by the validator instance",
+			Optional:         true,
+			Default:          helpers.DefaultValidatorMetricValue,
+			ValidateDiagFunc: validate.DiagFunc(validation.IntAtLeast(1)),
+		},
+
+		TimelineFieldName: {
+			Type:        schema.TypeList,
+			Description: "Instances reporting validator metric value per minute ordered by time and instance",
+			Computed:    true,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					TimestampFieldName: {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+					InstanceFieldName: {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+					LocationFieldName: {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+				},
+			},
+		},
+
+		TransitionsFieldName: {
+			Type:        schema.TypeInt,
+			Description: "How many times the validator has changed in the window. Frequent transitions mean flapping",
+			Computed:    true,
+		},
+
+		OverlapsFieldName: {
+			Type:        schema.TypeInt,
+			Description: "Number of minutes with more than one validator in the window. Overlapping validators risk double signing",
+			Computed:    true,
+		},
+	}
+}
@@ -0,0 +1,44 @@
+package resource
+
+import (
+	"testing"
+	"time"
+
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewValidatorHistory(t *testing.T) {
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	minute := func(m int) time.Time {
+		return start.Add(time.Duration(m) * time.Minute)
+	}
+
+	samples := []helpers.MetricSample{
+		{Timestamp: minute(0), Instance: "i1", Location: "l1", Value: 1},
+		{Timestamp: minute(0), Instance: "i2", Location: "l2", Value: 0},
+		{Timestamp: minute(1).Add(10 * time.Second), Instance: "i1", Location: "l1", Value: 1},
+		// i1 and i2 overlap
+		{Timestamp: minute(2), Instance: "i2", Location: "l2", Value: 1},
+		{Timestamp: minute(2), Instance: "i1", Location: "l1", Value: 1},
+		// nobody validates at minute 3
+		{Timestamp: minute(3), Instance: "i1", Location: "l1", Value: 0},
+		{Timestamp: minute(4), Instance: "i2", Location: "l2", Value: 1},
+	}
+
+	history := NewValidatorHistory(samples, helpers.DefaultValidatorMetricCheck())
+	require.Equal(t, []ValidatorHistoryEntry{
+		{Timestamp: minute(0), Instance: "i1", Location: "l1"},
+		{Timestamp: minute(1), Instance: "i1", Location: "l1"},
+		{Timestamp: minute(2), Instance: "i1", Location: "l1"},
+		{Timestamp: minute(2), Instance: "i2", Location: "l2"},
+		{Timestamp: minute(4), Instance: "i2", Location: "l2"},
+	}, history.Timeline)
+	require.Equal(t, 1, history.Overlaps)
+	require.Equal(t, 2, history.Transitions)
+
+	history = NewValidatorHistory(samples, helpers.ValidatorMetricCheck{Value: 2})
+	require.Empty(t, history.Timeline)
+	require.Zero(t, history.Transitions)
+	require.Zero(t, history.Overlaps)
+}
@@ -0,0 +1,50 @@
+package resource
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// IDVersion is the current resource ID format version
+	IDVersion = "v1"
+	// IDSeparator separates resource ID parts
+	IDSeparator = "/"
+)
+
+// FailoverID is a parsed resource ID. The ID format is v1/<prefix>/<failover mode>
+type FailoverID struct {
+	Version string
+	Prefix  string
+	Mode    FailOverMode
+}
+
+func (i FailoverID) String() string {
+	return JoinID(i.Prefix, string(i.Mode))
+}
+
+// JoinID builds versioned ID from parts
+func JoinID(parts ...string) string {
+	return strings.Join(append([]string{IDVersion}, parts...), IDSeparator)
+}
+
+// IsLegacyID checks whether ID was built with BsonPack by provider versions before the versioned ID format
+func IsLegacyID(id string) bool {
+	return !strings.HasPrefix(id, IDVersion+IDSeparator)
+}
+
+// ParseID parses versioned failover resource ID
+func ParseID(id string) (FailoverID, error) {
+	if IsLegacyID(id) {
+		return FailoverID{}, fmt.Errorf("unsupported resource ID format %q. Expected %s%s<prefix>%s<failover mode>", id, IDVersion, IDSeparator, IDSeparator)
+	}
+	parts := strings.Split(id, IDSeparator)
+	if len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+		return FailoverID{}, fmt.Errorf("cannot parse resource ID %q. Expected %s%s<prefix>%s<failover mode>", id, IDVersion, IDSeparator, IDSeparator)
+	}
+	return FailoverID{
+		Version: parts[0],
+		Prefix:  parts[1],
+		Mode:    FailOverMode(parts[2]),
+	}, nil
+}
@@ -0,0 +1,22 @@
+package resource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseID(t *testing.T) {
+	failoverID, err := ParseID("v1/test/single")
+	require.NoError(t, err)
+	require.Equal(t, FailoverID{Version: IDVersion, Prefix: "test", Mode: FailOverModeSingle}, failoverID)
+
+	_, err = ParseID("v1/test")
+	require.Error(t, err)
+
+	packed, err := BsonPack(Failover{Prefix: "test"})
+	require.NoError(t, err)
+	require.True(t, IsLegacyID(packed))
+	_, err = ParseID(packed)
+	require.Error(t, err)
+}
@@ -0,0 +1,151 @@
+package resource
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// ImportIDFormat describes polkadot_failover import ID. Instances count is required for every location, since instances
+// forces replacement and import cannot tell it from running instances
+const ImportIDFormat = "<prefix>/<failover mode>/<location>=<instances>,<location>=<instances>,.../<metric namespace>/<metric name>"
+
+// ImportIDProjectsFormat describes import ID of providers with per location projects. Project is optional for every location
+const ImportIDProjectsFormat = "<prefix>/<failover mode>/<location>=<instances>[@<project>],.../<metric namespace>/<metric name>"
+
+// ParseImportID parses import ID into failover
+func ParseImportID(id string) (Failover, error) {
+	f, projects, err := parseImportID(id, ImportIDFormat)
+	if err != nil {
+		return f, err
+	}
+	if len(projects) > 0 {
+		return f, fmt.Errorf("location projects are not supported in import ID %q. Expected %s", id, ImportIDFormat)
+	}
+	return f, nil
+}
+
+// ParseImportIDWithProjects parses import ID into failover and projects by location.
+// Locations without project are not in projects
+func ParseImportIDWithProjects(id string) (Failover, map[string]string, error) {
+	return parseImportID(id, ImportIDProjectsFormat)
+}
+
+// parseImportID parses import ID. format is the expected import ID format for errors
+func parseImportID(id, format string) (Failover, map[string]string, error) {
+
+	f := Failover{}
+	var projects map[string]string
+
+	parts := strings.Split(id, IDSeparator)
+	if len(parts) < 5 {
+		return f, nil, fmt.Errorf("cannot parse import ID %q. Expected %s", id, format)
+	}
+
+	f.Prefix = parts[0]
+	f.FailoverMode = FailOverMode(parts[1])
+	f.MetricNameSpace = parts[3]
+	// metric name might contain separator, i.e. validator/value
+	f.MetricName = strings.Join(parts[4:], IDSeparator)
+
+	if f.FailoverMode != FailOverModeSingle && f.FailoverMode != FailOverModeDistributed && f.FailoverMode != FailOverModeStandby {
+		return f, nil, fmt.Errorf("unsupported failover mode %q in import ID %q", f.FailoverMode, id)
+	}
+
+	for _, location := range strings.Split(parts[2], ",") {
+		project := ""
+		if idx := strings.Index(location, "@"); idx != -1 {
+			project = location[idx+1:]
+			location = location[:idx]
+			if project == "" {
+				return f, nil, fmt.Errorf("empty project for location %q in import ID %q", location, id)
+			}
+		}
+		idx := strings.Index(location, "=")
+		if idx == -1 {
+			return f, nil, fmt.Errorf("missing instances count for location %q in import ID %q. Expected %s", location, id, format)
+		}
+		count, err := strconv.Atoi(location[idx+1:])
+		if err != nil || count < 0 {
+			return f, nil, fmt.Errorf("cannot parse instances count for location %q in import ID %q", location, id)
+		}
+		location = location[:idx]
+		if location == "" {
+			return f, nil, fmt.Errorf("empty location in import ID %q. Expected %s", id, format)
+		}
+		if project != "" {
+			if projects == nil {
+				projects = map[string]string{}
+			}
+			projects[location] = project
+		}
+		f.Locations = append(f.Locations, location)
+		f.Instances = append(f.Instances, count)
+	}
+
+	if f.Prefix == "" || f.MetricNameSpace == "" || f.MetricName == "" {
+		return f, nil, fmt.Errorf("cannot parse import ID %q. Expected %s", id, format)
+	}
+
+	if err := ValidateLocations(len(f.Instances), len(f.Locations)); err != nil {
+		return f, nil, err
+	}
+
+	return f, projects, nil
+}
+
+// SetImportedCounts sets counts from running instances per location. Instances set in import ID are kept.
+// validatorLocation is index of the validator location or -1
+func (f *Failover) SetImportedCounts(running []int, validatorLocation int) {
+
+	if f.IsSingleMode() && validatorLocation >= 0 && validatorLocation < len(f.Locations) {
+		counts := make([]int, len(f.Locations))
+		counts[validatorLocation] = 1
+		f.SetCounts(counts...)
+		return
+	}
+
+	counts := make([]int, len(f.Locations))
+	copy(counts, running)
+	f.SetCounts(counts...)
+
+	if f.IsStandbyMode() {
+		// running instances besides the validator are standbys
+		f.StandbyCount = DefaultStandbyCount
+		if count := f.InstancesCount(); count > 0 {
+			f.StandbyCount = count - 1
+		}
+	}
+
+	f.FillDefaultCountsIfNotSet()
+}
+
+// SetConfigSchemaValues sets configuration attributes. It is used on import when only ID is known
+func (f Failover) SetConfigSchemaValues(d *schema.ResourceData) error {
+	if err := d.Set(PrefixFieldName, f.Prefix); err != nil {
+		return err
+	}
+	if err := d.Set(FailoverModeFieldName, string(f.FailoverMode)); err != nil {
+		return err
+	}
+	if err := d.Set(MetricNameFieldName, f.MetricName); err != nil {
+		return err
+	}
+	if err := d.Set(MetricNamespaceFieldName, f.MetricNameSpace); err != nil {
+		return err
+	}
+	if err := d.Set(InstancesFieldName, f.Instances); err != nil {
+		return err
+	}
+	if err := d.Set(LocationsFieldName, f.Locations); err != nil {
+		return err
+	}
+	if f.IsStandbyMode() {
+		if err := d.Set(StandbyCountFieldName, f.StandbyCount); err != nil {
+			return err
+		}
+	}
+	return f.SetSchemaValues(d)
+}
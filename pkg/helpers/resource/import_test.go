@@ -0,0 +1,83 @@
+package resource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseImportID(t *testing.T) {
+	failover, err := ParseImportID("test/single/us-east-1=1,us-east-2=2,us-west-1=0/polkadot/validator/value")
+	require.NoError(t, err)
+	require.Equal(t, "test", failover.Prefix)
+	require.Equal(t, FailOverModeSingle, failover.FailoverMode)
+	require.Equal(t, []string{"us-east-1", "us-east-2", "us-west-1"}, failover.Locations)
+	require.Equal(t, []int{1, 2, 0}, failover.Instances)
+	require.Equal(t, "polkadot", failover.MetricNameSpace)
+	require.Equal(t, "validator/value", failover.MetricName)
+
+	_, err = ParseImportID("test/single/us-east-1=1,us-east-2=1/polkadot/value")
+	require.Error(t, err)
+	_, err = ParseImportID("test/unknown/us-east-1=1,us-east-2=1,us-west-1=1/polkadot/value")
+	require.Error(t, err)
+	_, err = ParseImportID("test/single/us-east-1")
+	require.Error(t, err)
+	_, err = ParseImportID("test/single/us-east-1=1@project-1,us-east-2=1,us-west-1=1/polkadot/value")
+	require.Error(t, err)
+	// instances force replacement, so import does not guess them
+	_, err = ParseImportID("test/single/us-east-1,us-east-2=2,us-west-1=1/polkadot/value")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "missing instances count")
+}
+
+func TestParseImportIDWithProjects(t *testing.T) {
+	failover, projects, err := ParseImportIDWithProjects("test/single/us-east1=1@project-1,us-west1=2@project-2,europe-west1=1/polkadot/validator/value")
+	require.NoError(t, err)
+	require.Equal(t, []string{"us-east1", "us-west1", "europe-west1"}, failover.Locations)
+	require.Equal(t, []int{1, 2, 1}, failover.Instances)
+	require.Equal(t, map[string]string{"us-east1": "project-1", "us-west1": "project-2"}, projects)
+	require.Equal(t, "validator/value", failover.MetricName)
+
+	_, projects, err = ParseImportIDWithProjects("test/single/us-east1=1,us-west1=1,europe-west1=1/polkadot/value")
+	require.NoError(t, err)
+	require.Nil(t, projects)
+
+	_, _, err = ParseImportIDWithProjects("test/single/us-east1=1@,us-west1=1,europe-west1=1/polkadot/value")
+	require.Error(t, err)
+}
+
+func TestSetImportedCounts(t *testing.T) {
+	failover, err := ParseImportID("test/single/a=2,b=2,c=2/polkadot/value")
+	require.NoError(t, err)
+	failover.SetImportedCounts([]int{0, 0, 1}, 2)
+	// configured instances are kept, so plan after import does not replace the resource
+	require.Equal(t, []int{2, 2, 2}, failover.Instances)
+	require.Equal(t, []int{0, 0, 1}, failover.FailoverInstances)
+
+	failover, err = ParseImportID("test/single/a=1,b=1,c=1/polkadot/value")
+	require.NoError(t, err)
+	failover.SetImportedCounts([]int{0, 0, 0}, -1)
+	require.Equal(t, []int{1, 0, 0}, failover.FailoverInstances)
+
+	failover, err = ParseImportID("test/distributed/a=2,b=2,c=1/polkadot/value")
+	require.NoError(t, err)
+	failover.SetImportedCounts([]int{2, 1, 1}, 1)
+	require.Equal(t, []int{2, 2, 1}, failover.Instances)
+	require.Equal(t, []int{2, 1, 1}, failover.FailoverInstances)
+}
+
+func TestSetImportedCountsStandby(t *testing.T) {
+	f := Failover{
+		FailoverMode: FailOverModeStandby,
+		Instances:    []int{2, 2, 2},
+		Locations:    []string{"1", "2", "3"},
+	}
+	f.SetImportedCounts([]int{1, 1, 0}, 0)
+	require.Equal(t, []int{1, 1, 0}, f.FailoverInstances)
+	require.Equal(t, 1, f.StandbyCount)
+
+	f.Instances = []int{2, 2, 2}
+	f.SetImportedCounts([]int{0, 0, 0}, -1)
+	require.Equal(t, []int{1, 1, 1}, f.FailoverInstances)
+	require.Equal(t, DefaultStandbyCount, f.StandbyCount)
+}
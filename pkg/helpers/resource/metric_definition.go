@@ -0,0 +1,68 @@
+package resource
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/validate"
+)
+
+const MetricOutputNameFieldName = "metric_output_name"
+
+// MetricDefinition is the validator metric polkadot_metric_definition data source waits for
+type MetricDefinition struct {
+	Prefix           string
+	MetricName       string
+	MetricNameSpace  string
+	MetricOutputName string
+}
+
+func (m *MetricDefinition) FromSchema(d *schema.ResourceData) {
+	m.Prefix = d.Get(PrefixFieldName).(string)
+	m.MetricName = d.Get(MetricNameFieldName).(string)
+	m.MetricNameSpace = d.Get(MetricNamespaceFieldName).(string)
+}
+
+func (m MetricDefinition) ID(parts ...string) string {
+	return JoinID(append(parts, m.Prefix, m.MetricNameSpace, m.MetricName)...)
+}
+
+func (m MetricDefinition) SetSchemaValuesDiag(d *schema.ResourceData) diag.Diagnostics {
+	if err := d.Set(MetricOutputNameFieldName, m.MetricOutputName); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// GetMetricDefinitionSchema returns polkadot_metric_definition data source attributes shared by providers
+func GetMetricDefinitionSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+
+		MetricOutputNameFieldName: {
+			Type:        schema.TypeString,
+			Description: "Metric name found by the monitoring service",
+			Computed:    true,
+		},
+
+		MetricNameFieldName: {
+			Type:             schema.TypeString,
+			Required:         true,
+			ForceNew:         true,
+			ValidateDiagFunc: validate.DiagFunc(validation.StringIsNotEmpty),
+		},
+
+		PrefixFieldName: {
+			Type:             schema.TypeString,
+			Required:         true,
+			ForceNew:         true,
+			ValidateDiagFunc: validate.DiagFunc(validate.Prefix),
+		},
+
+		MetricNamespaceFieldName: {
+			Type:             schema.TypeString,
+			Required:         true,
+			ForceNew:         true,
+			ValidateDiagFunc: validate.DiagFunc(validation.StringIsNotEmpty),
+		},
+	}
+}
@@ -0,0 +1,233 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	helperErrors "github.com/protofire/polkadot-failover-mechanism/pkg/helpers/errors"
+)
+
+const (
+	PlannedValidatorFieldName = "planned_validator"
+	PlannedDeletionsFieldName = "planned_deletions"
+	PlannedStandbysFieldName  = "planned_standbys"
+	PlanWarningFieldName      = "plan_warning"
+	LocationFieldName         = "location"
+	PlannedInstancesFieldName = "instances"
+)
+
+// FailoverPlan describes instances single and standby mode failover keeps and deletes
+type FailoverPlan struct {
+	// Validator is the detected validator instance. Empty if validator has not been detected
+	Validator string
+	// Deletions are instances to delete per location. Positions are the same as in locations parameter
+	Deletions [][]string
+	// Standbys are instances kept as warm standbys in standby mode
+	Standbys []string
+	// MovedValidator is the live validator deleted because it runs outside of the pinned location
+	MovedValidator string
+	// Replacement is the instance in the pinned location kept to take over the moved validator
+	Replacement string
+	// Abort is set if apply fails because the validator has not been detected or runs outside of the pinned location
+	Abort AbortError
+	// ValidatorError is the validator detection error
+	ValidatorError error
+	// DryRun plans are executed without deleting instances
+	DryRun bool
+}
+
+// NewFailoverPlan creates plan for locations without deletions
+func NewFailoverPlan(locations int) FailoverPlan {
+	return FailoverPlan{Deletions: make([][]string, locations)}
+}
+
+// AddDeletion adds instance to delete in location with index locationIdx
+func (p *FailoverPlan) AddDeletion(locationIdx int, instance string) {
+	if locationIdx < 0 || locationIdx >= len(p.Deletions) {
+		return
+	}
+	p.Deletions[locationIdx] = append(p.Deletions[locationIdx], instance)
+}
+
+// DeletionsCount returns number of instances to delete
+func (p FailoverPlan) DeletionsCount() int {
+	s := 0
+	for _, instances := range p.Deletions {
+		s += len(instances)
+	}
+	return s
+}
+
+// Warning returns message for plans which delete instances without detected validator
+func (p FailoverPlan) Warning() string {
+	warning := p.warning()
+	if warning == "" || !p.DryRun {
+		return warning
+	}
+	return fmt.Sprintf("DRY RUN: %s. Nothing will be deleted", warning)
+}
+
+func (p FailoverPlan) warning() string {
+	if p.Abort != nil {
+		if p.DryRun {
+			return fmt.Sprintf("WARNING: %s", p.Abort)
+		}
+		return fmt.Sprintf("WARNING: %s. Apply will fail", p.Abort)
+	}
+	if p.Validator != "" || p.DeletionsCount() == 0 {
+		return ""
+	}
+	reason := "validator has not been detected"
+	validatorError := &helperErrors.ValidatorError{}
+	if p.MovedValidator != "" {
+		reason = fmt.Sprintf("validator %s runs outside of the pinned location", p.MovedValidator)
+	} else if errors.As(p.ValidatorError, validatorError) && validatorError.MultipleValidators() {
+		reason = "multiple validators have been detected"
+	}
+	if p.Replacement != "" {
+		return fmt.Sprintf("WARNING: %s. %d instances will be deleted, instance %s in the pinned location takes over", reason, p.DeletionsCount(), p.Replacement)
+	}
+	if len(p.Standbys) > 0 {
+		return fmt.Sprintf("WARNING: %s. %d instances will be deleted, %d standby instances will be kept", reason, p.DeletionsCount(), len(p.Standbys))
+	}
+	return fmt.Sprintf("WARNING: %s. All %d instances will be deleted", reason, p.DeletionsCount())
+}
+
+func (p FailoverPlan) standbys() []string {
+	if p.Standbys == nil {
+		return []string{}
+	}
+	return p.Standbys
+}
+
+func (p FailoverPlan) flatten(locations []string) []interface{} {
+	result := make([]interface{}, 0, len(locations))
+	for idx, location := range locations {
+		instances := []string{}
+		if idx < len(p.Deletions) && p.Deletions[idx] != nil {
+			instances = p.Deletions[idx]
+		}
+		result = append(result, map[string]interface{}{
+			LocationFieldName:         location,
+			PlannedInstancesFieldName: instances,
+		})
+	}
+	return result
+}
+
+// SetDiffValues sets plan computed attributes
+func (p FailoverPlan) SetDiffValues(diff *schema.ResourceDiff, locations []string) error {
+	if err := diff.SetNew(PlannedValidatorFieldName, p.Validator); err != nil {
+		return err
+	}
+	if err := diff.SetNew(PlannedDeletionsFieldName, p.flatten(locations)); err != nil {
+		return err
+	}
+	if err := diff.SetNew(PlannedStandbysFieldName, p.standbys()); err != nil {
+		return err
+	}
+	return diff.SetNew(PlanWarningFieldName, p.Warning())
+}
+
+// SetSchemaValues stores plan executed on apply
+func (p FailoverPlan) SetSchemaValues(d *schema.ResourceData, locations []string) error {
+	if err := d.Set(PlannedValidatorFieldName, p.Validator); err != nil {
+		return err
+	}
+	if err := d.Set(PlannedDeletionsFieldName, p.flatten(locations)); err != nil {
+		return err
+	}
+	if err := d.Set(PlannedStandbysFieldName, p.standbys()); err != nil {
+		return err
+	}
+	return d.Set(PlanWarningFieldName, p.Warning())
+}
+
+// PlanSchema returns computed attributes describing single and standby mode failover plan
+func PlanSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		PlannedValidatorFieldName: {
+			Type:        schema.TypeString,
+			Description: "Validator instance detected while planning single or standby mode failover",
+			Computed:    true,
+		},
+		PlannedDeletionsFieldName: {
+			Type:        schema.TypeList,
+			Description: "Instances single or standby mode failover deletes per location",
+			Computed:    true,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					LocationFieldName: {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+					PlannedInstancesFieldName: {
+						Type:     schema.TypeList,
+						Computed: true,
+						Elem:     &schema.Schema{Type: schema.TypeString},
+					},
+				},
+			},
+		},
+		PlannedStandbysFieldName: {
+			Type:        schema.TypeList,
+			Description: "Instances standby mode failover keeps as warm standbys",
+			Computed:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+		},
+		PlanWarningFieldName: {
+			Type:        schema.TypeString,
+			Description: "Warning for single or standby mode failover plans deleting instances without detected validator",
+			Computed:    true,
+		},
+	}
+}
+
+// PlanFunc discovers instances and validator for single and standby mode failover
+type PlanFunc func(ctx context.Context, diff *schema.ResourceDiff, meta interface{}) (FailoverPlan, []string, error)
+
+// CustomizeDiffPlan returns CustomizeDiff function putting single and standby mode failover plan into computed attributes.
+// Plan is calculated only for new resources or resources with changed configuration
+func CustomizeDiffPlan(plan PlanFunc) schema.CustomizeDiffFunc {
+	return func(ctx context.Context, diff *schema.ResourceDiff, meta interface{}) error {
+
+		mode := FailOverMode(diff.Get(FailoverModeFieldName).(string))
+		if mode != FailOverModeSingle && mode != FailOverModeStandby {
+			return nil
+		}
+
+		if diff.Id() != "" && len(diff.GetChangedKeysPrefix("")) == 0 {
+			return nil
+		}
+
+		for _, key := range []string{PrefixFieldName, LocationsFieldName, InstancesFieldName, MetricNameFieldName, MetricNamespaceFieldName, StandbyCountFieldName, PinLocationFieldName} {
+			if !diff.NewValueKnown(key) {
+				log.Printf("[DEBUG] failover: Plan. Value of %q is not known. Skipping failover plan", key)
+				return nil
+			}
+		}
+
+		failoverPlan, locations, err := plan(ctx, diff, meta)
+		if err != nil {
+			return err
+		}
+
+		if dryRun, ok := diff.Get(DryRunFieldName).(bool); ok {
+			failoverPlan.DryRun = dryRun
+		}
+
+		if warning := failoverPlan.Warning(); warning != "" {
+			log.Printf("[WARN] failover: Plan. %s", warning)
+		}
+
+		return failoverPlan.SetDiffValues(diff, locations)
+	}
+}
+
+// FromResourceDiff reads failover from planned values
+func (f *Failover) FromResourceDiff(diff *schema.ResourceDiff) error {
+	return f.fromGetter(diff)
+}
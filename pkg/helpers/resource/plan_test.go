@@ -0,0 +1,61 @@
+package resource
+
+import (
+	"fmt"
+	"testing"
+
+	helperErrors "github.com/protofire/polkadot-failover-mechanism/pkg/helpers/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailoverPlanWarning(t *testing.T) {
+	plan := NewFailoverPlan(3)
+	plan.AddDeletion(0, "instance-1")
+	plan.AddDeletion(2, "instance-2")
+	plan.AddDeletion(3, "instance-3")
+
+	require.Equal(t, 2, plan.DeletionsCount())
+	require.Equal(t, "WARNING: validator has not been detected. All 2 instances will be deleted", plan.Warning())
+
+	plan.ValidatorError = fmt.Errorf("wrapped: %w", helperErrors.NewValidatorError("validators", helperErrors.ValidatorErrorMultiple))
+	require.Equal(t, "WARNING: multiple validators have been detected. All 2 instances will be deleted", plan.Warning())
+
+	plan.MovedValidator = "instance-0"
+	require.Equal(t, "WARNING: validator instance-0 runs outside of the pinned location. All 2 instances will be deleted", plan.Warning())
+
+	plan.DryRun = true
+	require.Equal(t, "DRY RUN: WARNING: validator instance-0 runs outside of the pinned location. All 2 instances will be deleted. Nothing will be deleted", plan.Warning())
+
+	plan.DryRun = false
+	plan.Replacement = "instance-2"
+	require.Equal(t, "WARNING: validator instance-0 runs outside of the pinned location. 2 instances will be deleted, instance instance-2 in the pinned location takes over", plan.Warning())
+
+	plan.Validator = "instance-0"
+	require.Empty(t, plan.Warning())
+}
+
+func TestFailoverPlanWarningDryRunAbort(t *testing.T) {
+	plan := NewFailoverPlan(1)
+	plan.Abort = &ValidatorUnknownError{
+		Err:             helperErrors.NewValidatorError("no validators", helperErrors.ValidatorErrorNotFound),
+		Deletions:       2,
+		MetricNamespace: "polkadot",
+		MetricName:      "validator",
+	}
+	require.Contains(t, plan.Warning(), "Apply will fail")
+
+	plan.DryRun = true
+	require.NotContains(t, plan.Warning(), "Apply will fail")
+	require.Contains(t, plan.Warning(), "Nothing will be deleted")
+}
+
+func TestFailoverPlanFlatten(t *testing.T) {
+	plan := NewFailoverPlan(3)
+	plan.AddDeletion(1, "instance-1")
+
+	require.Equal(t, []interface{}{
+		map[string]interface{}{LocationFieldName: "1", PlannedInstancesFieldName: []string{}},
+		map[string]interface{}{LocationFieldName: "2", PlannedInstancesFieldName: []string{"instance-1"}},
+		map[string]interface{}{LocationFieldName: "3", PlannedInstancesFieldName: []string{}},
+	}, plan.flatten([]string{"1", "2", "3"}))
+}
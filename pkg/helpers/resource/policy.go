@@ -0,0 +1,254 @@
+package resource
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	helperErrors "github.com/protofire/polkadot-failover-mechanism/pkg/helpers/errors"
+)
+
+// ValidatorUnknownPolicy enumerates actions taken when failover cannot detect the validator
+type ValidatorUnknownPolicy string
+
+const (
+	// ValidatorUnknownAbort fails apply without deleting instances
+	ValidatorUnknownAbort ValidatorUnknownPolicy = "abort"
+	// ValidatorUnknownDeleteAll deletes all instances
+	ValidatorUnknownDeleteAll ValidatorUnknownPolicy = "delete_all"
+	// ValidatorUnknownKeepAll keeps all running instances
+	ValidatorUnknownKeepAll ValidatorUnknownPolicy = "keep_all"
+
+	OnValidatorUnknownFieldName = "on_validator_unknown"
+)
+
+// ValidatorUnknownError is returned if failover is aborted because the validator has not been detected
+type ValidatorUnknownError struct {
+	Err             error
+	Deletions       int
+	MetricNamespace string
+	MetricName      string
+}
+
+func (e ValidatorUnknownError) reason() string {
+	validatorError := &helperErrors.ValidatorError{}
+	if errors.As(e.Err, validatorError) && validatorError.MultipleValidators() {
+		return "multiple validators have been detected"
+	}
+	if e.conflict() {
+		return "validator sources detected different validators"
+	}
+	return "validator has not been detected"
+}
+
+func (e ValidatorUnknownError) metricValues() string {
+	validatorError := &helperErrors.ValidatorError{}
+	if errors.As(e.Err, validatorError) {
+		return validatorError.MetricValues()
+	}
+	return "<unknown>"
+}
+
+func (e ValidatorUnknownError) Error() string {
+	return fmt.Sprintf(
+		"%s. Failover is aborted to avoid deleting %d instances. Metric %s/%s values: %s",
+		e.reason(),
+		e.Deletions,
+		e.MetricNamespace,
+		e.MetricName,
+		e.metricValues(),
+	)
+}
+
+func (e ValidatorUnknownError) Unwrap() error {
+	return e.Err
+}
+
+func (e ValidatorUnknownError) conflict() bool {
+	validatorError := &helperErrors.ValidatorError{}
+	return errors.As(e.Err, validatorError) && validatorError.Conflict()
+}
+
+// Diagnostics returns error diagnostics describing how to proceed
+func (e ValidatorUnknownError) Diagnostics() diag.Diagnostics {
+	if e.conflict() {
+		return diag.Diagnostics{
+			{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("failover: %s. %d instances would be deleted", e.reason(), e.Deletions),
+				Detail: fmt.Sprintf(
+					"%s.\nMetric %s/%s values per instance: %s.\n"+
+						"Failover does not act until validator sources detect the same validator, %s is not applied.",
+					e.Err,
+					e.MetricNamespace,
+					e.MetricName,
+					e.metricValues(),
+					OnValidatorUnknownFieldName,
+				),
+			},
+		}
+	}
+	return diag.Diagnostics{
+		{
+			Severity: diag.Error,
+			Summary:  fmt.Sprintf("failover: %s. %d instances would be deleted", e.reason(), e.Deletions),
+			Detail: fmt.Sprintf(
+				"Metric %s/%s values per instance: %s.\n"+
+					"Check that the validator reports the metric and retry. "+
+					"Set %s to %q to delete all instances or to %q to keep all running instances.",
+				e.MetricNamespace,
+				e.MetricName,
+				e.metricValues(),
+				OnValidatorUnknownFieldName,
+				ValidatorUnknownDeleteAll,
+				ValidatorUnknownKeepAll,
+			),
+		},
+	}
+}
+
+// ApplyValidatorUnknownPolicy decides what to do with instances which would be deleted because the validator
+// has not been detected. validatorErr is the validator detection error. It returns true if all instances
+// should be kept and ValidatorUnknownError if failover should be aborted
+func (f Failover) ApplyValidatorUnknownPolicy(validatorErr error, deletions int) (bool, *ValidatorUnknownError) {
+
+	if validatorErr == nil || deletions == 0 {
+		return false, nil
+	}
+
+	// validator sources disagree on the validator. Failover never acts on conflicting sources
+	validatorError := &helperErrors.ValidatorError{}
+	if errors.As(validatorErr, validatorError) && validatorError.Conflict() {
+		return false, f.validatorUnknownError(validatorErr, deletions)
+	}
+
+	switch f.OnValidatorUnknown {
+	case ValidatorUnknownDeleteAll:
+		return false, nil
+	case ValidatorUnknownKeepAll:
+		return true, nil
+	default:
+		return false, f.validatorUnknownError(validatorErr, deletions)
+	}
+}
+
+func (f Failover) validatorUnknownError(validatorErr error, deletions int) *ValidatorUnknownError {
+	return &ValidatorUnknownError{
+		Err:             validatorErr,
+		Deletions:       deletions,
+		MetricNamespace: f.MetricNameSpace,
+		MetricName:      f.MetricName,
+	}
+}
+
+// PinnedValidatorPolicy enumerates actions taken when the live validator runs outside of the pinned location
+type PinnedValidatorPolicy string
+
+const (
+	// PinnedValidatorAbort fails apply without deleting instances
+	PinnedValidatorAbort PinnedValidatorPolicy = "abort"
+	// PinnedValidatorMove deletes the validator. An instance running in the pinned location is kept to take over
+	PinnedValidatorMove PinnedValidatorPolicy = "move"
+
+	OnValidatorOutsidePinFieldName = "on_validator_outside_pin"
+)
+
+// AbortError is returned if a failover policy aborts failover without deleting instances
+type AbortError interface {
+	error
+	// Diagnostics returns error diagnostics describing how to proceed
+	Diagnostics() diag.Diagnostics
+}
+
+// ValidatorOutsidePinError is returned if failover is aborted because the validator runs outside of the pinned location
+type ValidatorOutsidePinError struct {
+	Validator   string
+	Location    string
+	PinLocation string
+	Deletions   int
+	// NoReplacement is set if the validator cannot be moved because no instance runs in the pinned location
+	NoReplacement bool
+}
+
+func (e ValidatorOutsidePinError) Error() string {
+	if e.NoReplacement {
+		return fmt.Sprintf(
+			"validator %s runs in location %q outside of the pinned location %q, which has no instance to take over. Failover is aborted to avoid deleting %d instances including the validator",
+			e.Validator,
+			e.Location,
+			e.PinLocation,
+			e.Deletions,
+		)
+	}
+	return fmt.Sprintf(
+		"validator %s runs in location %q outside of the pinned location %q. Failover is aborted to avoid deleting %d instances including the validator",
+		e.Validator,
+		e.Location,
+		e.PinLocation,
+		e.Deletions,
+	)
+}
+
+// Diagnostics returns error diagnostics describing how to proceed
+func (e ValidatorOutsidePinError) Diagnostics() diag.Diagnostics {
+	if e.NoReplacement {
+		return diag.Diagnostics{
+			{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("failover: validator %s runs outside of the pinned location %q, which has no running instances", e.Validator, e.PinLocation),
+				Detail: fmt.Sprintf(
+					"Validator %s runs in location %q.\n"+
+						"Start an instance in the pinned location %q so that it can take over, or change %s.",
+					e.Validator,
+					e.Location,
+					e.PinLocation,
+					PinLocationFieldName,
+				),
+			},
+		}
+	}
+	return diag.Diagnostics{
+		{
+			Severity: diag.Error,
+			Summary:  fmt.Sprintf("failover: validator %s runs outside of the pinned location %q. %d instances would be deleted", e.Validator, e.PinLocation, e.Deletions),
+			Detail: fmt.Sprintf(
+				"Validator %s runs in location %q.\n"+
+					"Set %s to %q to delete it and let an instance in the pinned location take over, or change %s.",
+				e.Validator,
+				e.Location,
+				OnValidatorOutsidePinFieldName,
+				PinnedValidatorMove,
+				PinLocationFieldName,
+			),
+		},
+	}
+}
+
+// ApplyPinnedValidatorPolicy decides what to do with the live validator running outside of the pinned location.
+// validator is the validator instance in location with index detected, replacement is the instance in the pinned location
+// taking over and deletions is number of instances moving it deletes.
+// It returns ValidatorOutsidePinError if failover should be aborted. Moving is aborted too if there is no replacement
+func (f Failover) ApplyPinnedValidatorPolicy(validator string, detected int, replacement string, deletions int) *ValidatorOutsidePinError {
+
+	if validator == "" || f.KeepsValidator(detected) {
+		return nil
+	}
+
+	if f.OnValidatorOutsidePin == PinnedValidatorMove && replacement != "" {
+		return nil
+	}
+
+	location := ""
+	if detected >= 0 && detected < len(f.Locations) {
+		location = f.Locations[detected]
+	}
+
+	return &ValidatorOutsidePinError{
+		Validator:   validator,
+		Location:    location,
+		PinLocation: f.PinLocation,
+		Deletions:   deletions,
+		// abort policy does not need a replacement
+		NoReplacement: f.OnValidatorOutsidePin == PinnedValidatorMove,
+	}
+}
@@ -0,0 +1,108 @@
+package resource
+
+import (
+	"testing"
+
+	helperErrors "github.com/protofire/polkadot-failover-mechanism/pkg/helpers/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyValidatorUnknownPolicy(t *testing.T) {
+	validatorErr := helperErrors.NewValidatorError("cannot find validators", helperErrors.ValidatorErrorNotFound).WithValues(
+		[]helperErrors.MetricValue{
+			{Instance: "instance-1", Value: 0},
+			{Instance: "instance-2", Value: 0.5},
+			{Instance: "instance-3", Missing: true},
+		},
+	)
+
+	f := Failover{MetricNameSpace: "polkadot", MetricName: "validator/value"}
+
+	keepAll, abort := f.ApplyValidatorUnknownPolicy(nil, 3)
+	require.False(t, keepAll)
+	require.Nil(t, abort)
+
+	keepAll, abort = f.ApplyValidatorUnknownPolicy(validatorErr, 0)
+	require.False(t, keepAll)
+	require.Nil(t, abort)
+
+	keepAll, abort = f.ApplyValidatorUnknownPolicy(validatorErr, 3)
+	require.False(t, keepAll)
+	require.NotNil(t, abort)
+	require.Equal(
+		t,
+		"validator has not been detected. Failover is aborted to avoid deleting 3 instances. "+
+			"Metric polkadot/validator/value values: instance-1=0, instance-2=0.5, instance-3=<no data>",
+		abort.Error(),
+	)
+	diags := abort.Diagnostics()
+	require.True(t, diags.HasError())
+	require.Contains(t, diags[0].Detail, "instance-3=<no data>")
+	require.Contains(t, diags[0].Detail, OnValidatorUnknownFieldName)
+
+	f.OnValidatorUnknown = ValidatorUnknownKeepAll
+	keepAll, abort = f.ApplyValidatorUnknownPolicy(validatorErr, 3)
+	require.True(t, keepAll)
+	require.Nil(t, abort)
+
+	f.OnValidatorUnknown = ValidatorUnknownDeleteAll
+	keepAll, abort = f.ApplyValidatorUnknownPolicy(validatorErr, 3)
+	require.False(t, keepAll)
+	require.Nil(t, abort)
+}
+
+func TestApplyValidatorUnknownPolicyConflict(t *testing.T) {
+	validatorErr := helperErrors.NewValidatorError(
+		"validator sources detected different validators \"instance-1\" and \"instance-2\"",
+		helperErrors.ValidatorErrorConflict,
+	)
+
+	f := Failover{MetricNameSpace: "polkadot", MetricName: "validator/value"}
+
+	for _, policy := range []ValidatorUnknownPolicy{ValidatorUnknownAbort, ValidatorUnknownDeleteAll, ValidatorUnknownKeepAll} {
+		f.OnValidatorUnknown = policy
+		keepAll, abort := f.ApplyValidatorUnknownPolicy(validatorErr, 3)
+		require.False(t, keepAll)
+		require.NotNil(t, abort)
+		require.Contains(t, abort.Error(), "validator sources detected different validators")
+		diags := abort.Diagnostics()
+		require.True(t, diags.HasError())
+		require.Contains(t, diags[0].Detail, "instance-2")
+	}
+}
+
+func TestApplyPinnedValidatorPolicy(t *testing.T) {
+	f := Failover{
+		FailoverMode: FailOverModeSingle,
+		Locations:    []string{"1", "2", "3"},
+		PinLocation:  "3",
+	}
+
+	require.Nil(t, f.ApplyPinnedValidatorPolicy("", -1, "", 6))
+	require.Nil(t, f.ApplyPinnedValidatorPolicy("instance-5", 2, "", 5))
+
+	abort := f.ApplyPinnedValidatorPolicy("instance-1", 0, "instance-5", 5)
+	require.NotNil(t, abort)
+	require.Equal(
+		t,
+		"validator instance-1 runs in location \"1\" outside of the pinned location \"3\". Failover is aborted to avoid deleting 5 instances including the validator",
+		abort.Error(),
+	)
+	diags := abort.Diagnostics()
+	require.True(t, diags.HasError())
+	require.Contains(t, diags[0].Detail, OnValidatorOutsidePinFieldName)
+
+	f.OnValidatorOutsidePin = PinnedValidatorMove
+	require.Nil(t, f.ApplyPinnedValidatorPolicy("instance-1", 0, "instance-5", 5))
+
+	// validator is not moved if no instance in the pinned location takes over
+	abort = f.ApplyPinnedValidatorPolicy("instance-1", 0, "", 6)
+	require.NotNil(t, abort)
+	require.True(t, abort.NoReplacement)
+	require.Equal(
+		t,
+		"validator instance-1 runs in location \"1\" outside of the pinned location \"3\", which has no instance to take over. Failover is aborted to avoid deleting 6 instances including the validator",
+		abort.Error(),
+	)
+	require.Contains(t, abort.Diagnostics()[0].Detail, PinLocationFieldName)
+}
@@ -1,8 +1,12 @@
 package resource
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
 	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/failover/tags"
 	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/validate"
 )
@@ -17,7 +21,6 @@ func GetPolkadotSchema() map[string]*schema.Schema {
 			Type:     schema.TypeList,
 			Required: true,
 			ForceNew: true,
-			MaxItems: 3,
 			MinItems: 3,
 			Elem: &schema.Schema{
 				Type: schema.TypeInt,
@@ -28,7 +31,6 @@ func GetPolkadotSchema() map[string]*schema.Schema {
 			Type:     schema.TypeList,
 			Required: true,
 			ForceNew: true,
-			MaxItems: 3,
 			MinItems: 3,
 			Elem: &schema.Schema{
 				Type: schema.TypeString,
@@ -63,12 +65,130 @@ func GetPolkadotSchema() map[string]*schema.Schema {
 			ValidateDiagFunc: validate.DiagFunc(validation.StringInSlice([]string{
 				string(FailOverModeDistributed),
 				string(FailOverModeSingle),
+				string(FailOverModeStandby),
+			}, false)),
+		},
+
+		StandbyCountFieldName: {
+			Type:             schema.TypeInt,
+			Description:      "Number of warm standby instances kept besides the validator in standby mode. Standbys are placed in other locations than the validator",
+			Optional:         true,
+			Default:          DefaultStandbyCount,
+			ValidateDiagFunc: validate.DiagFunc(validation.IntAtLeast(0)),
+			DiffSuppressFunc: func(_, _, _ string, d *schema.ResourceData) bool {
+				return FailOverMode(d.Get(FailoverModeFieldName).(string)) != FailOverModeStandby
+			},
+		},
+
+		PreferredLocationsFieldName: {
+			Type:        schema.TypeList,
+			Description: "Locations in preference order to place the validator in single mode if the validator is not running",
+			Optional:    true,
+			Elem: &schema.Schema{
+				Type: schema.TypeString,
+			},
+		},
+
+		PinLocationFieldName: {
+			Type:        schema.TypeString,
+			Description: "Location of the validator in single mode. The validator running in other location is moved into this location if on_validator_outside_pin allows it",
+			Optional:    true,
+		},
+
+		OnValidatorOutsidePinFieldName: {
+			Type:        schema.TypeString,
+			Description: "Action in single mode if the validator runs outside of pin_location. abort fails apply, move deletes the validator keeping an instance in the pinned location to take over. move fails apply too if no instance runs in the pinned location",
+			Optional:    true,
+			Default:     string(PinnedValidatorAbort),
+			ValidateDiagFunc: validate.DiagFunc(validation.StringInSlice([]string{
+				string(PinnedValidatorAbort),
+				string(PinnedValidatorMove),
+			}, false)),
+		},
+
+		OnValidatorUnknownFieldName: {
+			Type:        schema.TypeString,
+			Description: "Action in single and standby modes if the validator has not been detected and instances would be deleted. One of abort, delete_all or keep_all",
+			Optional:    true,
+			Default:     string(ValidatorUnknownAbort),
+			ValidateDiagFunc: validate.DiagFunc(validation.StringInSlice([]string{
+				string(ValidatorUnknownAbort),
+				string(ValidatorUnknownDeleteAll),
+				string(ValidatorUnknownKeepAll),
 			}, false)),
 		},
 
+		ValidatorDetectionFieldName: {
+			Type:        schema.TypeString,
+			Description: "Validator detection source. metrics uses cloud monitoring, rpc calls system_nodeRoles on instances, both requires them to agree",
+			Optional:    true,
+			Default:     string(ValidatorDetectionMetrics),
+			ValidateDiagFunc: validate.DiagFunc(validation.StringInSlice([]string{
+				string(ValidatorDetectionMetrics),
+				string(ValidatorDetectionRPC),
+				string(ValidatorDetectionBoth),
+			}, false)),
+		},
+
+		RPCPortFieldName: {
+			Type:             schema.TypeInt,
+			Description:      "Polkadot JSON-RPC HTTP port of instances used by rpc validator detection",
+			Optional:         true,
+			Default:          DefaultRPCPort,
+			ValidateDiagFunc: validate.DiagFunc(validation.IsPortNumber),
+		},
+
+		MetricWindowFieldName: {
+			Type:             schema.TypeInt,
+			Description:      "Validator metric query window in seconds. The latest metric sample in the window is used",
+			Optional:         true,
+			Default:          int(helpers.DefaultMetricWindow.Seconds()),
+			ValidateDiagFunc: validate.DiagFunc(validation.IntAtLeast(60)),
+		},
+
+		MetricMaxAgeFieldName: {
+			Type:             schema.TypeInt,
+			Description:      "Validator metric samples older than max age in seconds are unknown rather than validator. 0 disables the check",
+			Optional:         true,
+			Default:          int(helpers.DefaultMetricMaxAge.Seconds()),
+			ValidateDiagFunc: validate.DiagFunc(validation.IntAtLeast(0)),
+		},
+
+		MetricAggregationFieldName: {
+			Type:             schema.TypeString,
+			Description:      "Statistic validator metric samples are aggregated with. One of maximum, minimum, average or sum",
+			Optional:         true,
+			Default:          string(helpers.MetricAggregationMaximum),
+			ValidateDiagFunc: validate.DiagFunc(validation.StringInSlice(helpers.MetricAggregations(), false)),
+		},
+
+		ValidatorMetricValueFieldName: {
+			Type:             schema.TypeInt,
+			Description:      "Aggregated validator metric value reported by the validator instance",
+			Optional:         true,
+			Default:          helpers.DefaultValidatorMetricValue,
+			ValidateDiagFunc: validate.DiagFunc(validation.IntAtLeast(1)),
+		},
+
+		ConsulAddressFieldName: {
+			Type:             schema.TypeString,
+			Description:      "Consul HTTP API address, e.g. http://10.0.0.10:8500. The detected validator is cross-checked against the Consul lock holder if it is set",
+			Optional:         true,
+			ValidateDiagFunc: validate.DiagFunc(validation.IsURLWithHTTPorHTTPS),
+		},
+
+		ConsulLockKeyFieldName: {
+			Type:             schema.TypeString,
+			Description:      "Consul KV key of the validator lock",
+			Optional:         true,
+			Default:          DefaultConsulLockKey,
+			ValidateDiagFunc: validate.DiagFunc(validation.StringIsNotEmpty),
+		},
+
 		FailoverInstancesFieldName: {
-			Type:     schema.TypeList,
-			Computed: true,
+			Type:        schema.TypeList,
+			Description: "Polkadot nodes count per location. Counts are in the same order as locations parameter",
+			Computed:    true,
 			Elem: &schema.Schema{
 				Type: schema.TypeInt,
 			},
@@ -93,3 +213,103 @@ func GetPolkadotSchema() map[string]*schema.Schema {
 		},
 	}
 }
+
+// DryRunSchema returns polkadot_failover resource attributes running failover without deleting instances
+func DryRunSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		DryRunFieldName: {
+			Type:        schema.TypeBool,
+			Description: "Run discovery, validator detection and counts calculation in single and standby modes without deleting instances",
+			Optional:    true,
+			Default:     false,
+		},
+
+		DryRunInstancesFieldName: {
+			Type:        schema.TypeList,
+			Description: "Polkadot nodes count per location calculated by the last dry run",
+			Computed:    true,
+			Elem: &schema.Schema{
+				Type: schema.TypeInt,
+			},
+		},
+	}
+}
+
+// GetPolkadotResourceSchema returns polkadot_failover resource schema with computed failover plan attributes
+func GetPolkadotResourceSchema() map[string]*schema.Schema {
+	polkadotSchema := GetPolkadotSchema()
+	for name, value := range PlanSchema() {
+		polkadotSchema[name] = value
+	}
+	for name, value := range DryRunSchema() {
+		polkadotSchema[name] = value
+	}
+	for name, value := range ValidatorSchema() {
+		polkadotSchema[name] = value
+	}
+	return polkadotSchema
+}
+
+// GetPolkadotDataSourceSchema returns polkadot_failover data source schema with the detected validator attributes
+func GetPolkadotDataSourceSchema() map[string]*schema.Schema {
+	polkadotSchema := GetPolkadotSchema()
+	for name, value := range ValidatorSchema() {
+		polkadotSchema[name] = value
+	}
+	return polkadotSchema
+}
+
+// CustomizeDiff validates that instances and locations describe the same odd number of locations,
+// validator metric check and that validator placement refers to these locations
+func CustomizeDiff(_ context.Context, diff *schema.ResourceDiff, _ interface{}) error {
+	if !diff.NewValueKnown(InstancesFieldName) || !diff.NewValueKnown(LocationsFieldName) {
+		return nil
+	}
+	instances := diff.Get(InstancesFieldName).([]interface{})
+	locations := diff.Get(LocationsFieldName).([]interface{})
+	if err := ValidateLocations(len(instances), len(locations)); err != nil {
+		return err
+	}
+	if diff.NewValueKnown(MetricAggregationFieldName) && diff.NewValueKnown(ValidatorMetricValueFieldName) {
+		if err := ValidateValidatorMetric(diff.Get(MetricAggregationFieldName).(string), diff.Get(ValidatorMetricValueFieldName).(int)); err != nil {
+			return err
+		}
+	}
+	if !diff.NewValueKnown(PreferredLocationsFieldName) || !diff.NewValueKnown(PinLocationFieldName) {
+		return nil
+	}
+	return ValidatePlacement(
+		ExpandString(locations),
+		ExpandString(diff.Get(PreferredLocationsFieldName).([]interface{})),
+		diff.Get(PinLocationFieldName).(string),
+	)
+}
+
+// ValidatePlacement checks that preferred and pinned locations are in locations list
+func ValidatePlacement(locations, preferred []string, pin string) error {
+	seen := make(map[string]bool, len(preferred))
+	for _, location := range preferred {
+		if helpers.FindStrIndex(location, locations) == -1 {
+			return fmt.Errorf("%q contains location %q which is not in %q", PreferredLocationsFieldName, location, LocationsFieldName)
+		}
+		if seen[location] {
+			return fmt.Errorf("%q contains location %q more than once", PreferredLocationsFieldName, location)
+		}
+		seen[location] = true
+	}
+	if pin != "" && helpers.FindStrIndex(pin, locations) == -1 {
+		return fmt.Errorf("%q %q is not in %q", PinLocationFieldName, pin, LocationsFieldName)
+	}
+	return nil
+}
+
+// ValidateLocations checks instances and locations lists lengths
+func ValidateLocations(instances, locations int) error {
+	if instances != locations {
+		return fmt.Errorf("%q has %d items but %q has %d items. Lists should have the same length", InstancesFieldName, instances, LocationsFieldName, locations)
+	}
+	if locations%2 == 0 {
+		return fmt.Errorf("%q should contain an odd number of items to keep consul quorum, got %d", LocationsFieldName, locations)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package resource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateLocations(t *testing.T) {
+	require.NoError(t, ValidateLocations(3, 3))
+	require.NoError(t, ValidateLocations(5, 5))
+	require.Error(t, ValidateLocations(3, 5))
+	require.Error(t, ValidateLocations(4, 4))
+}
+
+func TestValidatePlacement(t *testing.T) {
+	locations := []string{"1", "2", "3"}
+	require.NoError(t, ValidatePlacement(locations, nil, ""))
+	require.NoError(t, ValidatePlacement(locations, []string{"3", "1"}, "2"))
+	require.Error(t, ValidatePlacement(locations, []string{"4"}, ""))
+	require.Error(t, ValidatePlacement(locations, []string{"1", "1"}, ""))
+	require.Error(t, ValidatePlacement(locations, nil, "4"))
+}
+
+func TestValidateValidatorMetric(t *testing.T) {
+	require.NoError(t, ValidateValidatorMetric("maximum", 1))
+	require.NoError(t, ValidateValidatorMetric("average", 3))
+	require.Error(t, ValidateValidatorMetric("max", 1))
+	require.Error(t, ValidateValidatorMetric("maximum", 0))
+}
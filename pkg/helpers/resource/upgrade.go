@@ -0,0 +1,105 @@
+package resource
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/failover/tags"
+)
+
+// SchemaVersion is the current polkadot_failover schema version
+const SchemaVersion = 1
+
+// LegacyUnPack restores failover and provider specific attributes from ID packed with BsonPack
+type LegacyUnPack func(id string) (Failover, map[string]interface{}, error)
+
+// ResourceV0 returns polkadot_failover resource with schema version 0. It is used only to decode states
+// stored with packed IDs and must not be changed
+func ResourceV0(extra map[string]*schema.Schema) *schema.Resource {
+
+	intList := &schema.Schema{
+		Type:     schema.TypeList,
+		Required: true,
+		Elem:     &schema.Schema{Type: schema.TypeInt},
+	}
+	stringList := &schema.Schema{
+		Type:     schema.TypeList,
+		Required: true,
+		Elem:     &schema.Schema{Type: schema.TypeString},
+	}
+	computedInt := &schema.Schema{Type: schema.TypeInt, Computed: true}
+
+	polkadotSchema := map[string]*schema.Schema{
+		TagsFieldName:            tags.Schema(),
+		InstancesFieldName:       intList,
+		LocationsFieldName:       stringList,
+		PrefixFieldName:          {Type: schema.TypeString, Required: true},
+		MetricNameFieldName:      {Type: schema.TypeString, Required: true},
+		MetricNamespaceFieldName: {Type: schema.TypeString, Required: true},
+		FailoverModeFieldName:    {Type: schema.TypeString, Required: true},
+		FailoverInstancesFieldName: {
+			Type:     schema.TypeList,
+			Computed: true,
+			Elem:     &schema.Schema{Type: schema.TypeInt},
+		},
+		PrimaryCountFieldName:   computedInt,
+		SecondaryCountFieldName: computedInt,
+		TertiaryCountFieldName:  computedInt,
+	}
+
+	for name, value := range extra {
+		polkadotSchema[name] = value
+	}
+
+	return &schema.Resource{Schema: polkadotSchema}
+}
+
+// UpgradeStateV0 replaces ID packed with BsonPack with versioned ID.
+// Attributes absent in the state are restored from the packed ID
+func UpgradeStateV0(rawState map[string]interface{}, unpack LegacyUnPack) (map[string]interface{}, error) {
+
+	if rawState == nil {
+		return rawState, nil
+	}
+
+	id, _ := rawState["id"].(string)
+
+	if id == "" || !IsLegacyID(id) {
+		return rawState, nil
+	}
+
+	failover, extra, err := unpack(id)
+	if err != nil {
+		return nil, fmt.Errorf("cannot unpack legacy resource ID: %w", err)
+	}
+
+	setIfEmpty := func(name string, value interface{}) {
+		current, ok := rawState[name]
+		if !ok || current == nil || current == "" {
+			rawState[name] = value
+			return
+		}
+		if list, ok := current.([]interface{}); ok && len(list) == 0 {
+			rawState[name] = value
+		}
+	}
+
+	setIfEmpty(PrefixFieldName, failover.Prefix)
+	setIfEmpty(FailoverModeFieldName, string(failover.FailoverMode))
+	setIfEmpty(MetricNameFieldName, failover.MetricName)
+	setIfEmpty(MetricNamespaceFieldName, failover.MetricNameSpace)
+	setIfEmpty(InstancesFieldName, failover.Instances)
+	setIfEmpty(LocationsFieldName, failover.Locations)
+	setIfEmpty(FailoverInstancesFieldName, failover.FailoverInstances)
+
+	for name, value := range extra {
+		setIfEmpty(name, value)
+	}
+
+	prefix, _ := rawState[PrefixFieldName].(string)
+	mode, _ := rawState[FailoverModeFieldName].(string)
+
+	rawState["id"] = FailoverID{Prefix: prefix, Mode: FailOverMode(mode)}.String()
+
+	return rawState, nil
+}
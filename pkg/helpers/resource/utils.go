@@ -25,6 +25,22 @@ func BsonUnPack(failover interface{}, data string) error {
 	return bson.Unmarshal(res, failover)
 }
 
+// BsonUnPackLegacyCounts reads primary, secondary and tertiary counts from packed failover
+// created before counts were stored as a list
+func BsonUnPackLegacyCounts(data string) ([]int, error) {
+	legacy := struct {
+		Failover struct {
+			PrimaryCount   int
+			SecondaryCount int
+			TertiaryCount  int
+		}
+	}{}
+	if err := BsonUnPack(&legacy, data); err != nil {
+		return nil, err
+	}
+	return []int{legacy.Failover.PrimaryCount, legacy.Failover.SecondaryCount, legacy.Failover.TertiaryCount}, nil
+}
+
 func ExpandInt(values []interface{}) []int {
 	results := make([]int, 0, len(values))
 	for _, value := range values {
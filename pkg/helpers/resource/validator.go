@@ -0,0 +1,112 @@
+package resource
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const (
+	ValidatorInstanceFieldName   = "validator_instance"
+	ValidatorGroupFieldName      = "validator_group"
+	ValidatorLocationFieldName   = "validator_location"
+	ValidatorDetectedAtFieldName = "validator_detected_at"
+)
+
+var validatorFieldNames = []string{
+	ValidatorInstanceFieldName,
+	ValidatorGroupFieldName,
+	ValidatorLocationFieldName,
+	ValidatorDetectedAtFieldName,
+}
+
+// DetectedValidator is the validator found by metrics. Empty if validator has not been detected
+type DetectedValidator struct {
+	// Instance is instance ID, instance name or hostname of the validator
+	Instance string
+	// Group is auto scaling group, instance group manager or scale set of the validator
+	Group string
+	// Location is the validator region
+	Location string
+}
+
+// Detected returns true if validator has been detected
+func (v DetectedValidator) Detected() bool {
+	return v.Instance != "" || v.Group != ""
+}
+
+// SetSchemaValues stores the validator in computed attributes. Detection time is kept while the validator instance does not change
+func (v DetectedValidator) SetSchemaValues(d *schema.ResourceData) error {
+	detectedAt := ""
+	if v.Detected() {
+		detectedAt = time.Now().UTC().Format(time.RFC3339)
+		previousInstance, _ := d.Get(ValidatorInstanceFieldName).(string)
+		previousGroup, _ := d.Get(ValidatorGroupFieldName).(string)
+		previousDetectedAt, _ := d.Get(ValidatorDetectedAtFieldName).(string)
+		if previousInstance == v.Instance && previousGroup == v.Group && previousDetectedAt != "" {
+			detectedAt = previousDetectedAt
+		}
+	}
+	values := map[string]string{
+		ValidatorInstanceFieldName:   v.Instance,
+		ValidatorGroupFieldName:      v.Group,
+		ValidatorLocationFieldName:   v.Location,
+		ValidatorDetectedAtFieldName: detectedAt,
+	}
+	for _, name := range validatorFieldNames {
+		if err := d.Set(name, values[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetSchemaValuesDiag stores the validator in computed attributes
+func (v DetectedValidator) SetSchemaValuesDiag(d *schema.ResourceData) diag.Diagnostics {
+	if err := v.SetSchemaValues(d); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// ValidatorSchema returns computed attributes describing the detected validator
+func ValidatorSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		ValidatorInstanceFieldName: {
+			Type:        schema.TypeString,
+			Description: "Detected validator instance. Empty if validator has not been detected",
+			Computed:    true,
+		},
+		ValidatorGroupFieldName: {
+			Type:        schema.TypeString,
+			Description: "Instance group of the detected validator",
+			Computed:    true,
+		},
+		ValidatorLocationFieldName: {
+			Type:        schema.TypeString,
+			Description: "Location of the detected validator",
+			Computed:    true,
+		},
+		ValidatorDetectedAtFieldName: {
+			Type:        schema.TypeString,
+			Description: "RFC3339 time the validator instance has been detected first",
+			Computed:    true,
+		},
+	}
+}
+
+// CustomizeDiffValidator marks the detected validator as unknown for new resources or resources with changed configuration
+// since failover might change the validator
+func CustomizeDiffValidator(_ context.Context, diff *schema.ResourceDiff, _ interface{}) error {
+	if diff.Id() != "" && len(diff.GetChangedKeysPrefix("")) == 0 {
+		return nil
+	}
+	for _, name := range validatorFieldNames {
+		if err := diff.SetNewComputed(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
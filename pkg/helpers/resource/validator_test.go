@@ -0,0 +1,33 @@
+package resource
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectedValidatorSetSchemaValues(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, GetPolkadotResourceSchema(), map[string]interface{}{})
+
+	validator := DetectedValidator{Instance: "i-1", Group: "asg-1", Location: "us-east-1"}
+	require.NoError(t, validator.SetSchemaValues(d))
+	require.Equal(t, "i-1", d.Get(ValidatorInstanceFieldName))
+	require.Equal(t, "asg-1", d.Get(ValidatorGroupFieldName))
+	require.Equal(t, "us-east-1", d.Get(ValidatorLocationFieldName))
+	require.NotEmpty(t, d.Get(ValidatorDetectedAtFieldName))
+
+	// detection time is kept for the same validator
+	require.NoError(t, d.Set(ValidatorDetectedAtFieldName, "2020-01-01T00:00:00Z"))
+	require.NoError(t, validator.SetSchemaValues(d))
+	require.Equal(t, "2020-01-01T00:00:00Z", d.Get(ValidatorDetectedAtFieldName))
+
+	validator.Instance = "i-2"
+	require.NoError(t, validator.SetSchemaValues(d))
+	require.NotEqual(t, "2020-01-01T00:00:00Z", d.Get(ValidatorDetectedAtFieldName))
+
+	require.NoError(t, DetectedValidator{}.SetSchemaValues(d))
+	for _, name := range validatorFieldNames {
+		require.Empty(t, d.Get(name))
+	}
+}
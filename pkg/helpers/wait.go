@@ -0,0 +1,66 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	// MetricDefinitionPeriod is the interval between metric definition checks
+	MetricDefinitionPeriod = 5 * time.Second
+	// MetricDefinitionAttempts is the number of consecutive checks a metric definition should be found in.
+	// The same number of consecutive errors stops waiting
+	MetricDefinitionAttempts = 20
+)
+
+// WaitForStableValue calls check every period while it returns the same non empty value attempts times in a row.
+// Empty value resets the counter. Waiting stops on context cancellation or after attempts consecutive errors
+func WaitForStableValue(ctx context.Context, period time.Duration, attempts int, check func(ctx context.Context) (string, error)) (string, error) {
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	successCount := 0
+	errorsCount := 0
+	value := ""
+
+	for {
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("cancelled waiting for value. Last value %q", value)
+		case <-ticker.C:
+			current, err := check(ctx)
+
+			if err != nil {
+				errorsCount++
+				log.Printf("[DEBUG] failover: Error checking value. Errors occurred %d: %v", errorsCount, err)
+				if errorsCount >= attempts {
+					return "", fmt.Errorf("errors occurred %d: %w", errorsCount, err)
+				}
+				continue
+			}
+
+			errorsCount = 0
+
+			if current == "" || current != value {
+				successCount = 0
+			}
+
+			value = current
+
+			if value == "" {
+				continue
+			}
+
+			successCount++
+
+			if successCount >= attempts {
+				return value, nil
+			}
+
+			log.Printf("[DEBUG] failover: Found value %q. Retried: %d", value, successCount)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitForStableValue(t *testing.T) {
+	values := []string{"", "metric", "", "metric", "metric", "metric"}
+	calls := 0
+
+	value, err := WaitForStableValue(context.Background(), time.Millisecond, 3, func(ctx context.Context) (string, error) {
+		value := values[calls]
+		calls++
+		return value, nil
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, "metric", value)
+	require.Equal(t, len(values), calls)
+}
+
+func TestWaitForStableValueErrors(t *testing.T) {
+	calls := 0
+
+	_, err := WaitForStableValue(context.Background(), time.Millisecond, 3, func(ctx context.Context) (string, error) {
+		calls++
+		return "", errors.New("unavailable")
+	})
+
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unavailable")
+	require.Equal(t, 3, calls)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = WaitForStableValue(ctx, time.Hour, 3, func(ctx context.Context) (string, error) {
+		return "metric", nil
+	})
+
+	require.Error(t, err)
+}
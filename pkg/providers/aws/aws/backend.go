@@ -0,0 +1,289 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/hashicorp/go-multierror"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/aws"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/engine"
+)
+
+// backend is failover engine backend managing auto scaling groups instances
+type backend struct {
+	awsClients      []*Client
+	locations       []string
+	prefix          string
+	metricNamespace string
+	metricName      string
+	aggregation     helpers.MetricAggregation
+	freshness       helpers.MetricFreshness
+	standbyRemoval  bool
+	// groups and regions are auto scaling groups and their region IDs found by the last ListGroups call
+	groups  aws.AgsGroupsList
+	regions map[string]int
+	// suspended are processes suspended by SuspendSelfHealing by auto scaling group name
+	suspended map[string][]string
+}
+
+func newBackend(awsClients []*Client, failover *Failover) *backend {
+	return &backend{
+		awsClients:      awsClients,
+		locations:       failover.Locations,
+		prefix:          failover.Prefix,
+		metricNamespace: failover.MetricNameSpace,
+		metricName:      failover.MetricName,
+		aggregation:     failover.GetValidatorMetricCheck().GetAggregation(),
+		freshness:       failover.GetMetricFreshness(),
+		standbyRemoval:  failover.StandbyRemoval(),
+		regions:         map[string]int{},
+		suspended:       map[string][]string{},
+	}
+}
+
+func (b *backend) autoscalingClients() []*autoscaling.AutoScaling {
+	clients := make([]*autoscaling.AutoScaling, len(b.awsClients))
+	for idx, client := range b.awsClients {
+		clients[idx] = client.autoscalingconn
+	}
+	return clients
+}
+
+func (b *backend) cloudWatchClients() []*cloudwatch.CloudWatch {
+	clients := make([]*cloudwatch.CloudWatch, len(b.awsClients))
+	for idx, client := range b.awsClients {
+		clients[idx] = client.cloudwatchconn
+	}
+	return clients
+}
+
+// ListGroups returns auto scaling groups with running instances and instances kept in standby
+func (b *backend) ListGroups(ctx context.Context) ([]engine.Group, error) {
+
+	asgsGroupsList, standby, err := aws.GetASGsWithStandby(ctx, b.autoscalingClients(), b.prefix)
+
+	if err != nil {
+		return nil, err
+	}
+
+	b.groups = asgsGroupsList
+
+	var groups []engine.Group
+	positions := regionLocations(b.awsClients, b.locations)
+
+	for regionID, asgs := range asgsGroupsList {
+		for _, asg := range asgs {
+			group := engine.Group{
+				Name:     *asg.AutoScalingGroupName,
+				Location: positions[regionID],
+			}
+			for _, instance := range asg.Instances {
+				group.Instances = append(group.Instances, *instance.InstanceId)
+			}
+			group.Standby = standby[regionID][group.Name]
+			b.regions[group.Name] = regionID
+			groups = append(groups, group)
+		}
+	}
+
+	return groups, nil
+}
+
+// GetValidatorMetrics returns metric values of instances found by the last ListGroups call
+func (b *backend) GetValidatorMetrics(ctx context.Context, _ []engine.Group) ([]engine.Metric, error) {
+
+	validators, err := aws.GetValidatorMetrics(ctx, b.cloudWatchClients(), b.groups, b.metricNamespace, b.metricName, b.aggregation, b.freshness)
+
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := make([]engine.Metric, 0, len(validators))
+
+	for _, validator := range validators {
+		metrics = append(metrics, engine.Metric{
+			Group:     validator.ASGName,
+			Instance:  validator.InstanceID,
+			Value:     validator.RawValue,
+			Missing:   validator.Missing,
+			Stale:     validator.Stale,
+			Timestamp: validator.Timestamp,
+		})
+	}
+
+	return metrics, nil
+}
+
+// DeleteInstances terminates instances through the auto scaling group or moves them to standby
+func (b *backend) DeleteInstances(ctx context.Context, group engine.Group) error {
+
+	regionID, ok := b.regions[group.Name]
+
+	if !ok || regionID >= len(b.awsClients) {
+		return fmt.Errorf("cannot find region of auto scaling group %q", group.Name)
+	}
+
+	client := b.awsClients[regionID]
+
+	if b.standbyRemoval {
+		return aws.StandbyASGInstances(ctx, client.autoscalingconn, client.ec2conn, group.Name, group.Instances)
+	}
+
+	return aws.TerminateASGInstances(ctx, client.autoscalingconn, client.ec2conn, group.Name, group.Instances)
+}
+
+// ExitStandby starts standby instances of the group and returns them to service
+func (b *backend) ExitStandby(ctx context.Context, group engine.Group) error {
+
+	regionID, ok := b.regions[group.Name]
+
+	if !ok || regionID >= len(b.awsClients) {
+		return fmt.Errorf("cannot find region of auto scaling group %q", group.Name)
+	}
+
+	client := b.awsClients[regionID]
+
+	return aws.ExitStandbyASGInstances(ctx, client.autoscalingconn, client.ec2conn, group.Name, group.Standby)
+}
+
+// TerminateStandby terminates standby instances of the group
+func (b *backend) TerminateStandby(ctx context.Context, group engine.Group) error {
+
+	regionID, ok := b.regions[group.Name]
+
+	if !ok || regionID >= len(b.awsClients) {
+		return fmt.Errorf("cannot find region of auto scaling group %q", group.Name)
+	}
+
+	return aws.TerminateStandbyASGInstances(ctx, b.awsClients[regionID].ec2conn, group.Name, group.Standby)
+}
+
+// ProtectValidator protects the validator instance from scale in. Protection of other instances found by the last ListGroups call is removed
+func (b *backend) ProtectValidator(ctx context.Context, validator engine.Validator) error {
+
+	for regionID, asgs := range b.groups {
+		for _, asg := range asgs {
+			var unprotected []string
+			for _, instance := range asg.Instances {
+				protected := instance.ProtectedFromScaleIn != nil && *instance.ProtectedFromScaleIn
+				if protected && *instance.InstanceId != validator.Instance {
+					unprotected = append(unprotected, *instance.InstanceId)
+				}
+			}
+			if len(unprotected) == 0 {
+				continue
+			}
+			if err := aws.ProtectASGInstances(ctx, b.awsClients[regionID].autoscalingconn, *asg.AutoScalingGroupName, unprotected, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	regionID, ok := b.regions[validator.Group]
+
+	if !ok || regionID >= len(b.awsClients) {
+		return fmt.Errorf("cannot find region of auto scaling group %q", validator.Group)
+	}
+
+	return aws.ProtectASGInstances(ctx, b.awsClients[regionID].autoscalingconn, validator.Group, []string{validator.Instance}, true)
+}
+
+// SuspendSelfHealing suspends processes replacing instances of auto scaling groups found by the last ListGroups call.
+// Processes which have been suspended already are left as they are
+func (b *backend) SuspendSelfHealing(ctx context.Context, _ []engine.Group) error {
+
+	for regionID, asgs := range b.groups {
+		for _, asg := range asgs {
+			processes := aws.ProcessesToSuspend(asg)
+			if len(processes) == 0 {
+				continue
+			}
+			if err := aws.SuspendASGProcesses(ctx, b.awsClients[regionID].autoscalingconn, *asg.AutoScalingGroupName, processes); err != nil {
+				return err
+			}
+			b.suspended[*asg.AutoScalingGroupName] = processes
+		}
+	}
+
+	return nil
+}
+
+// ResumeSelfHealing resumes processes suspended by SuspendSelfHealing
+func (b *backend) ResumeSelfHealing(ctx context.Context) error {
+
+	result := &multierror.Error{}
+
+	for asgName, processes := range b.suspended {
+		regionID, ok := b.regions[asgName]
+		if !ok || regionID >= len(b.awsClients) {
+			result = multierror.Append(result, fmt.Errorf("cannot find region of auto scaling group %q", asgName))
+			continue
+		}
+		if err := aws.ResumeASGProcesses(ctx, b.awsClients[regionID].autoscalingconn, asgName, processes); err != nil {
+			result = multierror.Append(result, err)
+			continue
+		}
+		delete(b.suspended, asgName)
+	}
+
+	return result.ErrorOrNil()
+}
+
+// InstanceAddresses returns private IP addresses of instances
+func (b *backend) InstanceAddresses(ctx context.Context, groups []engine.Group) (map[string]string, error) {
+
+	instancesByRegion := map[int][]string{}
+
+	for _, group := range groups {
+		regionID, ok := b.regions[group.Name]
+		if !ok || regionID >= len(b.awsClients) {
+			return nil, fmt.Errorf("cannot find region of auto scaling group %q", group.Name)
+		}
+		instancesByRegion[regionID] = append(instancesByRegion[regionID], group.Instances...)
+	}
+
+	addresses := map[string]string{}
+
+	for regionID, instances := range instancesByRegion {
+		if len(instances) == 0 {
+			continue
+		}
+		regionAddresses, err := aws.GetInstancesPrivateIPs(ctx, b.awsClients[regionID].ec2conn, instances)
+		if err != nil {
+			return nil, err
+		}
+		for instance, address := range regionAddresses {
+			addresses[instance] = address
+		}
+	}
+
+	return addresses, nil
+}
+
+func (b *backend) WaitForCount(ctx context.Context, count int) error {
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timeout waiting for expected instances count: %d", count)
+		case <-ticker.C:
+			asgsGroupsList, err := aws.GetASGs(ctx, b.autoscalingClients(), b.prefix)
+			if err != nil {
+				log.Printf("[ERROR] failover: Cannot get auto scaling groups: %s", err)
+				continue
+			}
+			if asgsGroupsList.InstancesCount() == count {
+				return nil
+			}
+			log.Printf("[DEBUG] failover: Waiting for instances count %d. Found %d instances", count, asgsGroupsList.InstancesCount())
+		}
+	}
+}
@@ -17,14 +17,12 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/logging"
 )
 
-type Config struct {
-	AccessKey     string
-	SecretKey     string
-	CredsFilename string
-	Profile       string
-	Token         string
-	Regions       []string
-	MaxRetries    int
+// Credentials identify the provider in a region
+type Credentials struct {
+	AccessKey string
+	SecretKey string
+	Profile   string
+	Token     string
 
 	AssumeRoleARN               string
 	AssumeRoleDurationSeconds   int
@@ -34,6 +32,38 @@ type Config struct {
 	AssumeRoleSessionName       string
 	AssumeRoleTags              map[string]string
 	AssumeRoleTransitiveTagKeys []string
+}
+
+// override returns credentials with region block values. Static keys and profile are replaced together if the region sets any of them,
+// assume role is replaced if the region sets a role
+func (c Credentials) override(region Credentials) Credentials {
+	if region.AccessKey != "" || region.SecretKey != "" || region.Profile != "" {
+		c.AccessKey = region.AccessKey
+		c.SecretKey = region.SecretKey
+		c.Profile = region.Profile
+		c.Token = region.Token
+	}
+	if region.AssumeRoleARN != "" {
+		c.AssumeRoleARN = region.AssumeRoleARN
+		c.AssumeRoleDurationSeconds = region.AssumeRoleDurationSeconds
+		c.AssumeRoleExternalID = region.AssumeRoleExternalID
+		c.AssumeRolePolicy = region.AssumeRolePolicy
+		c.AssumeRolePolicyARNs = region.AssumeRolePolicyARNs
+		c.AssumeRoleSessionName = region.AssumeRoleSessionName
+		c.AssumeRoleTags = region.AssumeRoleTags
+		c.AssumeRoleTransitiveTagKeys = region.AssumeRoleTransitiveTagKeys
+	}
+	return c
+}
+
+type Config struct {
+	Credentials
+	// RegionCredentials are credentials of region blocks by region name. They override provider credentials
+	RegionCredentials map[string]Credentials
+
+	CredsFilename string
+	Regions       []string
+	MaxRetries    int
 
 	AllowedAccountIds   []string
 	ForbiddenAccountIds []string
@@ -74,8 +104,17 @@ func (client *Client) RegionalHostname(prefix string) string {
 	return fmt.Sprintf("%s.%s.%s", prefix, client.region, client.dnsSuffix)
 }
 
+// regionCredentials returns credentials of the region
+func (c *Config) regionCredentials(region string) Credentials {
+	if credentials, ok := c.RegionCredentials[region]; ok {
+		return c.Credentials.override(credentials)
+	}
+	return c.Credentials
+}
+
 func configureRegionClient(c *Config, regionIdx int) (*Client, error) {
 	region := c.Regions[regionIdx]
+	credentials := c.regionCredentials(region)
 
 	if !c.SkipRegionValidation {
 		if err := awsbase.ValidateRegion(c.Regions[regionIdx]); err != nil {
@@ -84,15 +123,15 @@ func configureRegionClient(c *Config, regionIdx int) (*Client, error) {
 	}
 
 	awsbaseConfig := &awsbase.Config{
-		AccessKey:                   c.AccessKey,
-		AssumeRoleARN:               c.AssumeRoleARN,
-		AssumeRoleDurationSeconds:   c.AssumeRoleDurationSeconds,
-		AssumeRoleExternalID:        c.AssumeRoleExternalID,
-		AssumeRolePolicy:            c.AssumeRolePolicy,
-		AssumeRolePolicyARNs:        c.AssumeRolePolicyARNs,
-		AssumeRoleSessionName:       c.AssumeRoleSessionName,
-		AssumeRoleTags:              c.AssumeRoleTags,
-		AssumeRoleTransitiveTagKeys: c.AssumeRoleTransitiveTagKeys,
+		AccessKey:                   credentials.AccessKey,
+		AssumeRoleARN:               credentials.AssumeRoleARN,
+		AssumeRoleDurationSeconds:   credentials.AssumeRoleDurationSeconds,
+		AssumeRoleExternalID:        credentials.AssumeRoleExternalID,
+		AssumeRolePolicy:            credentials.AssumeRolePolicy,
+		AssumeRolePolicyARNs:        credentials.AssumeRolePolicyARNs,
+		AssumeRoleSessionName:       credentials.AssumeRoleSessionName,
+		AssumeRoleTags:              credentials.AssumeRoleTags,
+		AssumeRoleTransitiveTagKeys: credentials.AssumeRoleTransitiveTagKeys,
 		CallerDocumentationURL:      "https://registry.terraform.io/providers/hashicorp/aws",
 		CallerName:                  "Terraform AWS Provider",
 		CredsFilename:               c.CredsFilename,
@@ -100,14 +139,14 @@ func configureRegionClient(c *Config, regionIdx int) (*Client, error) {
 		IamEndpoint:                 c.Endpoints["iam"],
 		Insecure:                    c.Insecure,
 		MaxRetries:                  c.MaxRetries,
-		Profile:                     c.Profile,
+		Profile:                     credentials.Profile,
 		Region:                      region,
-		SecretKey:                   c.SecretKey,
+		SecretKey:                   credentials.SecretKey,
 		SkipCredsValidation:         c.SkipCredsValidation,
 		SkipMetadataApiCheck:        c.SkipMetadataAPICheck,
 		SkipRequestingAccountId:     c.SkipRequestingAccountID,
 		StsEndpoint:                 c.Endpoints["sts"],
-		Token:                       c.Token,
+		Token:                       credentials.Token,
 		UserAgentProducts: []*awsbase.UserAgentProduct{
 			{Name: "APN", Version: "1.0"},
 			{Name: "HashiCorp", Version: "1.0"},
@@ -215,6 +254,14 @@ func configureRegionClient(c *Config, regionIdx int) (*Client, error) {
 func (c *Config) Client() (interface{}, diag.Diagnostics) {
 	// Get the auth and region. This can fail if keys/regions were not
 	// specified and we're attempting to use the environment.
+	seen := make(map[string]bool, len(c.Regions))
+	for _, region := range c.Regions {
+		if seen[region] {
+			return nil, diag.Errorf("region %q is set more than once", region)
+		}
+		seen[region] = true
+	}
+
 	if !c.SkipRegionValidation {
 		for _, region := range c.Regions {
 			if err := awsbase.ValidateRegion(region); err != nil {
@@ -3,6 +3,7 @@ package aws
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 	"strings"
 	"time"
@@ -11,7 +12,9 @@ import (
 
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
 	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/aws"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/engine"
 
 	helperErrors "github.com/protofire/polkadot-failover-mechanism/pkg/helpers/errors"
 
@@ -19,10 +22,23 @@ import (
 
 	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/resource"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/validate"
 )
 
 func resourcePolkadotFailover() *schema.Resource {
+
+	polkadotSchema := resource.GetPolkadotResourceSchema()
+	polkadotSchema[InstanceRemovalFieldName] = &schema.Schema{
+		Type:             schema.TypeString,
+		Description:      "How single and standby mode failover removes instances. terminate terminates them through the auto scaling group, standby moves them to standby and stops them keeping their volumes",
+		Optional:         true,
+		Default:          InstanceRemovalTerminate,
+		ValidateDiagFunc: validate.DiagFunc(validation.StringInSlice([]string{InstanceRemovalTerminate, InstanceRemovalStandby}, false)),
+	}
+
 	return &schema.Resource{
 
 		ReadContext:   resourcePolkadotFailoverRead,
@@ -30,6 +46,10 @@ func resourcePolkadotFailover() *schema.Resource {
 		UpdateContext: resourcePolkadotFailoverCreateOrUpdate,
 		DeleteContext: resourcePolkadotFailoverDelete,
 
+		Importer: &schema.ResourceImporter{
+			StateContext: resourcePolkadotFailoverImport,
+		},
+
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(time.Minute * 30),
 			Update: schema.DefaultTimeout(time.Minute * 60),
@@ -37,7 +57,17 @@ func resourcePolkadotFailover() *schema.Resource {
 			Delete: schema.DefaultTimeout(time.Minute * 30),
 		},
 
-		Schema: resource.GetPolkadotSchema(),
+		Schema: polkadotSchema,
+
+		SchemaVersion:  resource.SchemaVersion,
+		StateUpgraders: resourcePolkadotFailoverStateUpgraders(),
+
+		CustomizeDiff: customdiff.All(
+			resource.CustomizeDiff,
+			customizeDiffLocations,
+			resource.CustomizeDiffPlan(resourcePolkadotFailoverPlan),
+			resource.CustomizeDiffValidator,
+		),
 	}
 }
 
@@ -55,12 +85,6 @@ func resourcePolkadotFailoverRead(ctx context.Context, d *schema.ResourceData, m
 		return nil
 	}
 
-	if failover.IsDistributedMode() {
-		log.Printf("[DEBUG] failover: Read. Failover mode is %q. Using predefined number of instances", failover.FailoverMode)
-		failover.SetCounts(failover.Instances...)
-		return failover.SetSchemaValuesDiag(d)
-	}
-
 	log.Printf("[DEBUG] failover: Read. Failover mode is %q", failover.FailoverMode)
 
 	awsClients := meta.([]*Client)
@@ -88,9 +112,25 @@ func resourcePolkadotFailoverRead(ctx context.Context, d *schema.ResourceData, m
 
 	log.Printf("[DEBUG] failover: Read. Found %d instance groups", asgsGroupsList.GroupsCount())
 
-	positions := asgsGroupsList.InstancesCountPerRegion()
+	validator, err := getDetectedValidator(ctx, cloudWatchClients, asgsGroupsList, failover, awsClients)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := validator.SetSchemaValues(d); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if failover.IsDistributedMode() {
+		log.Printf("[DEBUG] failover: Read. Failover mode is %q. Using predefined number of instances", failover.FailoverMode)
+		failover.SetCounts(failover.Instances...)
+		return failover.SetSchemaValuesDiag(d)
+	}
 
-	log.Printf("[DEBUG] failover: Read. Found instance numbers per region: %v", positions)
+	positions := locationCounts(awsClients, failover.Locations, asgsGroupsList.InstancesCountPerRegion())
+
+	log.Printf("[DEBUG] failover: Read. Found instance numbers per location: %v", positions)
 
 	failover.SetCounts(positions...)
 
@@ -112,9 +152,18 @@ func resourcePolkadotFailoverCreateOrUpdate(ctx context.Context, d *schema.Resou
 
 	log.Printf("[DEBUG] failover: Create. Failover mode is %q", failover.FailoverMode)
 
+	awsClients := meta.([]*Client)
+
 	if failover.IsDistributedMode() {
 		log.Printf("[DEBUG] failover: Create. Failover mode is %q. Using predefined number of instances", failover.FailoverMode)
 		failover.SetCounts(failover.Instances...)
+		if !failover.DryRun {
+			restoreCtx, cancel := context.WithTimeout(ctx, d.Timeout(schema.TimeoutCreate))
+			defer cancel()
+			if err := restoreStandbyInstances(restoreCtx, newBackend(awsClients, failover), failover); err != nil {
+				return diag.FromErr(err)
+			}
+		}
 		id, err := failover.ID()
 		if err != nil {
 			return diag.FromErr(err)
@@ -123,106 +172,224 @@ func resourcePolkadotFailoverCreateOrUpdate(ctx context.Context, d *schema.Resou
 		return resourcePolkadotFailoverRead(ctx, d, meta)
 	}
 
-	awsClients := meta.([]*Client)
-	ec2Clients := make([]*ec2.EC2, len(awsClients))
-	cloudWatchClients := make([]*cloudwatch.CloudWatch, len(awsClients))
-	autoscalingClients := make([]*autoscaling.AutoScaling, len(awsClients))
-	for idx, client := range awsClients {
-		ec2Clients[idx] = client.ec2conn
-		cloudWatchClients[idx] = client.cloudwatchconn
-		autoscalingClients[idx] = client.autoscalingconn
-	}
-
 	ctx, cancel := context.WithTimeout(ctx, d.Timeout(schema.TimeoutCreate))
 	defer cancel()
 
-	log.Printf("[DEBUG] failover: Create. Getting ags groups...")
-	asgsGroupsList, err := aws.GetASGs(ctx, autoscalingClients, failover.Prefix)
+	backend := newBackend(awsClients, failover)
+
+	result, err := engine.New(backend, &failover.Failover).Run(ctx)
+
+	var abort resource.AbortError
+	if errors.As(err, &abort) {
+		return abort.Diagnostics()
+	}
+
+	plan := result.Plan(len(failover.Locations))
+	plan.DryRun = failover.DryRun
+
+	if err := plan.SetSchemaValues(d, failover.Locations); err != nil {
+		return diag.FromErr(err)
+	}
 
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	log.Printf("[DEBUG] failover: Create. Getting failover validator...")
-	validator, err := aws.GetValidator(ctx, cloudWatchClients, asgsGroupsList, failover.MetricNameSpace, failover.MetricName)
+	// standby instances are returned to service in locations which need more instances than are running
+	if !failover.DryRun {
+		if err := restoreStandbyInstances(ctx, backend, failover); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	log.Printf("[DEBUG] failover: Create. Set instance numbers per region: %v", failover.FailoverInstances)
+
+	id, err := failover.ID()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(id)
+	return resourcePolkadotFailoverRead(ctx, d, meta)
+}
+
+// getDetectedValidator finds the validator for computed attributes. Empty validator is returned if validator has not been detected
+func getDetectedValidator(
+	ctx context.Context,
+	cloudWatchClients []*cloudwatch.CloudWatch,
+	asgsGroupsList aws.AgsGroupsList,
+	failover *Failover,
+	awsClients []*Client,
+) (resource.DetectedValidator, error) {
+
+	if asgsGroupsList.InstancesCount() == 0 {
+		return resource.DetectedValidator{}, nil
+	}
+
+	if !failover.DetectsWithMetricsOnly() {
+		result, err := engine.New(newBackend(awsClients, failover), &failover.Failover).Inspect(ctx)
+		if err != nil {
+			return resource.DetectedValidator{}, err
+		}
+		return result.Validator.Detected(failover.Locations), nil
+	}
+
+	validator, err := aws.GetValidator(ctx, cloudWatchClients, asgsGroupsList, failover.MetricNameSpace, failover.MetricName, failover.GetValidatorMetricCheck(), failover.GetMetricFreshness())
 
 	if err != nil {
 		validatorError := &helperErrors.ValidatorError{}
 		if errors.As(err, validatorError) {
-			log.Printf("[WARNING] failover: Create. Cannot get validator: %s", validatorError)
-		} else {
-			log.Printf("[ERROR] failover: Create. Cannot get validator: %s", err)
-			return diag.FromErr(err)
+			log.Printf("[WARNING] failover: Read. Cannot get validator: %s", validatorError)
+			return resource.DetectedValidator{}, nil
 		}
+		return resource.DetectedValidator{}, err
 	}
 
-	if validator.InstanceID != "" {
-		log.Printf("[DEBUG] failover: Create. Found the validator instance %q in auto scale group %q", validator.InstanceID, validator.ASGName)
-	} else {
-		log.Printf("[DEBUG] failover: Create. Have not found the validator instance")
+	log.Printf("[DEBUG] failover: Read. Found the validator instance %q in auto scale group %q", validator.InstanceID, validator.ASGName)
+
+	detected := resource.DetectedValidator{
+		Instance: validator.InstanceID,
+		Group:    validator.ASGName,
+	}
+	if validator.RegionID < len(awsClients) {
+		detected.Location = awsClients[validator.RegionID].region
 	}
+	return detected, nil
+}
 
+// regionLocations maps provider regions to location indexes by name. -1 marks regions which are not in locations
+func regionLocations(awsClients []*Client, locations []string) []int {
 	positions := make([]int, len(awsClients))
+	for regionID, client := range awsClients {
+		positions[regionID] = helpers.FindStrIndex(client.region, locations)
+	}
+	return positions
+}
 
-	if validator.InstanceID != "" {
-		positions[validator.RegionID] = 1
+// locationCounts converts instance counts per provider region to instance counts per location
+func locationCounts(awsClients []*Client, locations []string, counts []int) []int {
+	positions := regionLocations(awsClients, locations)
+	result := make([]int, len(locations))
+	for regionID, count := range counts {
+		if regionID >= len(positions) || positions[regionID] == -1 {
+			if count > 0 && regionID < len(awsClients) {
+				log.Printf("[WARNING] failover: Region %q is not in locations list: %s", awsClients[regionID].region, strings.Join(locations, ", "))
+			}
+			continue
+		}
+		result[positions[regionID]] += count
 	}
+	return result
+}
 
-	failover.SetCounts(positions...)
-	failover.FillDefaultCountsIfNotSet()
+// validateLocationClients checks that every location has a provider region configured
+func validateLocationClients(awsClients []*Client, locations []string) error {
+	regions := make([]string, len(awsClients))
+	for idx, client := range awsClients {
+		regions[idx] = client.region
+	}
+	var missing []string
+	for _, location := range locations {
+		if helpers.FindStrIndex(location, regions) == -1 {
+			missing = append(missing, location)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%q contains locations without configured provider regions: %s. Configured regions: %s", resource.LocationsFieldName, strings.Join(missing, ", "), strings.Join(regions, ", "))
+	}
+	return nil
+}
 
-	// delete all instances besides the validator instance. In case we did not find the validator, or we found multiple validators,
-	// we will delete all instances
+// customizeDiffLocations fails the plan if a location has no configured provider region
+func customizeDiffLocations(_ context.Context, diff *schema.ResourceDiff, meta interface{}) error {
+	if !diff.NewValueKnown(resource.LocationsFieldName) {
+		return nil
+	}
+	locations := resource.ExpandString(diff.Get(resource.LocationsFieldName).([]interface{}))
+	return validateLocationClients(meta.([]*Client), locations)
+}
 
-	instancesToDelete := aws.NewAsgInstancesByRegion(len(awsClients))
+// resourcePolkadotFailoverPlan runs single and standby mode failover discovery while planning
+func resourcePolkadotFailoverPlan(ctx context.Context, diff *schema.ResourceDiff, meta interface{}) (resource.FailoverPlan, []string, error) {
 
-	for regionID, groups := range asgsGroupsList {
-		for _, group := range groups {
-			for _, instance := range group.Instances {
-				asgToInstances := &instancesToDelete[regionID]
-				groupName := *group.AutoScalingGroupName
-				instanceID := *instance.InstanceId
-				if instanceID != validator.InstanceID {
-					(*asgToInstances)[groupName] = append((*asgToInstances)[groupName], instanceID)
-				}
-			}
-		}
+	failover := &Failover{}
+	if err := failover.FromResourceDiff(diff); err != nil {
+		return resource.FailoverPlan{}, nil, err
 	}
 
-	log.Printf(
-		"[DEBUG] failover: Create. Deleting %d asg instances: %q",
-		instancesToDelete.InstancesCount(),
-		strings.Join(instancesToDelete.InstancesIDs(), ", "),
-	)
-	for regionID, mp := range instancesToDelete {
-		for asgName, instances := range mp {
-			if regionID < len(autoscalingClients) {
-				err := aws.DetachASGInstances(ctx, autoscalingClients[regionID], asgName, instances)
-				if err != nil {
-					return diag.FromErr(err)
-				}
-				err = aws.DeleteInstances(ctx, ec2Clients[regionID], instances)
-				if err != nil {
-					return diag.FromErr(err)
-				}
-			}
-		}
+	awsClients := meta.([]*Client)
+
+	result, err := engine.New(newBackend(awsClients, failover), &failover.Failover).Discover(ctx)
+	if err != nil {
+		return resource.FailoverPlan{}, nil, err
+	}
+
+	return result.Plan(len(failover.Locations)), failover.Locations, nil
+}
+
+// resourcePolkadotFailoverImport discovers autoscaling groups by prefix and restores counts from running instances
+func resourcePolkadotFailoverImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+
+	imported, err := resource.ParseImportID(d.Id())
+	if err != nil {
+		return nil, err
 	}
 
-	if validator.InstanceID != "" {
-		log.Printf("[DEBUG] failover: Create. Waiting for validator...")
-		_, err := aws.WaitForValidator(ctx, cloudWatchClients, asgsGroupsList, failover.MetricNameSpace, failover.MetricName, 5)
+	failover := &Failover{Failover: imported}
+
+	awsClients := meta.([]*Client)
+	cloudWatchClients := make([]*cloudwatch.CloudWatch, len(awsClients))
+	autoscalingClients := make([]*autoscaling.AutoScaling, len(awsClients))
+	for idx, client := range awsClients {
+		cloudWatchClients[idx] = client.cloudwatchconn
+		autoscalingClients[idx] = client.autoscalingconn
+	}
+
+	log.Printf("[DEBUG] failover: Import. Getting ags groups...")
+	asgsGroupsList, err := aws.GetASGs(ctx, autoscalingClients, failover.Prefix)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// provider regions might be ordered differently than locations
+	running := locationCounts(awsClients, failover.Locations, asgsGroupsList.InstancesCountPerRegion())
+	positions := regionLocations(awsClients, failover.Locations)
+
+	log.Printf("[DEBUG] failover: Import. Found instance numbers per location: %v", running)
+
+	validatorLocation := -1
+
+	if asgsGroupsList.InstancesCount() > 0 {
+		validator, err := aws.GetValidator(ctx, cloudWatchClients, asgsGroupsList, failover.MetricNameSpace, failover.MetricName, failover.GetValidatorMetricCheck(), failover.GetMetricFreshness())
 		if err != nil {
-			return diag.FromErr(err)
+			log.Printf("[WARNING] failover: Import. Cannot get validator: %s", err)
+		} else {
+			log.Printf("[DEBUG] failover: Import. Found the validator instance %q in auto scale group %q", validator.InstanceID, validator.ASGName)
+			if validator.RegionID < len(positions) {
+				validatorLocation = positions[validator.RegionID]
+			}
 		}
 	}
 
+	failover.SetImportedCounts(running, validatorLocation)
+
+	log.Printf("[DEBUG] failover: Import. Set instance numbers per location: %v", failover.FailoverInstances)
+
+	if err := failover.SetConfigSchemaValues(d); err != nil {
+		return nil, err
+	}
+
+	if err := d.Set(InstanceRemovalFieldName, InstanceRemovalTerminate); err != nil {
+		return nil, err
+	}
+
 	id, err := failover.ID()
 	if err != nil {
-		return diag.FromErr(err)
+		return nil, err
 	}
 	d.SetId(id)
-	return resourcePolkadotFailoverRead(ctx, d, meta)
+
+	return []*schema.ResourceData{d}, nil
 }
 
 func resourcePolkadotFailoverDelete(_ context.Context, _ *schema.ResourceData, _ interface{}) diag.Diagnostics {
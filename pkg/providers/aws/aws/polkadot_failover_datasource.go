@@ -0,0 +1,78 @@
+package aws
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/engine"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/resource"
+)
+
+func dataSourcePolkadotFailover() *schema.Resource {
+	return &schema.Resource{
+
+		ReadContext: dataSourcePolkadotFailoverRead,
+
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(time.Minute * 30),
+		},
+
+		Schema: resource.GetPolkadotDataSourceSchema(),
+	}
+}
+
+// dataSourceFailoverResult finds running instances per location and the validator. It never deletes instances
+func dataSourceFailoverResult(ctx context.Context, backend engine.CloudBackend, failover *Failover) (engine.Result, error) {
+
+	result, err := engine.New(backend, &failover.Failover).Inspect(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	failover.SetCounts(result.Running...)
+
+	return result, nil
+}
+
+// dataSourcePolkadotFailoverRead reports running instances per location and the current validator. It never deletes instances
+func dataSourcePolkadotFailoverRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	failover := &Failover{}
+	if err := failover.FromSchema(d); err != nil {
+		return diag.FromErr(err)
+	}
+
+	awsClients := meta.([]*Client)
+	if err := validateLocationClients(awsClients, failover.Locations); err != nil {
+		return diag.FromErr(err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout(schema.TimeoutRead))
+	defer cancel()
+
+	log.Printf("[DEBUG] failover: Data source read. Getting ags groups...")
+
+	result, err := dataSourceFailoverResult(ctx, newBackend(awsClients, failover), failover)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	log.Printf("[DEBUG] failover: Data source read. Found instance numbers per location: %v", failover.FailoverInstances)
+
+	detected := result.Validator.Detected(failover.Locations)
+
+	if err := detected.SetSchemaValues(d); err != nil {
+		return diag.FromErr(err)
+	}
+
+	id, err := failover.ID()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(id)
+	return failover.SetSchemaValuesDiag(d)
+}
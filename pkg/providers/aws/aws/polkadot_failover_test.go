@@ -8,6 +8,8 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/engine"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/resource"
 )
 
 var providerFactories map[string]func() (*schema.Provider, error)
@@ -35,3 +37,131 @@ func TestPolkadotConfigureCheck(t *testing.T) {
 	ds := prov.Resources()[0]
 	require.Equal(t, "polkadot_failover", ds.Name)
 }
+
+func TestPolkadotFailoverDataSource(t *testing.T) {
+	prov, err := providerFactories["polkadot"]()
+	require.NoError(t, err)
+	ds, ok := prov.DataSourcesMap["polkadot_failover"]
+	require.True(t, ok)
+	require.Nil(t, ds.CreateContext)
+	require.Nil(t, ds.DeleteContext)
+	require.Contains(t, ds.Schema, resource.ValidatorInstanceFieldName)
+	require.Contains(t, ds.Schema, resource.FailoverInstancesFieldName)
+}
+
+func TestPolkadotFailoverDataSourceResult(t *testing.T) {
+	backend := engine.NewFakeBackend(
+		engine.Group{Name: "group-1", Location: 0, Instances: []string{"i1", "i2"}},
+		engine.Group{Name: "group-2", Location: 1, Instances: []string{"i3"}},
+		engine.Group{Name: "group-3", Location: 2, Instances: []string{"i4", "i5"}},
+	)
+	backend.Metrics["i3"] = 1
+
+	failover := &Failover{}
+	failover.FailoverMode = resource.FailOverModeSingle
+	failover.Instances = []int{2, 2, 2}
+	failover.Locations = []string{"us-east-1", "us-east-2", "us-west-1"}
+	failover.OnValidatorUnknown = resource.ValidatorUnknownDeleteAll
+
+	result, err := dataSourceFailoverResult(context.Background(), backend, failover)
+	require.NoError(t, err)
+	require.Equal(t, engine.Validator{Group: "group-2", Instance: "i3", Location: 1}, result.Validator)
+	require.Equal(t, []int{2, 1, 2}, failover.FailoverInstances)
+	require.Empty(t, backend.Deleted)
+	require.Empty(t, backend.WaitedCounts)
+
+	// instances are kept even if the validator has not been detected
+	delete(backend.Metrics, "i3")
+	result, err = dataSourceFailoverResult(context.Background(), backend, failover)
+	require.NoError(t, err)
+	require.Error(t, result.ValidatorErr)
+	require.Equal(t, []int{2, 1, 2}, failover.FailoverInstances)
+	require.Empty(t, backend.Deleted)
+}
+
+func TestPolkadotFailoverInstanceRemoval(t *testing.T) {
+	prov, err := providerFactories["polkadot"]()
+	require.NoError(t, err)
+	res, ok := prov.ResourcesMap["polkadot_failover"]
+	require.True(t, ok)
+	require.Contains(t, res.Schema, InstanceRemovalFieldName)
+	require.Equal(t, InstanceRemovalTerminate, res.Schema[InstanceRemovalFieldName].Default)
+
+	d := res.TestResourceData()
+	require.NoError(t, d.Set(InstanceRemovalFieldName, InstanceRemovalStandby))
+	failover := &Failover{}
+	require.NoError(t, failover.FromSchema(d))
+	require.True(t, failover.StandbyRemoval())
+}
+
+func TestProviderRegionBlocks(t *testing.T) {
+	prov, err := providerFactories["polkadot"]()
+	require.NoError(t, err)
+	require.NoError(t, prov.InternalValidate())
+
+	d := schema.TestResourceDataRaw(t, prov.Schema, map[string]interface{}{
+		"profile": "default",
+		"regions": []interface{}{"us-east-1"},
+		"assume_role": []interface{}{
+			map[string]interface{}{"role_arn": "arn:aws:iam::111111111111:role/failover"},
+		},
+		"region": []interface{}{
+			map[string]interface{}{
+				"name":       "eu-west-1",
+				"access_key": "key",
+				"secret_key": "secret",
+			},
+			map[string]interface{}{
+				"name": "ap-south-1",
+				"assume_role": []interface{}{
+					map[string]interface{}{"role_arn": "arn:aws:iam::222222222222:role/failover", "session_name": "failover"},
+				},
+			},
+		},
+	})
+
+	config := expandConfig(d, "")
+
+	require.Equal(t, []string{"us-east-1", "eu-west-1", "ap-south-1"}, config.Regions)
+
+	// regions list uses provider credentials
+	credentials := config.regionCredentials("us-east-1")
+	require.Equal(t, "default", credentials.Profile)
+	require.Equal(t, "arn:aws:iam::111111111111:role/failover", credentials.AssumeRoleARN)
+
+	// region keys replace provider profile, provider role is assumed with them
+	credentials = config.regionCredentials("eu-west-1")
+	require.Equal(t, "key", credentials.AccessKey)
+	require.Equal(t, "secret", credentials.SecretKey)
+	require.Empty(t, credentials.Profile)
+	require.Equal(t, "arn:aws:iam::111111111111:role/failover", credentials.AssumeRoleARN)
+
+	// region role replaces provider role
+	credentials = config.regionCredentials("ap-south-1")
+	require.Equal(t, "default", credentials.Profile)
+	require.Equal(t, "arn:aws:iam::222222222222:role/failover", credentials.AssumeRoleARN)
+	require.Equal(t, "failover", credentials.AssumeRoleSessionName)
+}
+
+func TestLocationCounts(t *testing.T) {
+	awsClients := []*Client{{region: "us-east-1"}, {region: "eu-west-1"}, {region: "ap-south-1"}}
+	locations := []string{"eu-west-1", "us-east-1", "ap-south-1"}
+
+	// provider regions are ordered differently than locations
+	require.Equal(t, []int{2, 1, 0}, locationCounts(awsClients, locations, []int{1, 2, 0}))
+	require.Equal(t, []int{1, 0, -1}, regionLocations(awsClients, []string{"eu-west-1", "us-east-1"}))
+
+	// regions outside of locations are not counted
+	require.Equal(t, []int{2, 1}, locationCounts(awsClients, []string{"eu-west-1", "us-east-1"}, []int{1, 2, 3}))
+}
+
+func TestValidateLocationClients(t *testing.T) {
+	awsClients := []*Client{{region: "us-east-1"}, {region: "eu-west-1"}}
+
+	require.NoError(t, validateLocationClients(awsClients, []string{"eu-west-1", "us-east-1"}))
+	require.NoError(t, validateLocationClients(awsClients, []string{"eu-west-1"}))
+
+	err := validateLocationClients(awsClients, []string{"eu-west-1", "ap-south-1"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "ap-south-1")
+}
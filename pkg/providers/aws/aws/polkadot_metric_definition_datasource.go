@@ -0,0 +1,109 @@
+package aws
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/aws"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/resource"
+)
+
+const GroupNamesFieldName = "group_names"
+
+func dataSourcePolkadotMetricDefinition() *schema.Resource {
+
+	metricSchema := resource.GetMetricDefinitionSchema()
+	metricSchema[GroupNamesFieldName] = &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "Auto scaling groups reporting the metric with group_name dimension",
+		Required:    true,
+		ForceNew:    true,
+		MinItems:    1,
+		Elem: &schema.Schema{
+			Type: schema.TypeString,
+		},
+	}
+
+	return &schema.Resource{
+
+		ReadContext: dataSourcePolkadotMetricDefinitionRead,
+
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(time.Minute * 60),
+		},
+
+		Schema: metricSchema,
+	}
+}
+
+// dataSourcePolkadotMetricDefinitionRead waits while cloud watch has the metric for running auto scaling groups
+func dataSourcePolkadotMetricDefinitionRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	metric := resource.MetricDefinition{}
+	metric.FromSchema(d)
+	groupNames := resource.ExpandString(d.Get(GroupNamesFieldName).([]interface{}))
+
+	awsClients := meta.([]*Client)
+	cloudWatchClients := make([]*cloudwatch.CloudWatch, len(awsClients))
+	autoscalingClients := make([]*autoscaling.AutoScaling, len(awsClients))
+
+	for idx, client := range awsClients {
+		cloudWatchClients[idx] = client.cloudwatchconn
+		autoscalingClients[idx] = client.autoscalingconn
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout(schema.TimeoutRead))
+	defer cancel()
+
+	asgsGroupsList, err := aws.GetASGs(ctx, autoscalingClients, metric.Prefix)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	var asgNames []string
+
+	for _, asgs := range asgsGroupsList {
+		for _, asg := range asgs {
+			if helpers.StringsContainsBool(*asg.AutoScalingGroupName, groupNames) && len(asg.Instances) > 0 {
+				asgNames = append(asgNames, *asg.AutoScalingGroupName)
+			}
+		}
+	}
+
+	log.Printf("[DEBUG] failover: Metrics. Filtered auto scaling groups %s. Requested %s", asgNames, groupNames)
+
+	metric.MetricOutputName = metric.MetricName
+
+	if len(asgNames) > 0 {
+		asgToMetricName, err := aws.WaitValidatorMetricNames(
+			ctx,
+			cloudWatchClients,
+			asgNames,
+			metric.MetricNameSpace,
+			metric.MetricName,
+			helpers.MetricDefinitionPeriod,
+			helpers.MetricDefinitionAttempts,
+		)
+
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		log.Printf(
+			"[DEBUG] failover: Metrics. Found metric %q for metric namespace %q and auto scaling groups %v",
+			metric.MetricName,
+			metric.MetricNameSpace,
+			asgToMetricName,
+		)
+	}
+
+	d.SetId(metric.ID())
+	return metric.SetSchemaValuesDiag(d)
+}
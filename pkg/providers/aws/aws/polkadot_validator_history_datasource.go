@@ -0,0 +1,82 @@
+package aws
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/aws"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/resource"
+)
+
+func dataSourcePolkadotValidatorHistory() *schema.Resource {
+	return &schema.Resource{
+
+		ReadContext: dataSourcePolkadotValidatorHistoryRead,
+
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(time.Minute * 10),
+		},
+
+		Schema: resource.GetValidatorHistorySchema(),
+	}
+}
+
+// dataSourcePolkadotValidatorHistoryRead builds the validator timeline from cloud watch metrics of all provider regions
+func dataSourcePolkadotValidatorHistoryRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	source := resource.ValidatorHistorySource{}
+	source.FromSchema(d)
+
+	awsClients := meta.([]*Client)
+	cloudWatchClients := make([]*cloudwatch.CloudWatch, len(awsClients))
+	autoscalingClients := make([]*autoscaling.AutoScaling, len(awsClients))
+
+	for idx, client := range awsClients {
+		cloudWatchClients[idx] = client.cloudwatchconn
+		autoscalingClients[idx] = client.autoscalingconn
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout(schema.TimeoutRead))
+	defer cancel()
+
+	// validator metric is published to all regions. Samples locations are regions of auto scaling groups
+	asgsGroupsList, err := aws.GetASGs(ctx, autoscalingClients, source.Prefix)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	check := source.GetValidatorMetricCheck()
+
+	samples, err := aws.GetValidatorHistory(
+		ctx,
+		cloudWatchClients,
+		asgsGroupsList,
+		source.Prefix,
+		source.MetricNameSpace,
+		source.MetricName,
+		check.GetAggregation(),
+		source.GetWindow(),
+	)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	history := resource.NewValidatorHistory(samples, check)
+
+	log.Printf(
+		"[DEBUG] failover: Validator history. Found %d validator samples, %d transitions and %d overlaps",
+		len(history.Timeline),
+		history.Transitions,
+		history.Overlaps,
+	)
+
+	d.SetId(source.ID())
+	return history.SetSchemaValuesDiag(d)
+}
@@ -56,13 +56,15 @@ func Provider() *schema.Provider {
 
 			"regions": {
 				Type:        schema.TypeList,
-				Required:    true,
+				Optional:    true,
 				Description: descriptions["regions"],
 				Elem: &schema.Schema{
 					Type: schema.TypeString,
 				},
 			},
 
+			"region": regionSchema(),
+
 			"max_retries": {
 				Type:        schema.TypeInt,
 				Optional:    true,
@@ -163,7 +165,11 @@ func Provider() *schema.Provider {
 			},
 		},
 
-		DataSourcesMap: map[string]*schema.Resource{},
+		DataSourcesMap: map[string]*schema.Resource{
+			"polkadot_failover":          dataSourcePolkadotFailover(),
+			"polkadot_metric_definition": dataSourcePolkadotMetricDefinition(),
+			"polkadot_validator_history": dataSourcePolkadotValidatorHistory(),
+		},
 
 		ResourcesMap: map[string]*schema.Resource{
 			"polkadot_failover": resourcePolkadotFailover(),
@@ -191,6 +197,11 @@ func init() {
 		"regions": "The regions where AWS operations will take place. Examples\n" +
 			"are us-east-1, us-west-2, etc.",
 
+		"region": "The region where AWS operations will take place with its own credentials.\n" +
+			"Regions are matched to failover locations by name.",
+
+		"region_name": "The region name, e.g. us-east-1.",
+
 		"access_key": "The access key for API operations. You can retrieve this\n" +
 			"from the 'Security & Credentials' section of the AWS console.",
 
@@ -384,15 +395,24 @@ func init() {
 	}
 }
 
-// nolint
 func providerConfigure(d *schema.ResourceData, terraformVersion string) (interface{}, diag.Diagnostics) {
+	config := expandConfig(d, terraformVersion)
+	return config.Client()
+}
+
+// expandConfig reads provider configuration. Regions of region blocks follow regions list
+// nolint
+func expandConfig(d *schema.ResourceData, terraformVersion string) Config {
 	regionsRaw := d.Get("regions").([]interface{})
 	regions := resource.ExpandString(regionsRaw)
 	config := Config{
-		AccessKey:               d.Get("access_key").(string),
-		SecretKey:               d.Get("secret_key").(string),
-		Profile:                 d.Get("profile").(string),
-		Token:                   d.Get("token").(string),
+		Credentials: Credentials{
+			AccessKey: d.Get("access_key").(string),
+			SecretKey: d.Get("secret_key").(string),
+			Profile:   d.Get("profile").(string),
+			Token:     d.Get("token").(string),
+		},
+		RegionCredentials:       make(map[string]Credentials),
 		Regions:                 regions,
 		CredsFilename:           d.Get("shared_credentials_file").(string),
 		Endpoints:               make(map[string]string),
@@ -407,19 +427,64 @@ func providerConfigure(d *schema.ResourceData, terraformVersion string) (interfa
 		terraformVersion:        terraformVersion,
 	}
 
-	if l, ok := d.Get("assume_role").([]interface{}); ok && len(l) > 0 && l[0] != nil {
+	expandAssumeRole(d.Get("assume_role"), &config.Credentials)
+
+	for _, regionRaw := range d.Get("region").([]interface{}) {
+		m, ok := regionRaw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name := m["name"].(string)
+		credentials := Credentials{
+			AccessKey: m["access_key"].(string),
+			SecretKey: m["secret_key"].(string),
+			Profile:   m["profile"].(string),
+			Token:     m["token"].(string),
+		}
+		expandAssumeRole(m["assume_role"], &credentials)
+		config.Regions = append(config.Regions, name)
+		config.RegionCredentials[name] = credentials
+	}
+
+	endpointsSet := d.Get("endpoints").(*schema.Set)
+
+	for _, endpointsSetI := range endpointsSet.List() {
+		endpoints := endpointsSetI.(map[string]interface{})
+		for _, endpointServiceName := range endpointServiceNames {
+			config.Endpoints[endpointServiceName] = endpoints[endpointServiceName].(string)
+		}
+	}
+
+	if v, ok := d.GetOk("allowed_account_ids"); ok {
+		for _, accountIDRaw := range v.(*schema.Set).List() {
+			config.AllowedAccountIds = append(config.AllowedAccountIds, accountIDRaw.(string))
+		}
+	}
+
+	if v, ok := d.GetOk("forbidden_account_ids"); ok {
+		for _, accountIDRaw := range v.(*schema.Set).List() {
+			config.ForbiddenAccountIds = append(config.ForbiddenAccountIds, accountIDRaw.(string))
+		}
+	}
+
+	return config
+}
+
+// expandAssumeRole reads assume_role block into credentials
+func expandAssumeRole(raw interface{}, credentials *Credentials) {
+	if l, ok := raw.([]interface{}); ok && len(l) > 0 && l[0] != nil {
 		m := l[0].(map[string]interface{})
 
 		if v, ok := m["duration_seconds"].(int); ok && v != 0 {
-			config.AssumeRoleDurationSeconds = v
+			credentials.AssumeRoleDurationSeconds = v
 		}
 
 		if v, ok := m["external_id"].(string); ok && v != "" {
-			config.AssumeRoleExternalID = v
+			credentials.AssumeRoleExternalID = v
 		}
 
 		if v, ok := m["policy"].(string); ok && v != "" {
-			config.AssumeRolePolicy = v
+			credentials.AssumeRolePolicy = v
 		}
 
 		if policyARNSet, ok := m["policy_arns"].(*schema.Set); ok && policyARNSet.Len() > 0 {
@@ -430,20 +495,20 @@ func providerConfigure(d *schema.ResourceData, terraformVersion string) (interfa
 					continue
 				}
 
-				config.AssumeRolePolicyARNs = append(config.AssumeRolePolicyARNs, policyARN)
+				credentials.AssumeRolePolicyARNs = append(credentials.AssumeRolePolicyARNs, policyARN)
 			}
 		}
 
 		if v, ok := m["role_arn"].(string); ok && v != "" {
-			config.AssumeRoleARN = v
+			credentials.AssumeRoleARN = v
 		}
 
 		if v, ok := m["session_name"].(string); ok && v != "" {
-			config.AssumeRoleSessionName = v
+			credentials.AssumeRoleSessionName = v
 		}
 
 		if tagMapRaw, ok := m["tags"].(map[string]interface{}); ok && len(tagMapRaw) > 0 {
-			config.AssumeRoleTags = make(map[string]string)
+			credentials.AssumeRoleTags = make(map[string]string)
 
 			for k, vRaw := range tagMapRaw {
 				v, ok := vRaw.(string)
@@ -452,7 +517,7 @@ func providerConfigure(d *schema.ResourceData, terraformVersion string) (interfa
 					continue
 				}
 
-				config.AssumeRoleTags[k] = v
+				credentials.AssumeRoleTags[k] = v
 			}
 		}
 
@@ -464,35 +529,55 @@ func providerConfigure(d *schema.ResourceData, terraformVersion string) (interfa
 					continue
 				}
 
-				config.AssumeRoleTransitiveTagKeys = append(config.AssumeRoleTransitiveTagKeys, transitiveTagKey)
+				credentials.AssumeRoleTransitiveTagKeys = append(credentials.AssumeRoleTransitiveTagKeys, transitiveTagKey)
 			}
 		}
 
-		log.Printf("[INFO] assume_role configuration set: (ARN: %q, SessionID: %q, ExternalID: %q)", config.AssumeRoleARN, config.AssumeRoleSessionName, config.AssumeRoleExternalID)
-	}
-
-	endpointsSet := d.Get("endpoints").(*schema.Set)
-
-	for _, endpointsSetI := range endpointsSet.List() {
-		endpoints := endpointsSetI.(map[string]interface{})
-		for _, endpointServiceName := range endpointServiceNames {
-			config.Endpoints[endpointServiceName] = endpoints[endpointServiceName].(string)
-		}
-	}
-
-	if v, ok := d.GetOk("allowed_account_ids"); ok {
-		for _, accountIDRaw := range v.(*schema.Set).List() {
-			config.AllowedAccountIds = append(config.AllowedAccountIds, accountIDRaw.(string))
-		}
+		log.Printf("[INFO] assume_role configuration set: (ARN: %q, SessionID: %q, ExternalID: %q)", credentials.AssumeRoleARN, credentials.AssumeRoleSessionName, credentials.AssumeRoleExternalID)
 	}
+}
 
-	if v, ok := d.GetOk("forbidden_account_ids"); ok {
-		for _, accountIDRaw := range v.(*schema.Set).List() {
-			config.ForbiddenAccountIds = append(config.ForbiddenAccountIds, accountIDRaw.(string))
-		}
+// regionSchema is the region block with credentials overriding provider credentials
+func regionSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Optional:    true,
+		Description: descriptions["region"],
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"name": {
+					Type:        schema.TypeString,
+					Required:    true,
+					Description: descriptions["region_name"],
+				},
+				"access_key": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Default:     "",
+					Description: descriptions["access_key"],
+				},
+				"secret_key": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Default:     "",
+					Description: descriptions["secret_key"],
+				},
+				"profile": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Default:     "",
+					Description: descriptions["profile"],
+				},
+				"token": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Default:     "",
+					Description: descriptions["token"],
+				},
+				"assume_role": assumeRoleSchema(),
+			},
+		},
 	}
-
-	return config.Client()
 }
 
 func assumeRoleSchema() *schema.Schema {
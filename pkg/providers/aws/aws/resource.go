@@ -1,32 +1,92 @@
 package aws
 
 import (
+	"context"
+
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/resource"
 )
 
+const (
+	InstanceRemovalFieldName = "instance_removal"
+
+	// InstanceRemovalTerminate terminates instances through their auto scaling group
+	InstanceRemovalTerminate = "terminate"
+	// InstanceRemovalStandby moves instances to standby and stops them. Stopped instances keep their volumes
+	InstanceRemovalStandby = "standby"
+)
+
 type Failover struct {
 	resource.Failover
-	Project string
+	// InstanceRemoval is how single and standby mode failover removes instances from auto scaling groups
+	InstanceRemoval string
+}
+
+type getter interface {
+	Get(key string) interface{}
+}
+
+// fromGetter reads AWS failover attributes. Data sources do not have them
+func (f *Failover) fromGetter(d getter) {
+	f.InstanceRemoval, _ = d.Get(InstanceRemovalFieldName).(string)
+}
+
+func (f *Failover) FromSchema(d *schema.ResourceData) error {
+	if err := f.Failover.FromSchema(d); err != nil {
+		return err
+	}
+	f.fromGetter(d)
+	return nil
 }
 
 func (f *Failover) FromIDOrSchema(d *schema.ResourceData) error {
-	if id := d.Id(); id != "" {
-		err := resource.BsonUnPack(f, id)
-		if err != nil {
-			return err
-		}
-		f.Source = resource.FailoverSourceID
-		return nil
+	if err := f.Failover.FromIDOrSchema(d); err != nil {
+		return err
 	}
-	return f.FromSchema(d)
+	f.fromGetter(d)
+	return nil
+}
 
+func (f *Failover) FromResourceDiff(diff *schema.ResourceDiff) error {
+	if err := f.Failover.FromResourceDiff(diff); err != nil {
+		return err
+	}
+	f.fromGetter(diff)
+	return nil
+}
+
+// StandbyRemoval returns true if removed instances are kept in standby
+func (f Failover) StandbyRemoval() bool {
+	return f.InstanceRemoval == InstanceRemovalStandby
+}
+
+// legacyFailover is the failover stored in resource IDs packed with BsonPack
+type legacyFailover struct {
+	resource.Failover
+	Project string
+}
+
+func unpackLegacyID(id string) (resource.Failover, map[string]interface{}, error) {
+	failover := &legacyFailover{}
+	if err := resource.BsonUnPack(failover, id); err != nil {
+		return resource.Failover{}, nil, err
+	}
+	if err := failover.RestoreLegacyCounts(id); err != nil {
+		return resource.Failover{}, nil, err
+	}
+	return failover.Failover, nil, nil
 }
 
-func (f *Failover) FromID(id string) error {
-	return resource.BsonUnPack(f, id)
+func resourcePolkadotFailoverStateUpgradeV0(_ context.Context, rawState map[string]interface{}, _ interface{}) (map[string]interface{}, error) {
+	return resource.UpgradeStateV0(rawState, unpackLegacyID)
 }
 
-func (f *Failover) ID() (string, error) {
-	return resource.BsonPack(f)
+func resourcePolkadotFailoverStateUpgraders() []schema.StateUpgrader {
+	return []schema.StateUpgrader{
+		{
+			Version: 0,
+			Type:    resource.ResourceV0(nil).CoreConfigSchema().ImpliedType(),
+			Upgrade: resourcePolkadotFailoverStateUpgradeV0,
+		},
+	}
 }
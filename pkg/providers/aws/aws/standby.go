@@ -0,0 +1,109 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/engine"
+)
+
+// standbyChanges are standby instances returned to service and terminated per auto scaling group
+type standbyChanges struct {
+	exit      []engine.Group
+	terminate []engine.Group
+}
+
+// planStandbyInstances decides which standby instances are returned to service, so that running instances reach counts per location,
+// and which are terminated. If keep is true, remaining standby instances are kept while running and standby instances
+// of a location do not exceed its instances. Otherwise remaining standby instances are terminated
+func planStandbyInstances(groups []engine.Group, counts, instances []int, keep bool) standbyChanges {
+
+	sorted := make([]engine.Group, len(groups))
+	copy(sorted, groups)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	// missing are instances to return to service per location, kept are standby instances to keep per location
+	missing := make([]int, len(counts))
+	kept := make([]int, len(counts))
+
+	for idx, count := range counts {
+		missing[idx] = count
+		if keep && idx < len(instances) {
+			kept[idx] = instances[idx] - count
+		}
+	}
+
+	for _, group := range sorted {
+		if group.Location >= 0 && group.Location < len(counts) {
+			missing[group.Location] -= len(group.Instances)
+		}
+	}
+
+	changes := standbyChanges{}
+
+	for _, group := range sorted {
+		// groups outside of locations are left as they are
+		if group.Location < 0 || group.Location >= len(counts) || len(group.Standby) == 0 {
+			continue
+		}
+		standby := make([]string, len(group.Standby))
+		copy(standby, group.Standby)
+		sort.Strings(standby)
+
+		exit := engine.Group{Name: group.Name, Location: group.Location}
+		terminate := engine.Group{Name: group.Name, Location: group.Location}
+
+		for _, instance := range standby {
+			switch {
+			case missing[group.Location] > 0:
+				exit.Standby = append(exit.Standby, instance)
+				missing[group.Location]--
+			case kept[group.Location] > 0:
+				kept[group.Location]--
+			default:
+				terminate.Standby = append(terminate.Standby, instance)
+			}
+		}
+
+		if len(exit.Standby) > 0 {
+			changes.exit = append(changes.exit, exit)
+		}
+		if len(terminate.Standby) > 0 {
+			changes.terminate = append(changes.terminate, terminate)
+		}
+	}
+
+	return changes
+}
+
+// restoreStandbyInstances returns standby instances to service when failover counts increase and terminates
+// standby instances which are not needed any more. It runs after counts have been calculated
+func restoreStandbyInstances(ctx context.Context, b *backend, failover *Failover) error {
+
+	groups, err := b.ListGroups(ctx)
+	if err != nil {
+		return err
+	}
+
+	changes := planStandbyInstances(groups, failover.FailoverInstances, failover.Instances, failover.StandbyRemoval())
+
+	for _, group := range changes.exit {
+		log.Printf("[DEBUG] failover: Returning standby instances of group %q to service: %v", group.Name, group.Standby)
+		if err := b.ExitStandby(ctx, group); err != nil {
+			return fmt.Errorf("cannot return standby instances %v of group %q to service: %w", group.Standby, group.Name, err)
+		}
+	}
+
+	for _, group := range changes.terminate {
+		log.Printf("[DEBUG] failover: Terminating standby instances of group %q: %v", group.Name, group.Standby)
+		if err := b.TerminateStandby(ctx, group); err != nil {
+			return fmt.Errorf("cannot terminate standby instances %v of group %q: %w", group.Standby, group.Name, err)
+		}
+	}
+
+	return nil
+}
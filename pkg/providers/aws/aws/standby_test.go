@@ -0,0 +1,45 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/engine"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanStandbyInstances(t *testing.T) {
+	groups := []engine.Group{
+		{Name: "asg-1", Location: 0, Instances: []string{"i1"}, Standby: []string{"i3", "i2"}},
+		{Name: "asg-2", Location: 1, Standby: []string{"i4", "i5"}},
+		{Name: "asg-3", Location: 2, Standby: []string{"i6"}},
+		{Name: "asg-4", Location: -1, Standby: []string{"i7"}},
+	}
+
+	// failover mode goes back to distributed
+	changes := planStandbyInstances(groups, []int{3, 2, 1}, []int{3, 2, 1}, true)
+	require.Equal(t, []engine.Group{
+		{Name: "asg-1", Location: 0, Standby: []string{"i2", "i3"}},
+		{Name: "asg-2", Location: 1, Standby: []string{"i4", "i5"}},
+		{Name: "asg-3", Location: 2, Standby: []string{"i6"}},
+	}, changes.exit)
+	require.Empty(t, changes.terminate)
+
+	// counts do not change, standby instances are kept up to instances per location
+	changes = planStandbyInstances(groups, []int{1, 0, 0}, []int{2, 1, 1}, true)
+	require.Empty(t, changes.exit)
+	require.Equal(t, []engine.Group{
+		{Name: "asg-1", Location: 0, Standby: []string{"i3"}},
+		{Name: "asg-2", Location: 1, Standby: []string{"i5"}},
+	}, changes.terminate)
+
+	// instance removal goes back to terminate
+	changes = planStandbyInstances(groups, []int{2, 0, 0}, []int{2, 1, 1}, false)
+	require.Equal(t, []engine.Group{
+		{Name: "asg-1", Location: 0, Standby: []string{"i2"}},
+	}, changes.exit)
+	require.Equal(t, []engine.Group{
+		{Name: "asg-1", Location: 0, Standby: []string{"i3"}},
+		{Name: "asg-2", Location: 1, Standby: []string{"i4", "i5"}},
+		{Name: "asg-3", Location: 2, Standby: []string{"i6"}},
+	}, changes.terminate)
+}
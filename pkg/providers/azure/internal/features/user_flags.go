@@ -8,4 +8,6 @@ type UserFeatures struct {
 // PolkadotFailOverFeatures represents provider VMs features
 type PolkadotFailOverFeatures struct {
 	DeleteVmsWithAPIInSingleMode bool
+	// DeleteVmsInDataSource keeps legacy polkadot_failover data source behaviour deleting VMs in single mode
+	DeleteVmsInDataSource bool
 }
@@ -11,6 +11,11 @@ func schemaFeatures() *schema.Schema {
 			Type:     schema.TypeBool,
 			Optional: true,
 		},
+		"delete_vms_in_data_source": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Description: "Legacy behaviour. polkadot_failover data source deletes VMs besides the validator in single mode. Use polkadot_failover resource instead",
+		},
 	}
 
 	return &schema.Schema{
@@ -41,5 +46,9 @@ func expandFeatures(input []interface{}) features.UserFeatures {
 		expandedFeatures.PolkadotFailOverFeature.DeleteVmsWithAPIInSingleMode = v.(bool)
 	}
 
+	if v, ok := val["delete_vms_in_data_source"]; ok {
+		expandedFeatures.PolkadotFailOverFeature.DeleteVmsInDataSource = v.(bool)
+	}
+
 	return expandedFeatures
 }
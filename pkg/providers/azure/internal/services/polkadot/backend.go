@@ -0,0 +1,232 @@
+package polkadot
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2020-06-30/compute"
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/azure"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/engine"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/providers/azure/internal/clients"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/providers/azure/utils"
+)
+
+// backend is failover engine backend managing VM scale sets instances. Instances are identified by hostname.
+// VMs are deleted with API requests only if DeleteVmsWithAPIInSingleMode feature is enabled,
+// otherwise scale sets capacity is changed by failover instances numbers
+type backend struct {
+	client   *clients.Client
+	failover *AzureFailover
+	// vmIDs are VM IDs by VM scale set and hostname found by the last ListGroups call
+	vmIDs map[string]map[string]string
+	// repairs are automatic repairs policies disabled by SuspendSelfHealing by VM scale set
+	repairs map[string]compute.AutomaticRepairsPolicy
+}
+
+func newBackend(client *clients.Client, failover *AzureFailover) *backend {
+	return &backend{
+		client:   client,
+		failover: failover,
+		vmIDs:    map[string]map[string]string{},
+		repairs:  map[string]compute.AutomaticRepairsPolicy{},
+	}
+}
+
+func (b *backend) deleteWithAPI() bool {
+	return b.client.Features.PolkadotFailOverFeature.DeleteVmsWithAPIInSingleMode
+}
+
+func (b *backend) ListGroups(ctx context.Context) ([]engine.Group, error) {
+
+	vmss, err := azure.GetVirtualMachineScaleSetVMsWithClient(
+		ctx,
+		b.client.Polkadot.VMScaleSetsClient,
+		b.client.Polkadot.VMScaleSetVMsClient,
+		b.failover.Prefix,
+		b.failover.ResourceGroup,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("[ERROR] failover: Cannot get scale set VMs: %w", err)
+	}
+
+	var groups []engine.Group
+
+	for vmssName, vms := range vmss {
+		if len(vms) == 0 {
+			continue
+		}
+		group := engine.Group{Name: vmssName, Location: -1}
+		ids := map[string]string{}
+		for _, vm := range vms {
+			name := vmHostname(vm)
+			if name == "" {
+				name = path.Base(*vm.ID)
+			}
+			if vm.Location != nil {
+				group.Location = helpers.FindStrIndex(azure.Normalize(*vm.Location), b.failover.Locations)
+			}
+			ids[name] = path.Base(*vm.ID)
+			group.Instances = append(group.Instances, name)
+		}
+		b.vmIDs[vmssName] = ids
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
+
+func (b *backend) GetValidatorMetrics(ctx context.Context, groups []engine.Group) ([]engine.Metric, error) {
+
+	vmScaleSetNames := make([]string, 0, len(groups))
+	for _, group := range groups {
+		vmScaleSetNames = append(vmScaleSetNames, group.Name)
+	}
+
+	values, err := azure.GetValidatorMetricValues(
+		ctx,
+		b.client.Polkadot.MetricsClient,
+		vmScaleSetNames,
+		b.failover.ResourceGroup,
+		b.failover.MetricName,
+		b.failover.MetricNameSpace,
+		b.failover.GetValidatorMetricCheck().GetAggregation(),
+		b.failover.GetMetricFreshness(),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := make([]engine.Metric, 0, len(values))
+
+	for _, value := range values {
+		metrics = append(metrics, engine.Metric{
+			Group:    value.ScaleSetName,
+			Instance: value.Hostname,
+			Value:    value.Value,
+			// metric without host can not be matched to a VM
+			Missing:   value.Missing || value.Hostname == "",
+			Stale:     value.Stale,
+			Timestamp: value.Timestamp,
+		})
+	}
+
+	return metrics, nil
+}
+
+// DeleteInstances deletes VMs with API requests without changing scale set capacity
+func (b *backend) DeleteInstances(ctx context.Context, group engine.Group) error {
+
+	if !b.deleteWithAPI() {
+		return nil
+	}
+
+	ids, ok := b.vmIDs[group.Name]
+	if !ok {
+		return fmt.Errorf("cannot find VM scale set %q", group.Name)
+	}
+
+	vmIDs := make([]string, 0, len(group.Instances))
+	for _, name := range group.Instances {
+		vmIDs = append(vmIDs, ids[name])
+	}
+
+	return azure.DeleteVMs(
+		ctx,
+		b.client.Polkadot.VMScaleSetsClient,
+		b.failover.ResourceGroup,
+		group.Name,
+		vmIDs,
+		false,
+	)
+}
+
+// InstanceAddresses returns private IP addresses of VMs found by the last ListGroups call by hostname
+func (b *backend) InstanceAddresses(ctx context.Context, groups []engine.Group) (map[string]string, error) {
+
+	addresses := map[string]string{}
+
+	for _, group := range groups {
+		ids, ok := b.vmIDs[group.Name]
+		if !ok {
+			return nil, fmt.Errorf("cannot find VM scale set %q", group.Name)
+		}
+		ips, err := azure.GetVirtualMachineScaleSetPrivateIPs(ctx, b.client.Polkadot.InterfacesClient, b.failover.ResourceGroup, group.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range group.Instances {
+			if ip, ok := ips[ids[name]]; ok {
+				addresses[name] = ip
+			}
+		}
+	}
+
+	return addresses, nil
+}
+
+func (b *backend) WaitForCount(ctx context.Context, count int) error {
+
+	if !b.deleteWithAPI() {
+		return nil
+	}
+
+	_, err := azure.WaitForVirtualMachineScaleSetVMsWithClient(
+		ctx,
+		b.client.Polkadot.VMScaleSetsClient,
+		b.client.Polkadot.VMScaleSetVMsClient,
+		b.failover.Prefix,
+		b.failover.ResourceGroup,
+		count,
+		5,
+	)
+
+	return err
+}
+
+// SuspendSelfHealing disables automatic repairs of VM scale sets. Scale sets are not changed if VMs are not deleted with API requests
+func (b *backend) SuspendSelfHealing(ctx context.Context, groups []engine.Group) error {
+
+	if !b.deleteWithAPI() {
+		return nil
+	}
+
+	for _, group := range groups {
+		policy, err := azure.GetAutomaticRepairsPolicy(ctx, b.client.Polkadot.VMScaleSetsClient, b.failover.ResourceGroup, group.Name)
+		if err != nil {
+			return err
+		}
+		if policy == nil || policy.Enabled == nil || !*policy.Enabled {
+			continue
+		}
+		disabled := *policy
+		disabled.Enabled = utils.Bool(false)
+		if err := azure.SetAutomaticRepairsPolicy(ctx, b.client.Polkadot.VMScaleSetsClient, b.failover.ResourceGroup, group.Name, disabled); err != nil {
+			return err
+		}
+		b.repairs[group.Name] = *policy
+	}
+
+	return nil
+}
+
+// ResumeSelfHealing restores automatic repairs policies disabled by SuspendSelfHealing
+func (b *backend) ResumeSelfHealing(ctx context.Context) error {
+
+	result := &multierror.Error{}
+
+	for vmScaleSetName, policy := range b.repairs {
+		if err := azure.SetAutomaticRepairsPolicy(ctx, b.client.Polkadot.VMScaleSetsClient, b.failover.ResourceGroup, vmScaleSetName, policy); err != nil {
+			result = multierror.Append(result, err)
+			continue
+		}
+		delete(b.repairs, vmScaleSetName)
+	}
+
+	return result.ErrorOrNil()
+}
@@ -1,56 +1,34 @@
 package polkadot
 
 import (
-	"fmt"
-	"path"
-	"strings"
-
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2020-06-30/compute"
 	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
 
 	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/azure"
 )
 
-type vmssWithInstances struct {
-	vmssName string
-	vmsIDs   []string
-}
-
-type vmssWithInstancesList []vmssWithInstances
-
-func (v vmssWithInstancesList) String() string {
-	var pairs []string
-	for _, vmss := range v {
-		pairs = append(pairs, fmt.Sprintf("%s => %s", vmss.vmssName, strings.Join(vmss.vmsIDs, ", ")))
-	}
-	return strings.Join(pairs, ". ")
-}
-
-func (v vmssWithInstancesList) Size() int {
-	s := 0
-	for _, vmss := range v {
-		s += len(vmss.vmsIDs)
-	}
-	return s
-}
-
-func getVmsToDelete(vmScaleSetVMs azure.VMSMap, validatorHostname string) vmssWithInstancesList {
-
-	var results vmssWithInstancesList
-
-	for vmssName, vms := range vmScaleSetVMs {
-		vmss := vmssWithInstances{vmssName: vmssName}
+// runningVMs returns number of VMs per location
+func runningVMs(vmss azure.VMSMap, locations []string) []int {
+	counts := make([]int, len(locations))
+	for _, vms := range vmss {
 		for _, vm := range vms {
-			vmHostname := vm.OsProfile.ComputerName
-			if vmHostname == nil || *vmHostname != validatorHostname {
-				vmss.vmsIDs = append(vmss.vmsIDs, path.Base(*vm.ID))
+			if vm.Location == nil {
+				continue
+			}
+			if locationIdx := helpers.FindStrIndex(azure.Normalize(*vm.Location), locations); locationIdx != -1 {
+				counts[locationIdx]++
 			}
-		}
-		if len(vmss.vmsIDs) > 0 {
-			results = append(results, vmss)
 		}
 	}
+	return counts
+}
 
-	return results
+// vmHostname returns VM computer name or empty string if it is not known
+func vmHostname(vm compute.VirtualMachineScaleSetVM) string {
+	if vm.VirtualMachineScaleSetVMProperties != nil && vm.OsProfile != nil && vm.OsProfile.ComputerName != nil {
+		return *vm.OsProfile.ComputerName
+	}
+	return ""
 }
 
 func getValidatorLocation(vmScaleSetVMs azure.VMSMap, locations []string, validatorScaleSetName string) int {
@@ -5,8 +5,7 @@ import (
 	"log"
 	"time"
 
-	"github.com/Azure/azure-sdk-for-go/profiles/2019-03-01/resources/mgmt/insights"
-
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/engine"
 	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/resource"
 
 	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/azure"
@@ -20,7 +19,7 @@ import (
 
 func dataSourcePolkadotFailOver() *schema.Resource {
 
-	polkadotSchema := resource.GetPolkadotSchema()
+	polkadotSchema := resource.GetPolkadotDataSourceSchema()
 	polkadotSchema[ResourceGroupFieldName] = azure.SchemaResourceGroupName()
 
 	return &schema.Resource{
@@ -28,103 +27,78 @@ func dataSourcePolkadotFailOver() *schema.Resource {
 		ReadContext: dateSourcePolkadotFailOverRead,
 
 		Timeouts: &schema.ResourceTimeout{
-			Create: schema.DefaultTimeout(time.Minute * 90),
-			Update: schema.DefaultTimeout(time.Minute * 90),
-			Read:   schema.DefaultTimeout(time.Minute * 30),
-			Delete: schema.DefaultTimeout(time.Minute * 30),
+			Read: schema.DefaultTimeout(time.Minute * 90),
 		},
 
 		Schema: polkadotSchema,
 	}
 }
 
-func dateSourcePolkadotFailOverRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+// dataSourceFailoverResult finds running VMs and the validator. VMs are deleted only with the legacy
+// delete_vms_in_data_source feature in single mode, otherwise the data source is read-only.
+// Counts are the same as before the data source became read-only: instances in distributed mode and
+// the validator location in single mode. Standby mode reports running VMs
+func dataSourceFailoverResult(ctx context.Context, backend engine.CloudBackend, failover *AzureFailover, deleteVms bool) (engine.Result, error) {
 
-	failover := &AzureFailover{}
-	_ = failover.FromSchema(d)
+	e := engine.New(backend, &failover.Failover)
 
-	if failover.IsDistributedMode() {
-		log.Printf("[DEBUG] failover: Read. Failover mode is %q. Using predefined number of instances", failover.FailoverMode)
-		failover.SetCounts(failover.Instances...)
-		return failover.SetSchemaValuesDiag(d)
+	if deleteVms && failover.IsSingleMode() {
+		log.Printf("[WARNING] failover: Data source read. Legacy feature deletes VMs besides the validator")
+		return e.Run(ctx)
 	}
 
-	positions := make([]int, len(failover.Locations))
-
-	client := meta.(*clients.Client)
-
-	ctx, cancel := timeouts.ForCreate(ctx, d)
-	defer cancel()
-
-	features := meta.(*clients.Client).Features.PolkadotFailOverFeature
-
-	log.Printf("[DEBUG] failover: Read. Getting instances list...")
-
-	vmScaleSetNames, err := azure.GetVMScaleSetNames(
-		ctx,
-		client.Polkadot.VMScaleSetsClient,
-		failover.ResourceGroup,
-		failover.Prefix,
-	)
-
+	result, err := e.Inspect(ctx)
 	if err != nil {
-		return diag.Errorf("[ERROR] failover: Cannot get VM scale sets: %v", err)
+		return result, err
 	}
 
-	log.Printf("[DEBUG] failover: Read. Found %d VM scale sets", len(vmScaleSetNames))
-
-	if len(vmScaleSetNames) == 0 {
-		failover.FillDefaultCountsIfNotSet()
-		id, err := failover.ID()
-		if err != nil {
-			return diag.FromErr(err)
+	switch {
+	case failover.IsDistributedMode():
+		failover.SetCounts(failover.Instances...)
+	case failover.IsSingleMode():
+		counts := make([]int, len(failover.Locations))
+		if result.Validator.Location >= 0 && result.Validator.Location < len(counts) {
+			counts[result.Validator.Location] = 1
 		}
-		d.SetId(id)
-		return failover.SetSchemaValuesDiag(d)
+		failover.SetCounts(counts...)
+		failover.FillDefaultCountsIfNotSet()
+	default:
+		failover.SetCounts(result.Running...)
 	}
 
-	aggregator := insights.Maximum
+	return result, nil
+}
 
-	validator, err := azure.GetCurrentValidator(
-		ctx,
-		client.Polkadot.MetricsClient,
-		vmScaleSetNames,
-		failover.ResourceGroup,
-		failover.MetricName,
-		failover.MetricNameSpace,
-		aggregator,
-	)
+func dateSourcePolkadotFailOverRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 
-	if err != nil {
+	failover := &AzureFailover{}
+	if err := failover.FromSchema(d); err != nil {
 		return diag.FromErr(err)
 	}
 
-	log.Printf("[DEBUG] failover: Read. Found validator scale set %q, host %q", validator.ScaleSetName, validator.Hostname)
+	client := meta.(*clients.Client)
+
+	ctx, cancel := timeouts.ForRead(ctx, d)
+	defer cancel()
 
-	vmss, err := azure.GetVirtualMachineScaleSetVMsWithClient(
-		ctx,
-		client.Polkadot.VMScaleSetsClient,
-		client.Polkadot.VMScaleSetVMsClient,
-		failover.Prefix,
-		failover.ResourceGroup,
-	)
+	features := client.Features.PolkadotFailOverFeature
+
+	log.Printf("[DEBUG] failover: Data source read. Getting instances list...")
+
+	result, err := dataSourceFailoverResult(ctx, newBackend(client, failover), failover, features.DeleteVmsInDataSource)
 
 	if err != nil {
-		return diag.Errorf("[ERROR] failover: Cannot get scale set VMs: %v", err)
+		return diag.FromErr(err)
 	}
 
-	if locationIDx := getValidatorLocation(vmss, failover.Locations, validator.ScaleSetName); locationIDx != -1 {
-		positions[locationIDx] = 1
-	}
+	log.Printf("[DEBUG] failover: Data source read. Set instance numbers per region: %v", failover.FailoverInstances)
 
-	if features.DeleteVmsWithAPIInSingleMode {
-		if err := deleteVms(ctx, client, failover, vmss, validator, false); err != nil {
-			return diag.FromErr(err)
-		}
+	detected := result.Validator.Detected(failover.Locations)
+
+	if err := detected.SetSchemaValues(d); err != nil {
+		return diag.FromErr(err)
 	}
 
-	failover.SetCounts(positions...)
-	failover.FillDefaultCountsIfNotSet()
 	id, err := failover.ID()
 	if err != nil {
 		return diag.FromErr(err)
@@ -0,0 +1,91 @@
+package polkadot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/engine"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/resource"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testDataSourceFailover() *AzureFailover {
+	return &AzureFailover{
+		Failover: resource.Failover{
+			Prefix:             "test",
+			FailoverMode:       resource.FailOverModeSingle,
+			Instances:          []int{2, 2, 2},
+			Locations:          []string{"centralus", "eastus", "westus"},
+			OnValidatorUnknown: resource.ValidatorUnknownDeleteAll,
+		},
+		ResourceGroup: "test",
+	}
+}
+
+func testDataSourceBackend() *engine.FakeBackend {
+	backend := engine.NewFakeBackend(
+		engine.Group{Name: "vmss1", Location: 0, Instances: []string{"host1", "host2"}},
+		engine.Group{Name: "vmss2", Location: 1, Instances: []string{"host3"}},
+		engine.Group{Name: "vmss3", Location: 2, Instances: []string{"host4", "host5"}},
+	)
+	backend.Metrics["host3"] = 1
+	return backend
+}
+
+func TestDataSourceFailoverResultReadOnly(t *testing.T) {
+	backend := testDataSourceBackend()
+	failover := testDataSourceFailover()
+
+	result, err := dataSourceFailoverResult(context.Background(), backend, failover, false)
+	require.NoError(t, err)
+	require.Equal(t, engine.Validator{Group: "vmss2", Instance: "host3", Location: 1}, result.Validator)
+	require.Equal(t, []int{0, 1, 0}, failover.FailoverInstances)
+	require.Empty(t, backend.Deleted)
+	require.Empty(t, backend.WaitedCounts)
+
+	// validator errors are reported by computed attributes
+	delete(backend.Metrics, "host3")
+	failover = testDataSourceFailover()
+	result, err = dataSourceFailoverResult(context.Background(), backend, failover, false)
+	require.NoError(t, err)
+	require.Error(t, result.ValidatorErr)
+	require.Equal(t, []int{1, 0, 0}, failover.FailoverInstances)
+	require.Empty(t, backend.Deleted)
+}
+
+func TestDataSourceFailoverResultCounts(t *testing.T) {
+	backend := testDataSourceBackend()
+
+	failover := testDataSourceFailover()
+	failover.FailoverMode = resource.FailOverModeDistributed
+	_, err := dataSourceFailoverResult(context.Background(), backend, failover, false)
+	require.NoError(t, err)
+	require.Equal(t, []int{2, 2, 2}, failover.FailoverInstances)
+
+	failover = testDataSourceFailover()
+	failover.FailoverMode = resource.FailOverModeStandby
+	_, err = dataSourceFailoverResult(context.Background(), backend, failover, false)
+	require.NoError(t, err)
+	require.Equal(t, []int{2, 1, 2}, failover.FailoverInstances)
+	require.Empty(t, backend.Deleted)
+}
+
+func TestDataSourceFailoverResultLegacyDelete(t *testing.T) {
+	backend := testDataSourceBackend()
+	failover := testDataSourceFailover()
+
+	_, err := dataSourceFailoverResult(context.Background(), backend, failover, true)
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 1, 0}, failover.FailoverInstances)
+	require.Equal(t, []string{"host1", "host2", "host4", "host5"}, backend.Deleted)
+
+	// legacy feature deletes VMs in single mode only
+	backend = testDataSourceBackend()
+	failover = testDataSourceFailover()
+	failover.FailoverMode = resource.FailOverModeDistributed
+
+	_, err = dataSourceFailoverResult(context.Background(), backend, failover, true)
+	require.NoError(t, err)
+	require.Empty(t, backend.Deleted)
+}
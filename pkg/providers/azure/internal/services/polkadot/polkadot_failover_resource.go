@@ -3,15 +3,16 @@ package polkadot
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
+	"strings"
 	"time"
 
-	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/fanout"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/engine"
 
 	helperErrors "github.com/protofire/polkadot-failover-mechanism/pkg/helpers/errors"
 
-	"github.com/Azure/azure-sdk-for-go/profiles/2019-03-01/resources/mgmt/insights"
-
 	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/resource"
 
 	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/azure"
@@ -20,12 +21,13 @@ import (
 	"github.com/protofire/polkadot-failover-mechanism/pkg/providers/azure/internal/clients"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func resourcePolkadotFailOver() *schema.Resource {
 
-	polkadotSchema := resource.GetPolkadotSchema()
+	polkadotSchema := resource.GetPolkadotResourceSchema()
 	polkadotSchema[ResourceGroupFieldName] = azure.SchemaResourceGroupName()
 
 	return &schema.Resource{
@@ -35,6 +37,10 @@ func resourcePolkadotFailOver() *schema.Resource {
 		UpdateContext: resourcePolkadotFailoverCreateOrUpdate,
 		DeleteContext: resourcePolkadotFailoverDelete,
 
+		Importer: &schema.ResourceImporter{
+			StateContext: resourcePolkadotFailoverImport,
+		},
+
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(time.Minute * 90),
 			Update: schema.DefaultTimeout(time.Minute * 90),
@@ -43,95 +49,16 @@ func resourcePolkadotFailOver() *schema.Resource {
 		},
 
 		Schema: polkadotSchema,
-	}
-}
-
-func deleteVms(
-	ctx context.Context,
-	client *clients.Client,
-	failover *AzureFailover,
-	vms azure.VMSMap,
-	validator azure.Validator,
-	updateVMssCapacity bool,
-) error {
-
-	vmsToDelete := getVmsToDelete(vms, validator.Hostname)
-	if vmsToDelete.Size() == vms.Size() {
-		log.Printf("[DEBUG] failover: Create. We are going to delete all vm instances: %d. Validator: %#v", vmsToDelete.Size(), validator)
-	}
-	log.Printf("[DEBUG] failover: Create. We will delete %d instances with API requests: %s", vmsToDelete.Size(), vmsToDelete)
-
-	var vmsToDeleteInf []interface{}
-	for _, vmss := range vmsToDelete {
-		vmsToDeleteInf = append(vmsToDeleteInf, vmss)
-	}
-
-	if err := fanout.ReadErrorsChannel(fanout.ConcurrentResponseErrors(ctx, func(ctx context.Context, value interface{}) error {
-		vmss := value.(vmssWithInstances)
-		return azure.DeleteVMs(
-			ctx,
-			client.Polkadot.VMScaleSetsClient,
-			failover.ResourceGroup,
-			vmss.vmssName,
-			vmss.vmsIDs,
-			updateVMssCapacity,
-		)
-	}, vmsToDeleteInf...)); err != nil {
-		return err
-	}
-
-	waitForCount := 1
-	if validator.ScaleSetName == "" {
-		waitForCount = 0
-	}
-
-	log.Printf("[DEBUG] failover: Create. Waiting for VMs count: %d", waitForCount)
-
-	vmss, err := azure.WaitForVirtualMachineScaleSetVMsWithClient(
-		ctx,
-		client.Polkadot.VMScaleSetsClient,
-		client.Polkadot.VMScaleSetVMsClient,
-		failover.Prefix,
-		failover.ResourceGroup,
-		waitForCount,
-		5,
-	)
-	if err != nil {
-		return err
-	}
-
-	log.Printf("[DEBUG] failover: Create. Ensured VMs count: %d", waitForCount)
 
-	var vmScaleSetNames []string
+		SchemaVersion:  resource.SchemaVersion,
+		StateUpgraders: resourcePolkadotFailoverStateUpgraders(),
 
-	for name, vms := range vmss {
-		if len(vms) > 0 {
-			vmScaleSetNames = append(vmScaleSetNames, name)
-		}
+		CustomizeDiff: customdiff.All(
+			resource.CustomizeDiff,
+			resource.CustomizeDiffPlan(resourcePolkadotFailoverPlan),
+			resource.CustomizeDiffValidator,
+		),
 	}
-
-	if validator.ScaleSetName != "" && len(vmScaleSetNames) > 0 {
-
-		log.Printf("[DEBUG] failover: Create. Waiting for validator...")
-
-		validator, err := azure.WaitForValidator(
-			ctx,
-			client.Polkadot.MetricsClient,
-			vmScaleSetNames,
-			failover.ResourceGroup,
-			failover.MetricName,
-			failover.MetricNameSpace,
-			5,
-		)
-		if err != nil {
-			return err
-		}
-
-		log.Printf("[DEBUG] failover: Create. Ensured validator: %#v", validator)
-	}
-
-	return nil
-
 }
 
 func resourcePolkadotFailoverRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -148,16 +75,6 @@ func resourcePolkadotFailoverRead(ctx context.Context, d *schema.ResourceData, m
 		return nil
 	}
 
-	if failover.IsDistributedMode() {
-		log.Printf(
-			"[DEBUG] failover: Read. Failover mode is %q. Using predefined number of instances %d",
-			failover.FailoverMode,
-			failover.Instances,
-		)
-		failover.SetCounts(failover.Instances...)
-		return failover.SetSchemaValuesDiag(d)
-	}
-
 	log.Printf("[DEBUG] failover: Read. Failover mode is %q", failover.FailoverMode)
 
 	client := meta.(*clients.Client)
@@ -181,39 +98,57 @@ func resourcePolkadotFailoverRead(ctx context.Context, d *schema.ResourceData, m
 
 	log.Printf("[DEBUG] failover: Create. Found %d virtual machines in %d virtual machine scale sets", vmss.Size(), len(vmss))
 
-	var vmScaleSetNames []string
+	var validatorErr error
 
-	for name, vms := range vmss {
-		if len(vms) > 0 {
-			vmScaleSetNames = append(vmScaleSetNames, name)
-		}
-	}
-
-	validator, err := azure.GetCurrentValidator(
-		ctx,
-		client.Polkadot.MetricsClient,
-		vmScaleSetNames,
-		failover.ResourceGroup,
-		failover.MetricName,
-		failover.MetricNameSpace,
-		insights.Maximum,
-	)
+	validator, err := getDetectedValidator(ctx, client, failover, vmss)
 
 	if err != nil {
 		validatorError := &helperErrors.ValidatorError{}
 		if errors.As(err, validatorError) {
 			log.Printf("[WARNING] failover: Read. Cannot get validator: %s", validatorError)
+			validatorErr = err
 		} else {
 			log.Printf("[ERROR] failover: Read. Cannot get validator: %s", err)
 			return diag.FromErr(err)
 		}
 	} else {
-		log.Printf("[DEBUG] failover: Read. Found validator scale set %q, host %q", validator.ScaleSetName, validator.Hostname)
+		log.Printf("[DEBUG] failover: Read. Found validator scale set %q, host %q", validator.Group, validator.Instance)
+	}
+
+	locationIDx := validator.Location
+	detected := validator.Detected(failover.Locations)
+
+	if err := detected.SetSchemaValues(d); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if failover.IsDistributedMode() {
+		log.Printf(
+			"[DEBUG] failover: Read. Failover mode is %q. Using predefined number of instances %d",
+			failover.FailoverMode,
+			failover.Instances,
+		)
+		failover.SetCounts(failover.Instances...)
+		return failover.SetSchemaValuesDiag(d)
+	}
+
+	if failover.DryRun {
+		failover.SetCounts(runningVMs(vmss, failover.Locations)...)
+		failover.FillDefaultCountsIfNotSet()
+		log.Printf("[DEBUG] failover: Read. Dry run. Set running instance numbers per region: %v", failover.FailoverInstances)
+		return failover.SetSchemaValuesDiag(d)
+	}
+
+	if validatorErr != nil && failover.OnValidatorUnknown != resource.ValidatorUnknownDeleteAll && !failover.IsNotSet() {
+		log.Printf("[DEBUG] failover: Read. Policy %q keeps instance numbers per region: %v", failover.OnValidatorUnknown, failover.FailoverInstances)
+		return failover.SetSchemaValuesDiag(d)
 	}
 
 	log.Printf("[DEBUG] failover: Read. Getting instances list...")
 
-	if locationIDx := getValidatorLocation(vmss, failover.Locations, validator.ScaleSetName); locationIDx != -1 {
+	if failover.IsStandbyMode() {
+		positions = failover.StandbyCounts(locationIDx)
+	} else if locationIDx != -1 {
 		positions[locationIDx] = 1
 	}
 
@@ -226,6 +161,47 @@ func resourcePolkadotFailoverRead(ctx context.Context, d *schema.ResourceData, m
 
 }
 
+// getDetectedValidator finds the validator with validator_detection sources. ValidatorError is returned if validator has not been detected
+func getDetectedValidator(ctx context.Context, client *clients.Client, failover *AzureFailover, vmss azure.VMSMap) (engine.Validator, error) {
+
+	if !failover.DetectsWithMetricsOnly() {
+		result, err := engine.New(newBackend(client, failover), &failover.Failover).Inspect(ctx)
+		if err != nil {
+			return engine.Validator{Location: -1}, err
+		}
+		return result.Validator, result.ValidatorErr
+	}
+
+	var vmScaleSetNames []string
+
+	for name, vms := range vmss {
+		if len(vms) > 0 {
+			vmScaleSetNames = append(vmScaleSetNames, name)
+		}
+	}
+
+	validator, err := azure.GetCurrentValidator(
+		ctx,
+		client.Polkadot.MetricsClient,
+		vmScaleSetNames,
+		failover.ResourceGroup,
+		failover.MetricName,
+		failover.MetricNameSpace,
+		failover.GetValidatorMetricCheck(),
+		failover.GetMetricFreshness(),
+	)
+
+	if err != nil {
+		return engine.Validator{Location: -1}, err
+	}
+
+	return engine.Validator{
+		Group:    validator.ScaleSetName,
+		Instance: validator.Hostname,
+		Location: getValidatorLocation(vmss, failover.Locations, validator.ScaleSetName),
+	}, nil
+}
+
 func resourcePolkadotFailoverCreateOrUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 
 	client := meta.(*clients.Client)
@@ -233,8 +209,6 @@ func resourcePolkadotFailoverCreateOrUpdate(ctx context.Context, d *schema.Resou
 	ctx, cancel := timeouts.ForCreate(ctx, d)
 	defer cancel()
 
-	features := meta.(*clients.Client).Features.PolkadotFailOverFeature
-
 	failover := &AzureFailover{}
 	err := failover.FromIDOrSchema(d)
 
@@ -259,7 +233,73 @@ func resourcePolkadotFailoverCreateOrUpdate(ctx context.Context, d *schema.Resou
 
 	log.Printf("[DEBUG] failover: Create. Failover mode is %q", failover.FailoverMode)
 
-	positions := make([]int, len(failover.Locations))
+	// delete all VMs besides the validator and standbys. In case we did not find the validator, or we found multiple validators,
+	// on_validator_unknown policy decides whether all VMs are deleted
+	result, err := engine.New(newBackend(client, failover), &failover.Failover).Run(ctx)
+
+	var abort resource.AbortError
+	if errors.As(err, &abort) {
+		return abort.Diagnostics()
+	}
+
+	plan := result.Plan(len(failover.Locations))
+	plan.DryRun = failover.DryRun
+
+	if err := plan.SetSchemaValues(d, failover.Locations); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	log.Printf("[DEBUG] failover: Create. Set instance numbers per region: %v", failover.FailoverInstances)
+
+	id, err := failover.ID()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(id)
+	return resourcePolkadotFailoverRead(ctx, d, meta)
+
+}
+
+// resourcePolkadotFailoverPlan runs single and standby mode failover discovery while planning
+func resourcePolkadotFailoverPlan(ctx context.Context, diff *schema.ResourceDiff, meta interface{}) (resource.FailoverPlan, []string, error) {
+
+	client := meta.(*clients.Client)
+
+	failover := &AzureFailover{}
+	if err := failover.FromResourceDiff(diff); err != nil {
+		return resource.FailoverPlan{}, nil, err
+	}
+
+	result, err := engine.New(newBackend(client, failover), &failover.Failover).Discover(ctx)
+	if err != nil {
+		return resource.FailoverPlan{}, nil, err
+	}
+
+	return result.Plan(len(failover.Locations)), failover.Locations, nil
+}
+
+// resourcePolkadotFailoverImport discovers VM scale sets by prefix and restores counts from running VMs.
+// Import ID is <resource group>/ followed by the common import ID
+func resourcePolkadotFailoverImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+
+	client := meta.(*clients.Client)
+
+	parts := strings.SplitN(d.Id(), resource.IDSeparator, 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return nil, fmt.Errorf("cannot parse import ID %q. Expected <resource group>/%s", d.Id(), resource.ImportIDFormat)
+	}
+
+	imported, err := resource.ParseImportID(parts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	failover := &AzureFailover{Failover: imported, ResourceGroup: parts[0]}
+	failover.Locations = azure.NormalizeSlice(failover.Locations)
 
 	vmss, err := azure.GetVirtualMachineScaleSetVMsWithClient(
 		ctx,
@@ -270,10 +310,12 @@ func resourcePolkadotFailoverCreateOrUpdate(ctx context.Context, d *schema.Resou
 	)
 
 	if err != nil {
-		return diag.Errorf("[ERROR] failover: Cannot get scale set VMs: %v", err)
+		return nil, fmt.Errorf("cannot get scale set VMs: %w", err)
 	}
 
-	log.Printf("[DEBUG] failover: Create. Found %d virtual machines in %d virtual machine scale sets", vmss.Size(), len(vmss))
+	log.Printf("[DEBUG] failover: Import. Found %d virtual machines in %d virtual machine scale sets", vmss.Size(), len(vmss))
+
+	running := make([]int, len(failover.Locations))
 
 	var vmScaleSetNames []string
 
@@ -281,84 +323,55 @@ func resourcePolkadotFailoverCreateOrUpdate(ctx context.Context, d *schema.Resou
 		if len(vms) > 0 {
 			vmScaleSetNames = append(vmScaleSetNames, name)
 		}
-	}
-
-	if len(vmScaleSetNames) == 0 {
-		failover.SetCounts(positions...)
-		failover.FillDefaultCountsIfNotSet()
-		id, err := failover.ID()
-		if err != nil {
-			return diag.FromErr(err)
+		for _, vm := range vms {
+			if locationIdx := helpers.FindStrIndex(azure.Normalize(*vm.Location), failover.Locations); locationIdx != -1 {
+				running[locationIdx]++
+			}
 		}
-		d.SetId(id)
-		return resourcePolkadotFailoverRead(ctx, d, meta)
 	}
 
-	log.Printf("[DEBUG] failover: Create. Getting validator...")
+	log.Printf("[DEBUG] failover: Import. Found instance numbers per region: %v", running)
 
-	validator, err := azure.GetCurrentValidator(
-		ctx,
-		client.Polkadot.MetricsClient,
-		vmScaleSetNames,
-		failover.ResourceGroup,
-		failover.MetricName,
-		failover.MetricNameSpace,
-		insights.Maximum,
-	)
+	validatorLocation := -1
 
-	if err != nil {
-		validatorError := &helperErrors.ValidatorError{}
-		if errors.As(err, validatorError) {
-			log.Printf("[WARNING] failover: Create. Cannot get validator: %s", validatorError)
-		} else {
-			log.Printf("[ERROR] failover: Create. Cannot get validator: %s", err)
-			return diag.FromErr(err)
-		}
-	} else {
-		log.Printf("[DEBUG] failover: Create. Found validator scale set %q, host %q", validator.ScaleSetName, validator.Hostname)
-	}
-
-	if validator.ScaleSetName != "" {
-		log.Printf("[DEBUG] failover: Create. Found validator %#v", validator)
-	} else {
-		log.Printf("[DEBUG] failover: Create. Did not find validator")
-	}
-
-	if features.DeleteVmsWithAPIInSingleMode {
-		if err := deleteVms(ctx, client, failover, vmss, validator, false); err != nil {
-			return diag.FromErr(err)
-		}
-		vmss, err := azure.GetVirtualMachineScaleSetVMsWithClient(
+	if len(vmScaleSetNames) > 0 {
+		validator, err := azure.GetCurrentValidator(
 			ctx,
-			client.Polkadot.VMScaleSetsClient,
-			client.Polkadot.VMScaleSetVMsClient,
-			failover.Prefix,
+			client.Polkadot.MetricsClient,
+			vmScaleSetNames,
 			failover.ResourceGroup,
+			failover.MetricName,
+			failover.MetricNameSpace,
+			failover.GetValidatorMetricCheck(),
+			failover.GetMetricFreshness(),
 		)
-
 		if err != nil {
-			return diag.Errorf("[ERROR] failover: Cannot get scale set VMs: %v", err)
+			log.Printf("[WARNING] failover: Import. Cannot get validator: %s", err)
+		} else {
+			log.Printf("[DEBUG] failover: Import. Found validator scale set %q, host %q", validator.ScaleSetName, validator.Hostname)
+			validatorLocation = getValidatorLocation(vmss, failover.Locations, validator.ScaleSetName)
 		}
-
-		log.Printf("[DEBUG] failover: Create. Found %d virtual machines in %d virtual machine scale sets", vmss.Size(), len(vmss))
 	}
 
-	if locationIDx := getValidatorLocation(vmss, failover.Locations, validator.ScaleSetName); locationIDx != -1 {
-		positions[locationIDx] = 1
+	failover.SetImportedCounts(running, validatorLocation)
+
+	log.Printf("[DEBUG] failover: Import. Set instance numbers per region: %v", failover.FailoverInstances)
+
+	if err := failover.SetConfigSchemaValues(d); err != nil {
+		return nil, err
 	}
 
-	log.Printf("[DEBUG] failover: Create. Found instance numbers per region: %v", positions)
-	failover.SetCounts(positions...)
-	failover.FillDefaultCountsIfNotSet()
-	log.Printf("[DEBUG] failover: Create. Set instance numbers per region: %v", failover.FailoverInstances)
+	if err := d.Set(ResourceGroupFieldName, failover.ResourceGroup); err != nil {
+		return nil, err
+	}
 
 	id, err := failover.ID()
 	if err != nil {
-		return diag.FromErr(err)
+		return nil, err
 	}
 	d.SetId(id)
-	return resourcePolkadotFailoverRead(ctx, d, meta)
 
+	return []*schema.ResourceData{d}, nil
 }
 
 func resourcePolkadotFailoverDelete(_ context.Context, _ *schema.ResourceData, _ interface{}) diag.Diagnostics {
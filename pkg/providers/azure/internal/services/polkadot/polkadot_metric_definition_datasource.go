@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
 	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/validate"
 
 	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/azure"
@@ -138,8 +139,8 @@ func dateSourcePolkadotMetricDefinitionRead(ctx context.Context, d *schema.Resou
 		metricSource.ResourceGroup,
 		metricSource.MetricName,
 		metricSource.MetricNameSpace,
-		5,
-		20,
+		helpers.MetricDefinitionPeriod,
+		helpers.MetricDefinitionAttempts,
 	)
 
 	if err != nil {
@@ -0,0 +1,90 @@
+package polkadot
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/azure"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/resource"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/providers/azure/internal/clients"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/providers/azure/internal/timeouts"
+)
+
+func dataSourcePolkadotValidatorHistory() *schema.Resource {
+
+	historySchema := resource.GetValidatorHistorySchema()
+	historySchema[ResourceGroupFieldName] = azure.SchemaResourceGroupName()
+
+	return &schema.Resource{
+
+		ReadContext: dataSourcePolkadotValidatorHistoryRead,
+
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(time.Minute * 10),
+		},
+
+		Schema: historySchema,
+	}
+}
+
+// dataSourcePolkadotValidatorHistoryRead builds the validator timeline from azure monitor time series of VM scale sets with prefix
+func dataSourcePolkadotValidatorHistoryRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	source := resource.ValidatorHistorySource{}
+	source.FromSchema(d)
+	resourceGroup := d.Get(ResourceGroupFieldName).(string)
+
+	client := meta.(*clients.Client)
+
+	ctx, cancel := timeouts.ForRead(ctx, d)
+	defer cancel()
+
+	vmScaleSets, err := azure.GetVirtualMachineScaleSetsWithClient(ctx, client.Polkadot.VMScaleSetsClient, source.Prefix, resourceGroup)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	vmScaleSetLocations := make(map[string]string, len(vmScaleSets))
+
+	for _, vmScaleSet := range vmScaleSets {
+		location := ""
+		if vmScaleSet.Location != nil {
+			location = azure.Normalize(*vmScaleSet.Location)
+		}
+		vmScaleSetLocations[*vmScaleSet.Name] = location
+	}
+
+	check := source.GetValidatorMetricCheck()
+
+	samples, err := azure.GetValidatorHistory(
+		ctx,
+		client.Polkadot.MetricsClient,
+		vmScaleSetLocations,
+		resourceGroup,
+		source.MetricName,
+		source.MetricNameSpace,
+		check.GetAggregation(),
+		source.GetWindow(),
+	)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	history := resource.NewValidatorHistory(samples, check)
+
+	log.Printf(
+		"[DEBUG] failover: Validator history. Found %d validator samples, %d transitions and %d overlaps in %d scale sets",
+		len(history.Timeline),
+		history.Transitions,
+		history.Overlaps,
+		len(vmScaleSets),
+	)
+
+	d.SetId(source.ID(resourceGroup))
+	return history.SetSchemaValuesDiag(d)
+}
@@ -24,6 +24,7 @@ func (r Registration) SupportedDataSources() map[string]*schema.Resource {
 	return map[string]*schema.Resource{
 		"polkadot_failover":          dataSourcePolkadotFailOver(),
 		"polkadot_metric_definition": dataSourcePolkadotMetricDefinition(),
+		"polkadot_validator_history": dataSourcePolkadotValidatorHistory(),
 	}
 }
 
@@ -1,6 +1,8 @@
 package polkadot
 
 import (
+	"context"
+
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/azure"
@@ -50,7 +52,7 @@ func (m *MetricSource) FromSchema(d *schema.ResourceData) error {
 }
 
 func (m *MetricSource) ID() (string, error) {
-	return resource.BsonPack(m)
+	return resource.JoinID(m.ResourceGroup, m.Prefix, m.MetricNameSpace, m.MetricName), nil
 }
 
 func (m *MetricSource) SetMetric(metric string) {
@@ -63,24 +65,52 @@ type AzureFailover struct {
 }
 
 func (f *AzureFailover) FromIDOrSchema(d *schema.ResourceData) error {
-	if id := d.Id(); id != "" {
-		err := resource.BsonUnPack(f, id)
-		if err != nil {
-			return err
-		}
-		f.Source = resource.FailoverSourceID
-		return nil
-	}
-	err := f.FromSchema(d)
+	err := f.Failover.FromIDOrSchema(d)
 	f.Locations = azure.NormalizeSlice(f.Locations)
+	f.PreferredLocations = azure.NormalizeSlice(f.PreferredLocations)
+	f.PinLocation = azure.Normalize(f.PinLocation)
 	f.ResourceGroup = d.Get(ResourceGroupFieldName).(string)
 	return err
 }
 
-func (f *AzureFailover) FromID(id string) error {
-	return resource.BsonUnPack(f, id)
+// FromResourceDiff reads failover from planned values
+func (f *AzureFailover) FromResourceDiff(diff *schema.ResourceDiff) error {
+	err := f.Failover.FromResourceDiff(diff)
+	f.Locations = azure.NormalizeSlice(f.Locations)
+	f.PreferredLocations = azure.NormalizeSlice(f.PreferredLocations)
+	f.PinLocation = azure.Normalize(f.PinLocation)
+	f.ResourceGroup = diff.Get(ResourceGroupFieldName).(string)
+	return err
+}
+
+func unpackLegacyID(id string) (resource.Failover, map[string]interface{}, error) {
+	failover := &AzureFailover{}
+	if err := resource.BsonUnPack(failover, id); err != nil {
+		return resource.Failover{}, nil, err
+	}
+	if err := failover.RestoreLegacyCounts(id); err != nil {
+		return resource.Failover{}, nil, err
+	}
+	extra := map[string]interface{}{}
+	if failover.ResourceGroup != "" {
+		extra[ResourceGroupFieldName] = failover.ResourceGroup
+	}
+	return failover.Failover, extra, nil
 }
 
-func (f *AzureFailover) ID() (string, error) {
-	return resource.BsonPack(f)
+func resourcePolkadotFailoverStateUpgradeV0(_ context.Context, rawState map[string]interface{}, _ interface{}) (map[string]interface{}, error) {
+	return resource.UpgradeStateV0(rawState, unpackLegacyID)
+}
+
+func resourcePolkadotFailoverStateUpgraders() []schema.StateUpgrader {
+	v0 := resource.ResourceV0(map[string]*schema.Schema{
+		ResourceGroupFieldName: {Type: schema.TypeString, Required: true},
+	})
+	return []schema.StateUpgrader{
+		{
+			Version: 0,
+			Type:    v0.CoreConfigSchema().ImpliedType(),
+			Upgrade: resourcePolkadotFailoverStateUpgradeV0,
+		},
+	}
 }
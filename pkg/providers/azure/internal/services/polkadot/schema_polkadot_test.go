@@ -1,6 +1,7 @@
 package polkadot
 
 import (
+	"context"
 	"testing"
 
 	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/resource"
@@ -9,7 +10,21 @@ import (
 )
 
 func TestAzureFailoverID(t *testing.T) {
-	failoverOrig := &AzureFailover{
+	failover := &AzureFailover{
+		Failover: resource.Failover{
+			Prefix:       "test",
+			FailoverMode: resource.FailOverModeSingle,
+		},
+		ResourceGroup: "test",
+	}
+
+	id, err := failover.ID()
+	require.NoError(t, err)
+	require.Equal(t, "v1/test/single", id)
+}
+
+func TestAzureFailoverStateUpgradeV0(t *testing.T) {
+	legacy := &AzureFailover{
 		Failover: resource.Failover{
 			Prefix:            "test",
 			FailoverMode:      resource.FailOverModeDistributed,
@@ -17,21 +32,17 @@ func TestAzureFailoverID(t *testing.T) {
 			MetricNameSpace:   "test",
 			Instances:         []int{1, 2, 3},
 			Locations:         []string{"1", "2", "3"},
-			PrimaryCount:      1,
-			SecondaryCount:    0,
-			TertiaryCount:     0,
-			FailoverInstances: []int{1, 0, 0},
-			Source:            resource.FailoverSourceID,
+			FailoverInstances: []int{1, 2, 3},
 		},
-		ResourceGroup: "test",
+		ResourceGroup: "test-group",
 	}
 
-	id, err := failoverOrig.ID()
+	id, err := resource.BsonPack(legacy)
 	require.NoError(t, err)
 
-	failoverUnpack := &AzureFailover{}
-	err = failoverUnpack.FromID(id)
+	state, err := resourcePolkadotFailoverStateUpgradeV0(context.Background(), map[string]interface{}{"id": id}, nil)
 	require.NoError(t, err)
-	require.Equal(t, failoverOrig, failoverUnpack)
-
+	require.Equal(t, "v1/test/distributed", state["id"])
+	require.Equal(t, "test-group", state[ResourceGroupFieldName])
+	require.Equal(t, []int{1, 2, 3}, state[resource.FailoverInstancesFieldName])
 }
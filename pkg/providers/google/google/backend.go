@@ -0,0 +1,175 @@
+package google
+
+import (
+	"context"
+	"fmt"
+
+	monitoring "cloud.google.com/go/monitoring/apiv3/v2"
+	"github.com/hashicorp/go-multierror"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/engine"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/gcp"
+	"google.golang.org/api/compute/v1"
+)
+
+// backend is failover engine backend managing instance group managers instances
+type backend struct {
+	computeClient *compute.Service
+	metricsClient *monitoring.MetricClient
+	failover      *GCPFailover
+	// groups are instance group managers found by the last ListGroups call
+	groups gcp.InstanceGroupManagerList
+	// suspended are instance group managers which auto healing has been disabled by SuspendSelfHealing
+	suspended gcp.InstanceGroupManagerList
+}
+
+func newBackend(computeClient *compute.Service, metricsClient *monitoring.MetricClient, failover *GCPFailover) *backend {
+	return &backend{
+		computeClient: computeClient,
+		metricsClient: metricsClient,
+		failover:      failover,
+	}
+}
+
+func (b *backend) ListGroups(ctx context.Context) ([]engine.Group, error) {
+
+	instanceGroups, err := gcp.GetInstanceGroupManagersForProjects(
+		ctx,
+		b.computeClient,
+		b.failover.Prefix,
+		b.failover.ProjectRegions()...,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	b.groups = instanceGroups
+
+	groups := make([]engine.Group, 0, len(instanceGroups))
+
+	for _, instanceGroup := range instanceGroups {
+		group := engine.Group{
+			Name:     instanceGroup.Name,
+			Location: helpers.FindStrIndex(instanceGroup.Region, b.failover.Locations),
+		}
+		for _, name := range instanceGroup.InstanceNames() {
+			group.Instances = append(group.Instances, helpers.LastPartOnSplit(name, "/"))
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
+
+func (b *backend) GetValidatorMetrics(ctx context.Context, _ []engine.Group) ([]engine.Metric, error) {
+
+	values, err := gcp.GetValidatorMetricValues(
+		ctx,
+		b.metricsClient,
+		b.failover.MetricsScope(),
+		b.failover.Prefix,
+		b.failover.MetricNameSpace,
+		b.failover.MetricName,
+		b.failover.GetValidatorMetricCheck().GetAggregation(),
+		b.failover.GetMetricFreshness(),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := make([]engine.Metric, 0, len(values))
+
+	for _, value := range values {
+		metrics = append(metrics, engine.Metric{
+			Group:     value.GroupName,
+			Instance:  value.InstanceName,
+			Value:     value.Value,
+			Missing:   value.Missing,
+			Stale:     value.Stale,
+			Timestamp: value.Timestamp,
+		})
+	}
+
+	return metrics, nil
+}
+
+// DeleteInstances deletes instances of the instance group manager found by the last ListGroups call
+func (b *backend) DeleteInstances(ctx context.Context, group engine.Group) error {
+
+	for _, instanceGroup := range b.groups {
+		if instanceGroup.Name != group.Name {
+			continue
+		}
+		names := make(map[string]bool, len(group.Instances))
+		for _, name := range group.Instances {
+			names[name] = true
+		}
+		toDelete := gcp.InstanceGroupManager{Name: instanceGroup.Name, Project: instanceGroup.Project, Region: instanceGroup.Region}
+		for _, instance := range instanceGroup.Instances {
+			if names[helpers.LastPartOnSplit(instance.Instance, "/")] {
+				toDelete.Instances = append(toDelete.Instances, instance)
+			}
+		}
+		return gcp.DeleteManagementInstances(ctx, b.computeClient, gcp.InstanceGroupManagerList{toDelete})
+	}
+
+	return fmt.Errorf("cannot find instance group manager %q", group.Name)
+}
+
+// InstanceAddresses returns internal IP addresses of instances found by the last ListGroups call
+func (b *backend) InstanceAddresses(ctx context.Context, _ []engine.Group) (map[string]string, error) {
+	return gcp.GetInstancesAddresses(ctx, b.computeClient, b.groups)
+}
+
+func (b *backend) WaitForCount(ctx context.Context, count int) error {
+	return gcp.WaitForInstancesCount(
+		ctx,
+		b.computeClient,
+		b.failover.Prefix,
+		count,
+		b.failover.ProjectRegions()...,
+	)
+}
+
+// SuspendSelfHealing disables auto healing of instance group managers found by the last ListGroups call
+func (b *backend) SuspendSelfHealing(ctx context.Context, _ []engine.Group) error {
+
+	for _, instanceGroup := range b.groups {
+		if len(instanceGroup.AutoHealingPolicies) == 0 {
+			continue
+		}
+		if err := gcp.SetAutoHealingPolicies(ctx, b.computeClient, instanceGroup.Project, instanceGroup.Region, instanceGroup.Name, nil); err != nil {
+			return err
+		}
+		b.suspended = append(b.suspended, instanceGroup)
+	}
+
+	return nil
+}
+
+// ResumeSelfHealing restores auto healing policies disabled by SuspendSelfHealing
+func (b *backend) ResumeSelfHealing(ctx context.Context) error {
+
+	result := &multierror.Error{}
+	var failed gcp.InstanceGroupManagerList
+
+	for _, instanceGroup := range b.suspended {
+		if err := gcp.SetAutoHealingPolicies(
+			ctx,
+			b.computeClient,
+			instanceGroup.Project,
+			instanceGroup.Region,
+			instanceGroup.Name,
+			instanceGroup.AutoHealingPolicies,
+		); err != nil {
+			result = multierror.Append(result, err)
+			failed = append(failed, instanceGroup)
+		}
+	}
+
+	b.suspended = failed
+
+	return result.ErrorOrNil()
+}
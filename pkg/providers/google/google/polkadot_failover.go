@@ -3,11 +3,14 @@ package google
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 	"strings"
 	"time"
 
+	monitoring "cloud.google.com/go/monitoring/apiv3/v2"
 	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/engine"
 	helperErrors "github.com/protofire/polkadot-failover-mechanism/pkg/helpers/errors"
 
 	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/gcp"
@@ -15,11 +18,25 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 
 	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/resource"
+	"google.golang.org/api/compute/v1"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func resourcePolkadotFailover() *schema.Resource {
+
+	polkadotSchema := resource.GetPolkadotResourceSchema()
+	polkadotSchema[ProjectFieldName] = &schema.Schema{
+		Type:        schema.TypeString,
+		Description: "Google project of the failover instances. Provider project is used if not set",
+		Optional:    true,
+		Computed:    true,
+		ForceNew:    true,
+	}
+	polkadotSchema[LocationProjectsFieldName] = locationProjectsSchema()
+	polkadotSchema[LocationProjectsFieldName].ForceNew = true
+
 	return &schema.Resource{
 
 		ReadContext:   resourcePolkadotFailoverRead,
@@ -27,6 +44,10 @@ func resourcePolkadotFailover() *schema.Resource {
 		UpdateContext: resourcePolkadotFailoverCreateOrUpdate,
 		DeleteContext: resourcePolkadotFailoverDelete,
 
+		Importer: &schema.ResourceImporter{
+			StateContext: resourcePolkadotFailoverImport,
+		},
+
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(time.Minute * 30),
 			Update: schema.DefaultTimeout(time.Minute * 60),
@@ -34,7 +55,17 @@ func resourcePolkadotFailover() *schema.Resource {
 			Delete: schema.DefaultTimeout(time.Minute * 30),
 		},
 
-		Schema: resource.GetPolkadotSchema(),
+		Schema: polkadotSchema,
+
+		SchemaVersion:  resource.SchemaVersion,
+		StateUpgraders: resourcePolkadotFailoverStateUpgraders(),
+
+		CustomizeDiff: customdiff.All(
+			resource.CustomizeDiff,
+			customizeDiffLocationProjects,
+			resource.CustomizeDiffPlan(resourcePolkadotFailoverPlan),
+			resource.CustomizeDiffValidator,
+		),
 	}
 }
 
@@ -63,12 +94,6 @@ func resourcePolkadotFailoverRead(ctx context.Context, d *schema.ResourceData, m
 		return nil
 	}
 
-	if failover.IsDistributedMode() {
-		log.Printf("[DEBUG] failover: Read. Failover mode is %q. Using predefined number of instances", failover.FailoverMode)
-		failover.SetCounts(failover.Instances...)
-		return failover.SetSchemaValuesDiag(d)
-	}
-
 	log.Printf("[DEBUG] failover: Read. Failover mode is %q", failover.FailoverMode)
 
 	userAgent, err := generateUserAgentString(d, config.userAgent)
@@ -77,17 +102,26 @@ func resourcePolkadotFailoverRead(ctx context.Context, d *schema.ResourceData, m
 	}
 	computeClient := config.NewComputeClient(userAgent)
 
+	if computeClient == nil {
+		return diag.Errorf("cannot initialize compute client")
+	}
+
+	metricsClient := config.NewMetricsClient(userAgent)
+
+	if metricsClient == nil {
+		return diag.Errorf("cannot initialize metric client")
+	}
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	log.Printf("[DEBUG] failover: Read. Getting instances list...")
 
-	instanceGroups, err := gcp.GetInstanceGroupManagersForRegions(
+	instanceGroups, err := gcp.GetInstanceGroupManagersForProjects(
 		ctx,
 		computeClient,
-		failover.Project,
 		failover.Prefix,
-		failover.Locations...,
+		failover.ProjectRegions()...,
 	)
 
 	if err != nil {
@@ -96,17 +130,24 @@ func resourcePolkadotFailoverRead(ctx context.Context, d *schema.ResourceData, m
 
 	log.Printf("[DEBUG] failover: Read. Found %d managent instance groups", len(instanceGroups))
 
-	positions := make([]int, len(failover.Locations))
+	validator, err := getDetectedValidator(ctx, failover, computeClient, metricsClient, instanceGroups)
 
-	for _, group := range instanceGroups {
-		regionPosition := helpers.FindStrIndex(group.Region, failover.Locations)
-		if regionPosition == -1 {
-			log.Printf("[ERROR] failover: Read. Cannot find region %s in locations list: %s", group.Region, strings.Join(failover.Locations, ", "))
-			continue
-		}
-		positions[regionPosition] = len(group.Instances)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := validator.SetSchemaValues(d); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if failover.IsDistributedMode() {
+		log.Printf("[DEBUG] failover: Read. Failover mode is %q. Using predefined number of instances", failover.FailoverMode)
+		failover.SetCounts(failover.Instances...)
+		return failover.SetSchemaValuesDiag(d)
 	}
 
+	positions := regionCounts(instanceGroups, failover.Locations)
+
 	log.Printf("[DEBUG] failover: Read. Found instance numbers per region: %v", positions)
 
 	failover.SetCounts(positions...)
@@ -170,103 +211,275 @@ func resourcePolkadotFailoverCreateOrUpdate(ctx context.Context, d *schema.Resou
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	// delete all instances besides the validator instance and standbys. In case we did not find the validator, or we found multiple validators,
+	// on_validator_unknown policy decides whether all instances are deleted
+	result, err := engine.New(newBackend(computeClient, metricsClient, failover), &failover.Failover).Run(ctx)
+
+	var abort resource.AbortError
+	if errors.As(err, &abort) {
+		return abort.Diagnostics()
+	}
+
+	plan := result.Plan(len(failover.Locations))
+	plan.DryRun = failover.DryRun
+
+	if err := plan.SetSchemaValues(d, failover.Locations); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	log.Printf("[DEBUG] failover: Create. Set instance numbers per region: %v", failover.FailoverInstances)
+
+	id, err := failover.ID()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(id)
+	return resourcePolkadotFailoverRead(ctx, d, meta)
+}
+
+// regionCounts returns number of instances per location. Instances of instance groups sharing a region are added up
+func regionCounts(instanceGroups gcp.InstanceGroupManagerList, locations []string) []int {
+
+	counts := make([]int, len(locations))
+
+	for _, group := range instanceGroups {
+		regionPosition := helpers.FindStrIndex(group.Region, locations)
+		if regionPosition == -1 {
+			log.Printf("[ERROR] failover: Cannot find region %s in locations list: %s", group.Region, strings.Join(locations, ", "))
+			continue
+		}
+		counts[regionPosition] += len(group.Instances)
+	}
+
+	return counts
+}
+
+// getDetectedValidator finds the validator for computed attributes. Empty validator is returned if validator has not been detected
+func getDetectedValidator(
+	ctx context.Context,
+	failover *GCPFailover,
+	computeClient *compute.Service,
+	metricsClient *monitoring.MetricClient,
+	instanceGroups gcp.InstanceGroupManagerList,
+) (resource.DetectedValidator, error) {
+
+	if instanceGroups.InstancesCount() == 0 {
+		return resource.DetectedValidator{}, nil
+	}
+
+	if !failover.DetectsWithMetricsOnly() {
+		result, err := engine.New(newBackend(computeClient, metricsClient, failover), &failover.Failover).Inspect(ctx)
+		if err != nil {
+			return resource.DetectedValidator{}, err
+		}
+		return result.Validator.Detected(failover.Locations), nil
+	}
+
 	validator, err := gcp.GetValidatorWithClient(
 		ctx,
 		metricsClient,
-		failover.Project,
+		failover.MetricsScope(),
 		failover.Prefix,
 		failover.MetricNameSpace,
 		failover.MetricName,
-		1,
+		failover.GetValidatorMetricCheck(),
+		failover.GetMetricFreshness(),
 	)
 
 	if err != nil {
 		validatorError := &helperErrors.ValidatorError{}
 		if errors.As(err, validatorError) {
-			log.Printf("[WARNING] failover: Create. Cannot get validator: %s", validatorError)
-		} else {
-			log.Printf("[ERROR] failover: Create. Cannot get validator: %s", err)
-			return diag.FromErr(err)
+			log.Printf("[WARNING] failover: Read. Cannot get validator: %s", validatorError)
+			return resource.DetectedValidator{}, nil
 		}
+		return resource.DetectedValidator{}, err
+	}
+
+	log.Printf("[DEBUG] failover: Read. Found validator instance %q in group %q", validator.InstanceName, validator.GroupName)
+
+	return resource.DetectedValidator{
+		Instance: validator.InstanceName,
+		Group:    validator.GroupName,
+		Location: instanceGroups.InstanceRegion(validator.InstanceName),
+	}, nil
+}
+
+// resourcePolkadotFailoverPlan runs single and standby mode failover discovery while planning
+func resourcePolkadotFailoverPlan(ctx context.Context, diff *schema.ResourceDiff, meta interface{}) (resource.FailoverPlan, []string, error) {
+
+	config := meta.(*Config)
+
+	failover := &GCPFailover{}
+	if err := failover.FromResourceDiff(diff); err != nil {
+		return resource.FailoverPlan{}, nil, err
 	}
 
-	if validator.InstanceName != "" {
-		log.Printf("[DEBUG] failover: Create. Found validator instance: %s", validator.InstanceName)
-	} else {
-		log.Printf("[DEBUG] failover: Create. Have not found the validator instance")
+	failover.Project = diff.Get(ProjectFieldName).(string)
+	if failover.Project == "" {
+		failover.Project = config.Project
 	}
+	failover.LocationProjects = expandLocationProjects(diff.Get(LocationProjectsFieldName).(map[string]interface{}))
 
-	instanceGroups, err := gcp.GetInstanceGroupManagersForRegions(
-		ctx,
-		computeClient,
-		failover.Project,
-		failover.Prefix,
-		failover.Locations...,
-	)
+	if failover.Project == "" {
+		log.Printf("[DEBUG] failover: Plan. Google project is not known. Skipping failover plan")
+		return resource.NewFailoverPlan(len(failover.Locations)), failover.Locations, nil
+	}
+
+	computeClient := config.NewComputeClient(config.userAgent)
+	if computeClient == nil {
+		return resource.FailoverPlan{}, nil, fmt.Errorf("cannot initialize compute client")
+	}
+
+	metricsClient := config.NewMetricsClient(config.userAgent)
+	if metricsClient == nil {
+		return resource.FailoverPlan{}, nil, fmt.Errorf("cannot initialize metric client")
+	}
 
+	result, err := engine.New(newBackend(computeClient, metricsClient, failover), &failover.Failover).Discover(ctx)
 	if err != nil {
-		log.Printf("[ERROR] failover: Create. Cannot get management instance groups: %s", err)
-		return diag.FromErr(err)
+		return resource.FailoverPlan{}, nil, err
 	}
 
-	log.Printf(
-		"[DEBUG] failover: Create. Found %d managent instance groups with %d instances",
-		len(instanceGroups),
-		instanceGroups.InstancesCount(),
-	)
+	return result.Plan(len(failover.Locations)), failover.Locations, nil
+}
 
-	initialInstancesCount := instanceGroups.InstancesCount()
-	positions := make([]int, len(failover.Locations))
-
-	for i := 0; i < len(instanceGroups); i++ {
-		group := &instanceGroups[i]
-		if validatorInstance := group.SearchAndRemoveInstanceByName(validator.InstanceName); validatorInstance != nil {
-			log.Printf("[DEBUG] failover: Create. Processing validator instance: %s", validatorInstance.Instance)
-			regionPosition := helpers.FindStrIndex(group.Region, failover.Locations)
-			if regionPosition == -1 {
-				log.Printf("[ERROR] failover: Create. Cannot find region %s in locations list: %s", group.Region, strings.Join(failover.Locations, ", "))
-				continue
-			}
-			positions[regionPosition] = 1
-			break
-		}
+// parseImportID parses import ID with per location projects. project is used for locations without project
+func parseImportID(id, project string) (*GCPFailover, error) {
+
+	imported, locationProjects, err := resource.ParseImportIDWithProjects(id)
+	if err != nil {
+		return nil, err
 	}
 
-	failover.SetCounts(positions...)
-	failover.FillDefaultCountsIfNotSet()
+	failover := &GCPFailover{Failover: imported, Project: project, LocationProjects: locationProjects}
+
+	if err := validateLocationProjects(failover.Locations, failover.LocationProjects); err != nil {
+		return nil, err
+	}
+
+	return failover, nil
+}
+
+// setImportedSchemaValues sets configuration attributes of imported resource
+func setImportedSchemaValues(d *schema.ResourceData, failover *GCPFailover) error {
+	if err := failover.SetConfigSchemaValues(d); err != nil {
+		return err
+	}
+	if err := d.Set(ProjectFieldName, failover.Project); err != nil {
+		return err
+	}
+	return d.Set(LocationProjectsFieldName, failover.LocationProjects)
+}
+
+// resourcePolkadotFailoverImport discovers instance group managers by prefix and restores counts from running instances.
+// Provider project is used for locations without project in import ID
+func resourcePolkadotFailoverImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+
+	config := meta.(*Config)
 
-	// delete all instances besides the validator instance. In case we did not find the validator, or we found multiple validators,
-	// we will delete all instances
-	log.Printf(
-		"[DEBUG] failover: Create. Deleting %d managent instances: %q",
-		instanceGroups.InstancesCount(),
-		strings.Join(instanceGroups.InstanceNames(), ", "),
+	project, err := getProject(d, config)
+	if err != nil {
+		return nil, err
+	}
+
+	failover, err := parseImportID(d.Id(), project)
+	if err != nil {
+		return nil, err
+	}
+
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	computeClient := config.NewComputeClient(userAgent)
+	if computeClient == nil {
+		return nil, fmt.Errorf("cannot initialize compute client")
+	}
+
+	metricsClient := config.NewMetricsClient(userAgent)
+	if metricsClient == nil {
+		return nil, fmt.Errorf("cannot initialize metric client")
+	}
+
+	log.Printf("[DEBUG] failover: Import. Getting instances list...")
+
+	instanceGroups, err := gcp.GetInstanceGroupManagersForProjects(
+		ctx,
+		computeClient,
+		failover.Prefix,
+		failover.ProjectRegions()...,
 	)
-	err = gcp.DeleteManagementInstances(ctx, computeClient, failover.Project, instanceGroups)
+
 	if err != nil {
-		return diag.FromErr(err)
+		return nil, err
 	}
 
-	if initialInstancesCount > 0 {
-		err = gcp.WaitForInstancesCount(
+	running := regionCounts(instanceGroups, failover.Locations)
+
+	log.Printf("[DEBUG] failover: Import. Found instance numbers per region: %v", running)
+
+	validatorLocation := -1
+
+	if instanceGroups.InstancesCount() > 0 {
+		validator, err := gcp.GetValidatorWithClient(
 			ctx,
-			computeClient,
-			failover.Project,
+			metricsClient,
+			failover.MetricsScope(),
 			failover.Prefix,
-			failover.InstancesCount(),
-			failover.Locations...,
+			failover.MetricNameSpace,
+			failover.MetricName,
+			failover.GetValidatorMetricCheck(),
+			failover.GetMetricFreshness(),
 		)
 		if err != nil {
-			return diag.FromErr(err)
+			log.Printf("[WARNING] failover: Import. Cannot get validator: %s", err)
+		} else {
+			log.Printf("[DEBUG] failover: Import. Found validator instance: %s", validator.InstanceName)
+			validatorLocation = helpers.FindStrIndex(instanceGroups.InstanceRegion(validator.InstanceName), failover.Locations)
 		}
 	}
 
+	failover.SetImportedCounts(running, validatorLocation)
+
+	log.Printf("[DEBUG] failover: Import. Set instance numbers per region: %v", failover.FailoverInstances)
+
+	if err := setImportedSchemaValues(d, failover); err != nil {
+		return nil, err
+	}
+
 	id, err := failover.ID()
 	if err != nil {
-		return diag.FromErr(err)
+		return nil, err
 	}
 	d.SetId(id)
-	return resourcePolkadotFailoverRead(ctx, d, meta)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// locationProjectsSchema returns schema of projects of the failover instances by location
+func locationProjectsSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeMap,
+		Description: "Google projects of the failover instances by location. Locations which are not set use project. Validator metrics are read through the Cloud Monitoring metrics scope of project",
+		Optional:    true,
+		Elem:        &schema.Schema{Type: schema.TypeString},
+	}
+}
+
+// customizeDiffLocationProjects checks that location projects are set for failover locations only
+func customizeDiffLocationProjects(_ context.Context, diff *schema.ResourceDiff, _ interface{}) error {
+	if !diff.NewValueKnown(resource.LocationsFieldName) || !diff.NewValueKnown(LocationProjectsFieldName) {
+		return nil
+	}
+	return validateLocationProjects(
+		resource.ExpandString(diff.Get(resource.LocationsFieldName).([]interface{})),
+		expandLocationProjects(diff.Get(LocationProjectsFieldName).(map[string]interface{})),
+	)
 }
 
 func resourcePolkadotFailoverDelete(_ context.Context, _ *schema.ResourceData, _ interface{}) diag.Diagnostics {
@@ -0,0 +1,118 @@
+package google
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/engine"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/resource"
+)
+
+func dataSourcePolkadotFailover() *schema.Resource {
+
+	polkadotSchema := resource.GetPolkadotDataSourceSchema()
+	polkadotSchema[ProjectFieldName] = &schema.Schema{
+		Type:        schema.TypeString,
+		Description: "Google project of the failover instances. Provider project is used if not set",
+		Optional:    true,
+		Computed:    true,
+	}
+	polkadotSchema[LocationProjectsFieldName] = locationProjectsSchema()
+
+	return &schema.Resource{
+
+		ReadContext: dataSourcePolkadotFailoverRead,
+
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(time.Minute * 30),
+		},
+
+		Schema: polkadotSchema,
+	}
+}
+
+// dataSourceFailoverResult finds running instances per location and the validator. It never deletes instances
+func dataSourceFailoverResult(ctx context.Context, backend engine.CloudBackend, failover *GCPFailover) (engine.Result, error) {
+
+	result, err := engine.New(backend, &failover.Failover).Inspect(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	failover.SetCounts(result.Running...)
+
+	return result, nil
+}
+
+// dataSourcePolkadotFailoverRead reports running instances per location and the current validator. It never deletes instances
+func dataSourcePolkadotFailoverRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	config := meta.(*Config)
+	failover := &GCPFailover{}
+
+	if err := failover.FromSchema(d); err != nil {
+		return diag.FromErr(err)
+	}
+
+	failover.Project = d.Get(ProjectFieldName).(string)
+	failover.LocationProjects = expandLocationProjects(d.Get(LocationProjectsFieldName).(map[string]interface{}))
+
+	if err := validateLocationProjects(failover.Locations, failover.LocationProjects); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if failover.Project == "" {
+		project, err := getProject(d, config)
+		if err != nil {
+			log.Printf("[DEBUG] failover: Data source read. Error getting google project: %v", err)
+			return diag.FromErr(err)
+		}
+		failover.Project = project
+	}
+
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	computeClient := config.NewComputeClient(userAgent)
+
+	if computeClient == nil {
+		return diag.Errorf("cannot initialize compute client")
+	}
+
+	metricsClient := config.NewMetricsClient(userAgent)
+
+	if metricsClient == nil {
+		return diag.Errorf("cannot initialize metric client")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout(schema.TimeoutRead))
+	defer cancel()
+
+	log.Printf("[DEBUG] failover: Data source read. Getting instances list...")
+
+	result, err := dataSourceFailoverResult(ctx, newBackend(computeClient, metricsClient, failover), failover)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	log.Printf("[DEBUG] failover: Data source read. Found instance numbers per region: %v", failover.FailoverInstances)
+
+	detected := result.Validator.Detected(failover.Locations)
+
+	if err := detected.SetSchemaValues(d); err != nil {
+		return diag.FromErr(err)
+	}
+
+	id, err := failover.ID()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(id)
+	return failover.SetSchemaValuesDiag(d)
+}
@@ -5,9 +5,13 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"google.golang.org/api/compute/v1"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/engine"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/gcp"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/resource"
 )
 
 var providerFactories map[string]func() (*schema.Provider, error)
@@ -35,3 +39,60 @@ func TestPolkadotConfigureCheck(t *testing.T) {
 	ds := prov.Resources()[0]
 	require.Equal(t, "polkadot_failover", ds.Name)
 }
+
+func TestPolkadotFailoverDataSource(t *testing.T) {
+	prov, err := providerFactories["polkadot"]()
+	require.NoError(t, err)
+	ds, ok := prov.DataSourcesMap["polkadot_failover"]
+	require.True(t, ok)
+	require.Nil(t, ds.CreateContext)
+	require.Nil(t, ds.DeleteContext)
+	require.Contains(t, ds.Schema, resource.ValidatorInstanceFieldName)
+	require.Contains(t, ds.Schema, resource.FailoverInstancesFieldName)
+}
+
+func TestPolkadotFailoverDataSourceResult(t *testing.T) {
+	backend := engine.NewFakeBackend(
+		engine.Group{Name: "group-1", Location: 0, Instances: []string{"i1", "i2"}},
+		engine.Group{Name: "group-2", Location: 1, Instances: []string{"i3"}},
+		engine.Group{Name: "group-3", Location: 2, Instances: []string{"i4", "i5"}},
+	)
+	backend.Metrics["i3"] = 1
+
+	failover := &GCPFailover{Project: "test"}
+	failover.FailoverMode = resource.FailOverModeSingle
+	failover.Instances = []int{2, 2, 2}
+	failover.Locations = []string{"us-central1", "us-east1", "us-west1"}
+	failover.OnValidatorUnknown = resource.ValidatorUnknownDeleteAll
+
+	result, err := dataSourceFailoverResult(context.Background(), backend, failover)
+	require.NoError(t, err)
+	require.Equal(t, engine.Validator{Group: "group-2", Instance: "i3", Location: 1}, result.Validator)
+	require.Equal(t, []int{2, 1, 2}, failover.FailoverInstances)
+	require.Empty(t, backend.Deleted)
+	require.Empty(t, backend.WaitedCounts)
+
+	// instances are kept even if the validator has not been detected
+	delete(backend.Metrics, "i3")
+	result, err = dataSourceFailoverResult(context.Background(), backend, failover)
+	require.NoError(t, err)
+	require.Error(t, result.ValidatorErr)
+	require.Equal(t, []int{2, 1, 2}, failover.FailoverInstances)
+	require.Empty(t, backend.Deleted)
+}
+
+func TestRegionCounts(t *testing.T) {
+	instances := func(count int) []*compute.ManagedInstance {
+		return make([]*compute.ManagedInstance, count)
+	}
+
+	// instance groups of different projects share a region
+	counts := regionCounts(gcp.InstanceGroupManagerList{
+		{Name: "test-1", Project: "project-1", Region: "us-east1", Instances: instances(1)},
+		{Name: "test-2", Project: "project-2", Region: "us-east1", Instances: instances(2)},
+		{Name: "test-3", Project: "project-1", Region: "us-west1", Instances: instances(1)},
+		{Name: "test-4", Project: "project-1", Region: "asia-east1", Instances: instances(1)},
+	}, []string{"us-east1", "us-west1", "europe-west1"})
+
+	require.Equal(t, []int{3, 1, 0}, counts)
+}
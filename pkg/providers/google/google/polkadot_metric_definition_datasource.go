@@ -0,0 +1,88 @@
+package google
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/gcp"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/resource"
+)
+
+func dataSourcePolkadotMetricDefinition() *schema.Resource {
+
+	metricSchema := resource.GetMetricDefinitionSchema()
+	metricSchema[ProjectFieldName] = &schema.Schema{
+		Type:        schema.TypeString,
+		Description: "Google project of the metric. Provider project is used if not set",
+		Optional:    true,
+		Computed:    true,
+	}
+
+	return &schema.Resource{
+
+		ReadContext: dataSourcePolkadotMetricDefinitionRead,
+
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(time.Minute * 60),
+		},
+
+		Schema: metricSchema,
+	}
+}
+
+// dataSourcePolkadotMetricDefinitionRead waits while cloud monitoring has custom.googleapis.com/<namespace>/<name> metric descriptor
+func dataSourcePolkadotMetricDefinitionRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	config := meta.(*Config)
+
+	metric := resource.MetricDefinition{}
+	metric.FromSchema(d)
+
+	project, err := getProject(d, config)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	metricsClient := config.NewMetricsClient(userAgent)
+
+	if metricsClient == nil {
+		return diag.Errorf("cannot initialize metric client")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout(schema.TimeoutRead))
+	defer cancel()
+
+	metricType, err := gcp.WaitValidatorMetricDescriptor(
+		ctx,
+		metricsClient,
+		project,
+		metric.MetricNameSpace,
+		metric.MetricName,
+		helpers.MetricDefinitionPeriod,
+		helpers.MetricDefinitionAttempts,
+	)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	log.Printf("[DEBUG] failover: Metrics. Found metric descriptor %q in project %q", metricType, project)
+
+	metric.MetricOutputName = metricType
+
+	if err := d.Set(ProjectFieldName, project); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(metric.ID(project))
+	return metric.SetSchemaValuesDiag(d)
+}
@@ -0,0 +1,96 @@
+package google
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/gcp"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/resource"
+)
+
+func dataSourcePolkadotValidatorHistory() *schema.Resource {
+
+	historySchema := resource.GetValidatorHistorySchema()
+	historySchema[ProjectFieldName] = &schema.Schema{
+		Type:        schema.TypeString,
+		Description: "Google project of the metric. Provider project is used if not set",
+		Optional:    true,
+		Computed:    true,
+	}
+
+	return &schema.Resource{
+
+		ReadContext: dataSourcePolkadotValidatorHistoryRead,
+
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(time.Minute * 10),
+		},
+
+		Schema: historySchema,
+	}
+}
+
+// dataSourcePolkadotValidatorHistoryRead builds the validator timeline from cloud monitoring time series
+func dataSourcePolkadotValidatorHistoryRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	config := meta.(*Config)
+
+	source := resource.ValidatorHistorySource{}
+	source.FromSchema(d)
+
+	project, err := getProject(d, config)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	metricsClient := config.NewMetricsClient(userAgent)
+
+	if metricsClient == nil {
+		return diag.Errorf("cannot initialize metric client")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout(schema.TimeoutRead))
+	defer cancel()
+
+	check := source.GetValidatorMetricCheck()
+
+	samples, err := gcp.GetValidatorHistory(
+		ctx,
+		metricsClient,
+		project,
+		source.Prefix,
+		source.MetricNameSpace,
+		source.MetricName,
+		check.GetAggregation(),
+		source.GetWindow(),
+	)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	history := resource.NewValidatorHistory(samples, check)
+
+	log.Printf(
+		"[DEBUG] failover: Validator history. Found %d validator samples, %d transitions and %d overlaps in project %q",
+		len(history.Timeline),
+		history.Transitions,
+		history.Overlaps,
+		project,
+	)
+
+	if err := d.Set(ProjectFieldName, project); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(source.ID(project))
+	return history.SetSchemaValuesDiag(d)
+}
@@ -587,7 +587,11 @@ func provider(testing bool) *schema.Provider {
 			"polkadot_failover": resourcePolkadotFailover(),
 		},
 
-		DataSourcesMap: ResourceMap(),
+		DataSourcesMap: map[string]*schema.Resource{
+			"polkadot_failover":          dataSourcePolkadotFailover(),
+			"polkadot_metric_definition": dataSourcePolkadotMetricDefinition(),
+			"polkadot_validator_history": dataSourcePolkadotValidatorHistory(),
+		},
 	}
 
 	provider.ConfigureContextFunc = func(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
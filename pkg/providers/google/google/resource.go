@@ -1,32 +1,139 @@
 package google
 
 import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers"
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/gcp"
 	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/resource"
 )
 
+const (
+	ProjectFieldName          = "project"
+	LocationProjectsFieldName = "location_projects"
+)
+
 type GCPFailover struct {
 	resource.Failover
+	// Project is the project of locations without location project and the Cloud Monitoring scoping project
 	Project string
+	// LocationProjects are projects of the failover instances by location
+	LocationProjects map[string]string
 }
 
 func (f *GCPFailover) FromIDOrSchema(d *schema.ResourceData) error {
-	if id := d.Id(); id != "" {
-		err := resource.BsonUnPack(f, id)
-		if err != nil {
-			return err
-		}
-		f.Source = resource.FailoverSourceID
+	if err := f.Failover.FromIDOrSchema(d); err != nil {
+		return err
+	}
+	f.Project = d.Get(ProjectFieldName).(string)
+	f.LocationProjects = expandLocationProjects(d.Get(LocationProjectsFieldName).(map[string]interface{}))
+	return nil
+}
+
+func (f GCPFailover) SetSchemaValues(d *schema.ResourceData) error {
+	if err := f.Failover.SetSchemaValues(d); err != nil {
+		return err
+	}
+	return d.Set(ProjectFieldName, f.Project)
+}
+
+// expandLocationProjects converts location projects attribute. nil is returned if it is empty
+func expandLocationProjects(raw map[string]interface{}) map[string]string {
+	if len(raw) == 0 {
 		return nil
 	}
-	return f.FromSchema(d)
+	projects := make(map[string]string, len(raw))
+	for location, project := range raw {
+		projects[location] = project.(string)
+	}
+	return projects
+}
+
+// validateLocationProjects checks that location projects are set for failover locations only
+func validateLocationProjects(locations []string, projects map[string]string) error {
+	var unknown []string
+	for location := range projects {
+		if helpers.FindStrIndex(location, locations) == -1 {
+			unknown = append(unknown, location)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("%q contains locations which are not in %q: %v", LocationProjectsFieldName, resource.LocationsFieldName, unknown)
+	}
+	return nil
+}
+
+// LocationProject returns the project of the location instances
+func (f GCPFailover) LocationProject(location string) string {
+	if project := f.LocationProjects[location]; project != "" {
+		return project
+	}
+	return f.Project
+}
 
+// ProjectRegions groups locations by their projects. Projects are ordered by first location
+func (f GCPFailover) ProjectRegions() []gcp.ProjectRegions {
+	var result []gcp.ProjectRegions
+	positions := make(map[string]int)
+	for _, location := range f.Locations {
+		project := f.LocationProject(location)
+		position, ok := positions[project]
+		if !ok {
+			position = len(result)
+			positions[project] = position
+			result = append(result, gcp.ProjectRegions{Project: project})
+		}
+		result[position].Regions = append(result[position].Regions, location)
+	}
+	return result
 }
 
-func (f *GCPFailover) FromID(id string) error {
-	return resource.BsonUnPack(f, id)
+// MetricsScope returns metrics scope reading validator metrics of all location projects through the failover project
+func (f GCPFailover) MetricsScope() gcp.MetricsScope {
+	scope := gcp.ProjectScope(f.Project)
+	for _, projectRegions := range f.ProjectRegions() {
+		scope.Projects = append(scope.Projects, projectRegions.Project)
+	}
+	return scope
 }
 
-func (f *GCPFailover) ID() (string, error) {
-	return resource.BsonPack(f)
+func unpackLegacyID(id string) (resource.Failover, map[string]interface{}, error) {
+	failover := &GCPFailover{}
+	if err := resource.BsonUnPack(failover, id); err != nil {
+		return resource.Failover{}, nil, err
+	}
+	if err := failover.RestoreLegacyCounts(id); err != nil {
+		return resource.Failover{}, nil, err
+	}
+	extra := map[string]interface{}{}
+	if failover.Project != "" {
+		extra[ProjectFieldName] = failover.Project
+	}
+	return failover.Failover, extra, nil
+}
+
+func resourcePolkadotFailoverStateUpgradeV0(_ context.Context, rawState map[string]interface{}, _ interface{}) (map[string]interface{}, error) {
+	return resource.UpgradeStateV0(rawState, unpackLegacyID)
+}
+
+func resourcePolkadotFailoverStateUpgraders() []schema.StateUpgrader {
+	return []schema.StateUpgrader{
+		{
+			Version: 0,
+			Type:    resource.ResourceV0(nil).CoreConfigSchema().ImpliedType(),
+			Upgrade: resourcePolkadotFailoverStateUpgradeV0,
+		},
+	}
+}
+
+func (f GCPFailover) SetSchemaValuesDiag(d *schema.ResourceData) diag.Diagnostics {
+	if err := f.SetSchemaValues(d); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
 }
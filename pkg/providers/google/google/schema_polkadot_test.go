@@ -1,79 +1,217 @@
 package google
 
 import (
+	"context"
 	"testing"
 
+	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/gcp"
 	"github.com/protofire/polkadot-failover-mechanism/pkg/helpers/resource"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 
 	"github.com/stretchr/testify/require"
 )
 
 func TestGCPFailoverID(t *testing.T) {
-	failoverOrig := &GCPFailover{
+	failover := &GCPFailover{
 		Failover: resource.Failover{
-			Prefix:            "test",
-			FailoverMode:      resource.FailOverModeDistributed,
-			MetricName:        "test",
-			MetricNameSpace:   "test",
-			Instances:         []int{1, 2, 3},
-			Locations:         []string{"1", "2", "3"},
-			PrimaryCount:      1,
-			SecondaryCount:    0,
-			TertiaryCount:     0,
-			FailoverInstances: []int{1, 0, 0},
-			Source:            resource.FailoverSourceID,
+			Prefix:       "test",
+			FailoverMode: resource.FailOverModeDistributed,
 		},
 		Project: "test",
 	}
 
-	id, err := failoverOrig.ID()
+	id, err := failover.ID()
 	require.NoError(t, err)
+	require.Equal(t, "v1/test/distributed", id)
 
-	failoverUnpack := &GCPFailover{}
-	err = failoverUnpack.FromID(id)
+	failoverID, err := resource.ParseID(id)
 	require.NoError(t, err)
-	require.Equal(t, failoverOrig, failoverUnpack)
-
+	require.Equal(t, "test", failoverID.Prefix)
+	require.Equal(t, resource.FailOverModeDistributed, failoverID.Mode)
 }
 
 func TestGCPFailoverIDOrSchema(t *testing.T) {
-	failoverOrig := &GCPFailover{
+	d := schema.TestResourceDataRaw(t, resourcePolkadotFailover().Schema, map[string]interface{}{
+		resource.PrefixFieldName:          "test",
+		resource.FailoverModeFieldName:    string(resource.FailOverModeDistributed),
+		resource.MetricNameFieldName:      "test",
+		resource.MetricNamespaceFieldName: "test",
+		resource.InstancesFieldName:       []interface{}{1, 2, 3},
+		resource.LocationsFieldName:       []interface{}{"1", "2", "3"},
+		ProjectFieldName:                  "test",
+	})
+	d.SetId("v1/test/distributed")
+
+	failover := &GCPFailover{}
+	err := failover.FromIDOrSchema(d)
+	require.NoError(t, err)
+	require.Equal(t, &GCPFailover{
 		Failover: resource.Failover{
-			Prefix:            "test",
-			FailoverMode:      resource.FailOverModeDistributed,
-			MetricName:        "test",
-			MetricNameSpace:   "test",
-			Instances:         []int{1, 2, 3},
-			Locations:         []string{"1", "2", "3"},
-			PrimaryCount:      1,
-			SecondaryCount:    0,
-			TertiaryCount:     0,
-			FailoverInstances: []int{1, 0, 0},
-			Source:            resource.FailoverSourceID,
+			Prefix:                "test",
+			FailoverMode:          resource.FailOverModeDistributed,
+			MetricName:            "test",
+			MetricNameSpace:       "test",
+			Instances:             []int{1, 2, 3},
+			Locations:             []string{"1", "2", "3"},
+			PreferredLocations:    []string{},
+			OnValidatorUnknown:    resource.ValidatorUnknownAbort,
+			OnValidatorOutsidePin: resource.PinnedValidatorAbort,
+			DryRunInstances:       []int{},
+			ValidatorDetection:    resource.ValidatorDetectionMetrics,
+			RPCPort:               resource.DefaultRPCPort,
+			ConsulLockKey:         resource.DefaultConsulLockKey,
+			MetricWindow:          300,
+			MetricMaxAge:          180,
+			MetricAggregation:     "maximum",
+			ValidatorMetricValue:  1,
+			Source:                resource.FailoverSourceID,
+		},
+		Project: "test",
+	}, failover)
+
+	d.SetId("v1/other/distributed")
+	err = failover.FromIDOrSchema(d)
+	require.Error(t, err)
+}
+
+func TestGCPFailoverSetCount(t *testing.T) {
+	failover := &GCPFailover{}
+
+	failover.SetCounts(1, 2, 3)
+	require.Equal(t, 1, failover.Count(0))
+	require.Equal(t, 2, failover.Count(1))
+	require.Equal(t, 3, failover.Count(2))
+	require.Equal(t, 0, failover.Count(3))
+}
+
+func TestGCPFailoverSetCountFiveLocations(t *testing.T) {
+	failover := &GCPFailover{}
+	failover.Instances = []int{1, 1, 1, 1, 1}
+
+	failover.SetCounts(0, 0, 0, 1)
+	require.Equal(t, []int{0, 0, 0, 1, 1}, failover.FailoverInstances)
+	require.Equal(t, 2, failover.InstancesCount())
+}
+
+func TestGCPFailoverStateUpgradeV0(t *testing.T) {
+	type legacyFailover struct {
+		Prefix          string
+		FailoverMode    resource.FailOverMode
+		MetricName      string
+		MetricNameSpace string
+		Instances       []int
+		Locations       []string
+		PrimaryCount    int
+		SecondaryCount  int
+		TertiaryCount   int
+	}
+
+	legacy := struct {
+		Failover legacyFailover
+		Project  string
+	}{
+		Failover: legacyFailover{
+			Prefix:          "test",
+			FailoverMode:    resource.FailOverModeSingle,
+			MetricName:      "test",
+			MetricNameSpace: "test",
+			Instances:       []int{1, 1, 1},
+			Locations:       []string{"1", "2", "3"},
+			SecondaryCount:  1,
 		},
 		Project: "test",
 	}
 
-	id, err := failoverOrig.ID()
+	id, err := resource.BsonPack(legacy)
 	require.NoError(t, err)
 
-	d := &schema.ResourceData{}
-	d.SetId(id)
+	rawState := map[string]interface{}{
+		"id":                                id,
+		resource.PrefixFieldName:            "test",
+		resource.FailoverModeFieldName:      "single",
+		resource.FailoverInstancesFieldName: []interface{}{},
+	}
 
-	failoverUnpack := &GCPFailover{}
-	err = failoverUnpack.FromIDOrSchema(d)
+	state, err := resourcePolkadotFailoverStateUpgradeV0(context.Background(), rawState, nil)
 	require.NoError(t, err)
-	require.Equal(t, failoverOrig, failoverUnpack)
+	require.Equal(t, "v1/test/single", state["id"])
+	require.Equal(t, "test", state[ProjectFieldName])
+	require.Equal(t, []int{0, 1, 0}, state[resource.FailoverInstancesFieldName])
+	require.Equal(t, []string{"1", "2", "3"}, state[resource.LocationsFieldName])
 
+	state, err = resourcePolkadotFailoverStateUpgradeV0(context.Background(), state, nil)
+	require.NoError(t, err)
+	require.Equal(t, "v1/test/single", state["id"])
 }
 
-func TestGCPFailoverSetCount(t *testing.T) {
+func TestGCPFailoverLocationProjects(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourcePolkadotFailover().Schema, map[string]interface{}{
+		resource.PrefixFieldName:    "test",
+		resource.InstancesFieldName: []interface{}{1, 1, 1},
+		resource.LocationsFieldName: []interface{}{"us-east1", "europe-west3", "asia-east1"},
+		ProjectFieldName:            "scope",
+		LocationProjectsFieldName: map[string]interface{}{
+			"us-east1":   "project-a",
+			"asia-east1": "project-a",
+		},
+	})
+
 	failover := &GCPFailover{}
+	require.NoError(t, failover.FromIDOrSchema(d))
 
-	failover.SetCounts(1, 2, 3)
-	require.Equal(t, 1, failover.PrimaryCount)
-	require.Equal(t, 2, failover.SecondaryCount)
-	require.Equal(t, 3, failover.TertiaryCount)
+	require.Equal(t, "project-a", failover.LocationProject("us-east1"))
+	require.Equal(t, "scope", failover.LocationProject("europe-west3"))
+
+	require.Equal(t, []gcp.ProjectRegions{
+		{Project: "project-a", Regions: []string{"us-east1", "asia-east1"}},
+		{Project: "scope", Regions: []string{"europe-west3"}},
+	}, failover.ProjectRegions())
+
+	require.Equal(t, gcp.MetricsScope{Project: "scope", Projects: []string{"project-a", "scope"}}, failover.MetricsScope())
+
+	require.NoError(t, validateLocationProjects(failover.Locations, failover.LocationProjects))
+	require.Error(t, validateLocationProjects(failover.Locations, map[string]string{"us-west1": "project-b"}))
+}
+
+func TestGCPFailoverImportLocationProjects(t *testing.T) {
+	failover, err := parseImportID("test/single/us-east1=2@project-1,us-west1=2@project-2,europe-west1=2/polkadot/value", "test")
+	require.NoError(t, err)
+	require.Equal(t, []gcp.ProjectRegions{
+		{Project: "project-1", Regions: []string{"us-east1"}},
+		{Project: "project-2", Regions: []string{"us-west1"}},
+		{Project: "test", Regions: []string{"europe-west1"}},
+	}, failover.ProjectRegions())
+
+	failover.SetImportedCounts([]int{1, 0, 0}, 0)
+
+	d := schema.TestResourceDataRaw(t, resourcePolkadotFailover().Schema, map[string]interface{}{})
+	require.NoError(t, setImportedSchemaValues(d, failover))
+	require.Equal(t, "test", d.Get(ProjectFieldName))
+	require.Equal(t, map[string]interface{}{"us-east1": "project-1", "us-west1": "project-2"}, d.Get(LocationProjectsFieldName))
+
+	// plan after import does not replace the resource
+	id, err := failover.ID()
+	require.NoError(t, err)
+	d.SetId(id)
+	res := resourcePolkadotFailover()
+	// plan customization queries instances
+	res.CustomizeDiff = nil
+	diff, err := res.Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(map[string]interface{}{
+		resource.PrefixFieldName:          "test",
+		resource.FailoverModeFieldName:    "single",
+		resource.LocationsFieldName:       []interface{}{"us-east1", "us-west1", "europe-west1"},
+		resource.InstancesFieldName:       []interface{}{2, 2, 2},
+		resource.MetricNamespaceFieldName: "polkadot",
+		resource.MetricNameFieldName:      "value",
+		ProjectFieldName:                  "test",
+		LocationProjectsFieldName:         map[string]interface{}{"us-east1": "project-1", "us-west1": "project-2"},
+	}), nil)
+	require.NoError(t, err)
+	require.False(t, diff != nil && diff.RequiresNew(), "unexpected replacement: %v", diff)
+
+	failover, err = parseImportID("test/single/us-east1=2,us-west1=2,europe-west1=2/polkadot/value", "test")
+	require.NoError(t, err)
+	require.Nil(t, failover.LocationProjects)
 }